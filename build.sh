@@ -29,7 +29,7 @@ fi
 SERVER_URL_B64=$(echo -n "$SERVER_URL" | base64)
 
 echo "Building server..."
-go build -ldflags "-s -w" -o "$RELEASE_DIR/server" main.go
+go build -ldflags "-s -w" -o "$RELEASE_DIR/server" .
 
 upx --best --lzma "$RELEASE_DIR/server"
 chmod +x "$RELEASE_DIR/server"
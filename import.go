@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/config"
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+const importUsage = `Usage: server import -format slack|irssi|weechat (-room <id> | -owner <user> [-title <title>]) [flags] <file>
+
+Imports a Slack export ZIP or an irssi/WeeChat channel log into a chatroom. Messages
+keep their original times. Authors are matched to the user with the same name, or the
+one given with -map, and otherwise to a placeholder user such as alice@slack. Running an
+import again only adds what is new.
+
+History is ordered as it is stored, so import into a new room, or an existing one
+before it is used.
+
+Flags:
+`
+
+// runImport implements "server import".
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), importUsage)
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "", "what the file is: slack, irssi or weechat")
+	roomID := fs.Uint("room", 0, "id of an existing room to import into")
+	owner := fs.String("owner", "", "user who owns the new room to import into")
+	title := fs.String("title", "", "title of the new room (default: the channel or file name)")
+	public := fs.Bool("public", false, "make the new room public")
+	channel := fs.String("channel", "", "Slack channel to import, when the export has several")
+	tz := fs.String("tz", "Local", "time zone of the times in an IRC log, e.g. Europe/Berlin")
+	mapping := fs.String("map", "", "authors to attribute to existing users, as name=user,name=user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || (*roomID == 0) == (*owner == "") {
+		fs.Usage()
+		return errors.New("import: need one file, and either -room or -owner")
+	}
+	source, err := services.ParseImportSource(*format)
+	if err != nil {
+		return fmt.Errorf("import: -format must be slack, irssi or weechat")
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	opts := services.ImportOptions{Users: make(map[string]string)}
+	for _, pair := range strings.Split(*mapping, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, user, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || user == "" {
+			return fmt.Errorf("import: bad -map entry %q", pair)
+		}
+		opts.Users[name] = user
+	}
+
+	path := fs.Arg(0)
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer f.Close()
+	var history []services.ImportedMessage
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if source == services.ImportSlack {
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		history, name, err = services.ReadSlackExport(f, info.Size(), *channel)
+		if err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+	} else if history, err = services.ReadIRCLog(f, source, loc); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	if err := config.InitDB(); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	importer := services.NewImportService(
+		repositories.DefaultMessageRepository(),
+		repositories.DefaultUserRepository(),
+		repositories.DefaultChatroomRepository(),
+	)
+	var room *models.Chatroom
+	if *roomID != 0 {
+		room, err = importer.Room(*roomID)
+	} else {
+		if *title == "" {
+			*title = name
+		}
+		room, err = importer.CreateRoom(*title, *owner, *public)
+	}
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	result, err := importer.Import(room, source, history, opts)
+	if err != nil {
+		return fmt.Errorf("import: after %d messages: %w", result.Imported+result.Skipped, err)
+	}
+	fmt.Printf("Imported %d messages into %q (room %d); %d were already there.\n", result.Imported, room.Title, room.Id, result.Skipped)
+	if len(result.Placeholders) > 0 {
+		fmt.Printf("Placeholder authors: %s\n", strings.Join(result.Placeholders, ", "))
+	}
+	return nil
+}
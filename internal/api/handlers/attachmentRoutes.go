@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+// multipartMemory is how much of an upload is buffered in memory before it spills to disk.
+const multipartMemory = 1 << 20
+
+// UploadAttachment posts a message carrying a file. The body is multipart/form-data with
+// a "file" part and optional "content" (caption) and "client_id" fields.
+func UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	// Leave room for the form fields and part headers around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAttachmentSize+multipartMemory)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart body", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	msg, sender, err := Svcs.Attachment.Upload(r.Context(), userID, uint(chatroomID), services.AttachmentUpload{
+		Name:     header.Filename,
+		Body:     file,
+		Caption:  r.FormValue("content"),
+		ClientID: r.FormValue("client_id"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAttachmentTooLarge):
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, services.ErrEmptyAttachment):
+			http.Error(w, "File is empty", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidFileName):
+			http.Error(w, "Invalid file name", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidClientID):
+			http.Error(w, "Invalid client id", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidTTL):
+			http.Error(w, "Invalid message ttl", http.StatusBadRequest)
+		case errors.Is(err, services.ErrMuted):
+			http.Error(w, "You are muted in this chatroom", http.StatusForbidden)
+		case errors.Is(err, services.ErrCannotPost):
+			http.Error(w, "Your role cannot post in this chatroom", http.StatusForbidden)
+		case errors.Is(err, services.ErrSlowMode):
+			http.Error(w, "Slow mode is on; wait before posting again", http.StatusTooManyRequests)
+		default:
+			log.Printf("upload attachment failed: %v", err)
+			http.Error(w, "Unable to upload file", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":  "success",
+		"Message": msg,
+		"Sender":  sender,
+	})
+}
+
+// DownloadAttachment streams an attachment of the room. The X-Content-SHA256 header
+// lets clients verify what they received.
+func DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	chatroomID, attachmentID, ok := attachmentPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, body, err := Svcs.Attachment.Open(r.Context(), chatroomID, attachmentID)
+	if err != nil {
+		attachmentOpenError(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-SHA256", attachment.SHA256)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("stream attachment %d failed: %v", attachment.ID, err)
+	}
+}
+
+// DownloadThumbnail streams the PNG thumbnail of an image attachment.
+func DownloadThumbnail(w http.ResponseWriter, r *http.Request) {
+	chatroomID, attachmentID, ok := attachmentPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, body, err := Svcs.Attachment.OpenThumbnail(r.Context(), chatroomID, attachmentID)
+	if err != nil {
+		attachmentOpenError(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("stream thumbnail of attachment %d failed: %v", attachment.ID, err)
+	}
+}
+
+func attachmentPathIDs(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.ParseUint(r.PathValue("attachmentId"), 10, 64)
+	if err != nil || attachmentID == 0 {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uint(chatroomID), uint(attachmentID), true
+}
+
+func attachmentOpenError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrAttachmentNotFound) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("open attachment failed: %v", err)
+	http.Error(w, "Unable to read attachment", http.StatusInternalServerError)
+}
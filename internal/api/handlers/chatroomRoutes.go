@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/services"
 	"github.com/Wal-20/cli-chat-app/internal/utils"
 	"gorm.io/gorm"
 )
@@ -20,7 +23,7 @@ func GetChatrooms(w http.ResponseWriter, r *http.Request) {
 
 	if id == "" {
 		var chatrooms []models.Chatroom
-		result := config.DB.Find(&chatrooms)
+		result := config.DB.Where("is_direct = ?", false).Find(&chatrooms)
 
 		if result.Error != nil {
 			http.Error(w, "Failed to retrieve chatrooms", http.StatusInternalServerError)
@@ -33,7 +36,7 @@ func GetChatrooms(w http.ResponseWriter, r *http.Request) {
 
 	} else {
 		var chatroom models.Chatroom
-		result := config.DB.First(&chatroom, id)
+		result := config.DB.Where("is_direct = ?", false).First(&chatroom, id)
 
 		if result.Error != nil {
 			http.Error(w, "Failed to retrieve chatroom", http.StatusInternalServerError)
@@ -91,36 +94,54 @@ func GetUsersByChatroom(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetMessagesByChatroom(w http.ResponseWriter, r *http.Request) {
-	chatroomId := r.PathValue("id")
 	encoder := json.NewEncoder(w)
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok || userID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	if chatroomId == "" {
+	chatroomId, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomId == 0 {
 		http.Error(w, "Please provide a valid ID", http.StatusBadRequest)
 		return
 	}
 
-	searchTerms := r.URL.Query().Get("search")
-	var messages []models.MessageWithUser
-
-	query := config.DB.
-		Table("messages").
-		Select("messages.content, messages.created_at, users.name AS username").
-		Joins("JOIN users ON messages.user_id = users.id").
-		Where("messages.chatroom_id = ?", chatroomId)
-
-	if searchTerms != "" {
-		query = query.Where("messages.content LIKE ?", "%"+searchTerms+"%")
+	params := r.URL.Query()
+	query := repositories.MessageQuery{Search: params.Get("search")}
+	for name, target := range map[string]*uint{"before": &query.Before, "after": &query.After} {
+		if raw := params.Get(name); raw != "" {
+			v, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s cursor", name), http.StatusBadRequest)
+				return
+			}
+			*target = uint(v)
+		}
+	}
+	if query.Before != 0 && query.After != 0 {
+		http.Error(w, "Use either before or after, not both", http.StatusBadRequest)
+		return
+	}
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
 	}
 
-	err := query.Order("messages.created_at ASC").Limit(20).Scan(&messages).Error
+	page, err := Svcs.Message.GetMessages(uint(chatroomId), query)
 	if err != nil {
 		http.Error(w, "No messages found", http.StatusNotFound)
 		return
 	}
+	if err := Svcs.Poll.MarkVoted(userID, page.Messages); err != nil {
+		log.Printf("poll votes lookup failed: %v", err)
+	}
 
-	encoder.Encode(map[string]any{
-		"Messages": messages,
-	})
+	encoder.Encode(page)
 }
 
 func CreateChatroom(w http.ResponseWriter, r *http.Request) {
@@ -136,6 +157,7 @@ func CreateChatroom(w http.ResponseWriter, r *http.Request) {
 		Title        string `json:"title"`
 		MaxUserCount uint   `json:"maxUserCount"`
 		IsPublic     bool   `json:"is_public"`
+		MessageTTL   uint   `json:"message_ttl"` // seconds; 0 keeps messages
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -144,25 +166,37 @@ func CreateChatroom(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	// Validate input (recipient optional)
-	if requestBody.RecipientID == userID && requestBody.RecipientID != 0 {
-		http.Error(w, "Cannot create a chatroom with yourself", http.StatusBadRequest)
-		return
-	}
-
-	// Fetch owner (and recipient if provided)
-	var users []models.User
+	// A recipient makes this a direct message, which has its own rules.
 	if requestBody.RecipientID != 0 {
-		userIDs := []uint{userID, requestBody.RecipientID}
-		if err := config.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil || len(users) != 2 {
-			http.Error(w, "Users not found", http.StatusBadRequest)
+		conv, created, err := Svcs.Direct.Open(userID, strconv.FormatUint(uint64(requestBody.RecipientID), 10))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrUserNotFound):
+				http.Error(w, "Users not found", http.StatusBadRequest)
+			case errors.Is(err, services.ErrDirectWithSelf):
+				http.Error(w, "Cannot create a chatroom with yourself", http.StatusBadRequest)
+			default:
+				http.Error(w, "Failed to create chatroom", http.StatusInternalServerError)
+			}
 			return
 		}
-	} else {
-		if err := config.DB.Where("id = ?", userID).Find(&users).Error; err != nil || len(users) != 1 {
-			http.Error(w, "User not found", http.StatusBadRequest)
-			return
+		w.Header().Set("Content-Type", "application/json")
+		if created {
+			w.WriteHeader(http.StatusCreated)
 		}
+		json.NewEncoder(w).Encode(conv.Chatroom)
+		return
+	}
+
+	if time.Duration(requestBody.MessageTTL)*time.Second > services.MaxMessageTTL {
+		http.Error(w, "Invalid message ttl", http.StatusBadRequest)
+		return
+	}
+
+	var users []models.User
+	if err := config.DB.Where("id = ?", userID).Find(&users).Error; err != nil || len(users) != 1 {
+		http.Error(w, "User not found", http.StatusBadRequest)
+		return
 	}
 
 	// Create chatroom
@@ -171,6 +205,7 @@ func CreateChatroom(w http.ResponseWriter, r *http.Request) {
 		Title:        requestBody.Title,
 		IsPublic:     requestBody.IsPublic,
 		MaxUserCount: requestBody.MaxUserCount,
+		MessageTTL:   requestBody.MessageTTL,
 	}
 	if err := config.DB.Create(&newChatRoom).Error; err != nil {
 		http.Error(w, "Failed to create chatroom", http.StatusInternalServerError)
@@ -182,9 +217,6 @@ func CreateChatroom(w http.ResponseWriter, r *http.Request) {
 	userChatrooms := []models.UserChatroom{
 		{UserID: userID, Name: users[0].Name, ChatroomID: newChatRoom.Id, IsJoined: true, LastJoinTime: &now, IsAdmin: true, IsOwner: true},
 	}
-	if requestBody.RecipientID != 0 {
-		userChatrooms = append(userChatrooms, models.UserChatroom{UserID: requestBody.RecipientID, Name: users[1].Name, ChatroomID: newChatRoom.Id, IsJoined: false, LastJoinTime: &now})
-	}
 	if err := config.DB.
 		Select("UserID", "Name", "ChatroomID", "IsJoined", "IsAdmin", "IsOwner", "LastJoinTime").
 		Create(&userChatrooms).Error; err != nil {
@@ -200,18 +232,13 @@ func CreateChatroom(w http.ResponseWriter, r *http.Request) {
 
 func DeleteChatroom(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	isOwner := r.Context().Value("isOwner").(bool)
+	userID, _ := r.Context().Value("userID").(uint)
 
 	if id == "" {
 		http.Error(w, "Please provide a valid ID", http.StatusBadRequest)
 		return
 	}
 
-	if !isOwner {
-		http.Error(w, "Unauthorized, user not the owner", http.StatusUnauthorized)
-		return
-	}
-
 	var chatroom models.Chatroom
 	if err := config.DB.First(&chatroom, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -222,12 +249,23 @@ func DeleteChatroom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Deleting the room is the one thing no role can grant.
+	if chatroom.OwnerId != userID {
+		http.Error(w, "Unauthorized, user not the owner", http.StatusUnauthorized)
+		return
+	}
+
 	// Delete associated UserChatroom entries
 	if err := config.DB.Where("chatroom_id = ?", chatroom.Id).Delete(&models.UserChatroom{}).Error; err != nil {
 		http.Error(w, "Error deleting user-chatroom links", http.StatusInternalServerError)
 		return
 	}
 
+	if err := config.DB.Where("chatroom_id = ?", chatroom.Id).Delete(&models.Role{}).Error; err != nil {
+		http.Error(w, "Error deleting chatroom roles", http.StatusInternalServerError)
+		return
+	}
+
 	// Delete chatroom
 	if err := config.DB.Delete(&chatroom).Error; err != nil {
 		http.Error(w, "Error deleting chatroom", http.StatusInternalServerError)
@@ -267,6 +305,10 @@ func JoinChatroom(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if chatroom.IsDirect {
+		http.Error(w, "Direct messages are limited to their two members", http.StatusForbidden)
+		return
+	}
 	if _, err := Svcs.Chat.JoinChatroom(userID, username, chatroomID); err != nil {
 		http.Error(w, "Error adding user to chatroom", http.StatusInternalServerError)
 		return
@@ -314,44 +356,11 @@ func JoinChatroom(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func transferOwnership(chatroomId string) error {
-	var newOwner models.UserChatroom
-
-	// Try to find an admin first
-	err := config.DB.Where("chatroom_id = ? AND is_admin = ?", chatroomId, true).
-		Order("last_join_time ASC").
-		First(&newOwner).Error
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// No admin found, assign oldest non-admin user
-		err = config.DB.Where("chatroom_id = ?", chatroomId).
-			Order("last_join_time ASC").
-			First(&newOwner).Error
-	}
-
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil
-	} else if err != nil {
-		return err
-	}
-
-	newOwner.IsOwner = true
-	newOwner.IsAdmin = true
-	if err := config.DB.Save(&newOwner).Error; err != nil {
-		return err
-	}
-
-	return config.DB.Model(&models.Chatroom{}).
-		Where("id = ?", chatroomId).
-		Update("owner_id", newOwner.UserID).Error
-}
-
 func LeaveChatroom(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value("userID").(uint)
 	chatroomId := r.PathValue("id")
 
-	cacheKey := fmt.Sprintf("membership:%v:%s", userId, chatroomId)
-	utils.MembershipCache.Delete(cacheKey)
+	utils.ForgetMembership(userId, chatroomId)
 
 	var membership models.UserChatroom
 	if err := config.DB.Where("user_id = ? AND chatroom_id = ?", userId, chatroomId).First(&membership).Error; err != nil {
@@ -375,13 +384,139 @@ func LeaveChatroom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if wasOwner {
-		if err := transferOwnership(chatroomId); err != nil {
-			http.Error(w, "Failed to transfer ownership after leave", http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// MarkChatroomRead advances the caller's read marker in the room to the given message.
+func MarkChatroomRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uint)
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		MessageID uint `json:"message_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.MessageID == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := Svcs.Chat.MarkRead(userID, uint(chatroomID), requestBody.MessageID); err != nil {
+		http.Error(w, "Error updating read marker", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Read marker updated"})
+}
+
+// SetMessageTTL changes the room's default lifetime for new messages (manage_room only).
+func SetMessageTTL(w http.ResponseWriter, r *http.Request) {
+	if !permissions(r).Has(models.PermManageRoom) {
+		http.Error(w, "Your role cannot change the message ttl", http.StatusForbidden)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		TTL uint `json:"ttl"` // seconds; 0 keeps messages
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	room, err := Svcs.Chat.SetMessageTTL(uint(chatroomID), time.Duration(requestBody.TTL)*time.Second)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTTL) {
+			http.Error(w, "Invalid message ttl", http.StatusBadRequest)
 			return
 		}
+		http.Error(w, "Error updating message ttl", http.StatusInternalServerError)
+		return
 	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Message ttl updated", "Chatroom": room})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(res)
+// SetTopic changes the room's topic line (manage_room only). An empty topic clears it.
+func SetTopic(w http.ResponseWriter, r *http.Request) {
+	if !permissions(r).Has(models.PermManageRoom) {
+		http.Error(w, "Your role cannot change the topic", http.StatusForbidden)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody struct {
+		Topic string `json:"topic"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	room, err := Svcs.Chat.SetTopic(uint(chatroomID), requestBody.Topic)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTopic) {
+			http.Error(w, "Topic must be a single line of at most 255 characters", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error updating topic", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Topic updated", "Chatroom": room})
+}
+
+// UpdateChatroom changes any of the room's title, topic, visibility, capacity, slow mode
+// and message ttl (manage_room only). Fields left out of the body stay as they are.
+func UpdateChatroom(w http.ResponseWriter, r *http.Request) {
+	if !permissions(r).Has(models.PermManageRoom) {
+		http.Error(w, "Your role cannot change the room's settings", http.StatusForbidden)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var settings services.RoomSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	room, err := Svcs.Chat.UpdateSettings(uint(chatroomID), settings)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "Chatroom not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrDirectSettings):
+			http.Error(w, "Direct conversations have no settings", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidTitle):
+			http.Error(w, fmt.Sprintf("Title must be a single line of 1 to %d characters", services.MaxTitleLength), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidTopic):
+			http.Error(w, fmt.Sprintf("Topic must be a single line of at most %d characters", services.MaxTopicLength), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidCapacity):
+			http.Error(w, fmt.Sprintf("Capacity must be between 2 and %d, and no less than the current members", services.MaxRoomCapacity), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidSlowMode):
+			http.Error(w, fmt.Sprintf("Slow mode can be at most %v", services.MaxSlowMode), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidTTL):
+			http.Error(w, "Invalid message ttl", http.StatusBadRequest)
+		default:
+			http.Error(w, "Error updating chatroom", http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Chatroom updated", "Chatroom": room})
 }
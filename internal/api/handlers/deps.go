@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"log"
+
+	"github.com/Wal-20/cli-chat-app/internal/api/ws"
 	"github.com/Wal-20/cli-chat-app/internal/repositories"
 	"github.com/Wal-20/cli-chat-app/internal/services"
+	"github.com/Wal-20/cli-chat-app/internal/storage"
 )
 
 // Svcs holds initialized service singletons for handlers to use.
@@ -11,15 +15,37 @@ var Svcs struct {
 	Chat         *services.ChatroomService
 	Message      *services.MessageService
 	Notification *services.NotificationService
+	Scheduled    *services.ScheduledMessageService
+	Attachment   *services.AttachmentService
+	Direct       *services.DirectMessageService
+	Poll         *services.PollService
+	Export       *services.ExportService
+	Member       *services.MemberService
+	Role         *services.RoleService
 }
 
 func InitHandlers() {
 	userRepo := repositories.DefaultUserRepository()
 	chatRepo := repositories.DefaultChatroomRepository()
 	msgRepo := repositories.DefaultMessageRepository()
+	notificationRepo := repositories.DefaultNotificationRepository()
 
 	Svcs.Auth = services.NewAuthService(userRepo)
 	Svcs.Chat = services.NewChatroomService(chatRepo)
-	Svcs.Message = services.NewMessageService(msgRepo, userRepo)
+	Svcs.Message = services.NewMessageService(msgRepo, userRepo, notificationRepo, chatRepo)
 	Svcs.Notification = services.NewNotificationService()
+	Svcs.Direct = services.NewDirectMessageService(chatRepo, userRepo, notificationRepo)
+	Svcs.Poll = services.NewPollService(repositories.DefaultPollRepository(), Svcs.Message)
+	Svcs.Export = services.NewExportService(msgRepo, chatRepo)
+	Svcs.Member = services.NewMemberService(chatRepo)
+	Svcs.Role = services.NewRoleService(chatRepo)
+	Svcs.Scheduled = services.NewScheduledMessageService(repositories.DefaultScheduledMessageRepository(), Svcs.Message, chatRepo)
+
+	blobs, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Blob store not configured: %v", err)
+	}
+	Svcs.Attachment = services.NewAttachmentService(blobs, Svcs.Message, msgRepo)
+
+	ws.HandleSendMessage(handleSocketSend)
 }
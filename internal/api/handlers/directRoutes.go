@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+// OpenDirectMessage returns the caller's conversation with a user, given by id or name,
+// creating it if needed (201) and notifying the recipient.
+func OpenDirectMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok || userID == 0 {
+		http.Error(w, "Unauthorized: missing or invalid user ID", http.StatusUnauthorized)
+		return
+	}
+	peer := r.PathValue("userId")
+	if peer == "" {
+		http.Error(w, "No valid user identifier provided", http.StatusBadRequest)
+		return
+	}
+
+	conv, created, err := Svcs.Direct.Open(userID, peer)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			http.Error(w, "User not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrDirectWithSelf):
+			http.Error(w, "Cannot message yourself", http.StatusBadRequest)
+		default:
+			http.Error(w, "Error opening conversation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	status := "Conversation opened"
+	if created {
+		status = "Conversation created"
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":       status,
+		"Conversation": conv,
+	})
+}
+
+// GetDirectMessages lists the caller's conversations, most recently active first.
+func GetDirectMessages(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uint)
+
+	conversations, err := Svcs.Direct.List(userID)
+	if err != nil {
+		http.Error(w, "Error retrieving conversations", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Conversations": conversations,
+	})
+}
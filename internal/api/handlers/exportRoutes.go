@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/services"
+	"gorm.io/gorm"
+)
+
+// ExportChatroom streams the room's history as a transcript. Query parameters:
+// format (jsonl, md or html; jsonl by default) and from/to, each an RFC 3339 time or a
+// YYYY-MM-DD date in UTC. from is inclusive; to is exclusive, except that a date covers
+// that whole day.
+func ExportChatroom(w http.ResponseWriter, r *http.Request) {
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := services.ExportOptions{Format: services.ExportJSONLines}
+	if name := query.Get("format"); name != "" {
+		if opts.Format, err = services.ParseExportFormat(name); err != nil {
+			http.Error(w, "Format must be jsonl, md or html", http.StatusBadRequest)
+			return
+		}
+	}
+	if opts.From, err = parseExportBound(query.Get("from"), false); err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+	if opts.To, err = parseExportBound(query.Get("to"), true); err != nil {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+	if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+	opts.AttachmentURL = func(attachment *models.Attachment) string {
+		return fmt.Sprintf("%s/api/chatrooms/%d/attachments/%d", requestOrigin(r), chatroomID, attachment.ID)
+	}
+
+	room, err := Svcs.Export.Room(uint(chatroomID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Chatroom not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Unable to export chatroom", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Nothing is written before the transcript starts streaming, so a failure after that
+	// can only cut the download short; it is logged.
+	filename := fmt.Sprintf("%s-%d.%s", exportSlug(room.Title), chatroomID, opts.Format)
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	buffered := bufio.NewWriterSize(w, 32<<10)
+	err = Svcs.Export.Export(r.Context(), buffered, room, opts)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		log.Printf("export of chatroom %d failed: %v", chatroomID, err)
+	}
+}
+
+func parseExportBound(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// requestOrigin is the scheme and host the client reached the server on.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// exportSlug turns a room title into a file name stem.
+func exportSlug(title string) string {
+	slug := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			return c
+		case c >= 'A' && c <= 'Z':
+			return c + 'a' - 'A'
+		}
+		return '-'
+	}, title)
+	slug = strings.Trim(slug, "-")
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	if slug == "" {
+		return "chatroom"
+	}
+	return slug
+}
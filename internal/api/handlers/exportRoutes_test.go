@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExportBound(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		end     bool
+		want    string // RFC3339, "" for no bound
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "timestamp", value: "2026-03-01T10:30:00Z", want: "2026-03-01T10:30:00Z"},
+		{name: "timestamp as end is exact", value: "2026-03-01T10:30:00+02:00", end: true, want: "2026-03-01T10:30:00+02:00"},
+		{name: "date as start", value: "2026-03-01", want: "2026-03-01T00:00:00Z"},
+		{name: "date as end covers the whole day", value: "2026-03-01", end: true, want: "2026-03-02T00:00:00Z"},
+		{name: "garbage", value: "last week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExportBound(tt.value, tt.end)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseExportBound(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("parseExportBound(%q) = %v, want nil", tt.value, got)
+				}
+				return
+			}
+			if got == nil || got.Format(time.RFC3339) != tt.want {
+				t.Errorf("parseExportBound(%q) = %v, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "General", want: "general"},
+		{title: "Team Ops 2026", want: "team-ops-2026"},
+		{title: "  --Release!! notes--  ", want: "release-notes"},
+		{title: "café", want: "caf"},
+		{title: "???", want: "chatroom"},
+		{title: "", want: "chatroom"},
+	}
+	for _, tt := range tests {
+		if got := exportSlug(tt.title); got != tt.want {
+			t.Errorf("exportSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
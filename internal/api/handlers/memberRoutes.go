@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/services"
+	"github.com/Wal-20/cli-chat-app/internal/utils"
+)
+
+// KickUser removes a member from the room.
+func KickUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.Kick(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	utils.ForgetMembership(targetID, chatroomID)
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User kicked",
+		"User status": member,
+	})
+}
+
+// MuteUser stops a member posting in the room. The body gives the duration in seconds,
+// 0 or absent until they are unmuted, and an optional reason.
+func MuteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody struct {
+		Duration uint   `json:"duration"` // seconds
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	member, err := Svcs.Member.Mute(actorID, chatroomID, targetID, time.Duration(requestBody.Duration)*time.Second, requestBody.Reason)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User muted",
+		"User status": member,
+	})
+}
+
+// UnmuteUser lets a muted member post again.
+func UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.Unmute(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User unmuted",
+		"User status": member,
+	})
+}
+
+// BanUser removes a member and stops them rejoining. The optional body gives the
+// duration in seconds, 0 or absent until they are unbanned, and a reason.
+func BanUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody struct {
+		Duration uint   `json:"duration"` // seconds
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	member, err := Svcs.Member.Ban(actorID, chatroomID, targetID, time.Duration(requestBody.Duration)*time.Second, requestBody.Reason)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	utils.ForgetMembership(targetID, chatroomID)
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User banned",
+		"User status": member,
+	})
+}
+
+// UnbanUser lifts a ban so the user can join again.
+func UnbanUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.Unban(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User unbanned",
+		"User status": member,
+	})
+}
+
+// GetBans lists the room's banned members for those whose role may ban.
+func GetBans(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
+		return
+	}
+	bans, err := Svcs.Member.Bans(userID, uint(chatroomID))
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"Bans": bans})
+}
+
+// PromoteUser makes a member an admin (manage_roles only).
+func PromoteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.Promote(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User promoted to admin",
+		"User status": member,
+	})
+}
+
+// DemoteUser takes the admin role from a member (manage_roles only).
+func DemoteUser(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.Demote(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "User demoted",
+		"User status": member,
+	})
+}
+
+// OfferOwnership offers the room to a member, who must accept it (owner only).
+func OfferOwnership(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.OfferOwnership(actorID, chatroomID, targetID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "Ownership offered",
+		"User status": member,
+	})
+}
+
+// WithdrawOwnership withdraws the owner's offer of the room to a member.
+func WithdrawOwnership(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := Svcs.Member.DeclineOwnership(actorID, chatroomID, targetID); err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Ownership offer withdrawn"})
+}
+
+// AssignRole gives a member one of the room's roles. The body names it by id.
+func AssignRole(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, targetID, ok := memberPathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody struct {
+		RoleID uint `json:"role_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.RoleID == 0 {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	member, err := Svcs.Member.AssignRole(actorID, chatroomID, targetID, requestBody.RoleID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "Role assigned",
+		"User status": member,
+	})
+}
+
+// AcceptOwnership makes the caller the owner of a room they were offered.
+func AcceptOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, chatroomID, ok := ownershipPathIDs(w, r)
+	if !ok {
+		return
+	}
+	member, err := Svcs.Member.AcceptOwnership(userID, chatroomID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":      "You are now the owner",
+		"User status": member,
+	})
+}
+
+// DeclineOwnership turns down the caller's offer of a room.
+func DeclineOwnership(w http.ResponseWriter, r *http.Request) {
+	userID, chatroomID, ok := ownershipPathIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := Svcs.Member.DeclineOwnership(userID, chatroomID, userID); err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Ownership declined"})
+}
+
+func ownershipPathIDs(w http.ResponseWriter, r *http.Request) (userID, chatroomID uint, ok bool) {
+	userID, ok = r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	room, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || room == 0 {
+		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, uint(room), true
+}
+
+func memberPathIDs(w http.ResponseWriter, r *http.Request) (actorID, chatroomID, targetID uint, ok bool) {
+	actorID, ok = r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, 0, false
+	}
+	room, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || room == 0 {
+		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	target, err := strconv.ParseUint(r.PathValue("userId"), 10, 64)
+	if err != nil || target == 0 {
+		http.Error(w, "No valid user ID provided", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return actorID, uint(room), uint(target), true
+}
+
+func writeMemberError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrNoPermission):
+		http.Error(w, "Your role does not allow that", http.StatusForbidden)
+	case errors.Is(err, services.ErrNotRoomOwner):
+		http.Error(w, "You are not the owner", http.StatusUnauthorized)
+	case errors.Is(err, services.ErrMemberNotFound):
+		http.Error(w, "User is not a member of this chatroom", http.StatusNotFound)
+	case errors.Is(err, services.ErrCannotModerate):
+		http.Error(w, "You cannot moderate this member", http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidMute):
+		http.Error(w, "A mute lasts at most a year and its reason at most 255 characters", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotMuted):
+		http.Error(w, "User is not muted", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidBan):
+		http.Error(w, "A ban lasts at most a year and its reason at most 255 characters", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAlreadyBanned):
+		http.Error(w, "User already banned", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotBanned):
+		http.Error(w, "User is not banned", http.StatusBadRequest)
+	case errors.Is(err, services.ErrAlreadyAdmin):
+		http.Error(w, "User is already an admin", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotAdmin):
+		http.Error(w, "User is not an admin", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNoOwnerOffer):
+		http.Error(w, "No pending ownership offer", http.StatusNotFound)
+	case errors.Is(err, services.ErrRoleNotFound):
+		http.Error(w, "Role not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidRole):
+		http.Error(w, "Role names are up to 50 letters, digits, - or _, and not owner, admin or member", http.StatusBadRequest)
+	case errors.Is(err, services.ErrRoleExists):
+		http.Error(w, "A role with that name already exists", http.StatusConflict)
+	case errors.Is(err, services.ErrBuiltInRole):
+		http.Error(w, "Built-in roles cannot be renamed, reranked, deleted or, for owner, granted", http.StatusBadRequest)
+	case errors.Is(err, services.ErrRoleTooHigh):
+		http.Error(w, "You can only manage roles ranked below yours, with permissions you hold", http.StatusForbidden)
+	default:
+		log.Printf("member moderation failed: %v", err)
+		http.Error(w, "Unable to update member", http.StatusInternalServerError)
+	}
+}
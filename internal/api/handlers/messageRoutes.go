@@ -5,9 +5,10 @@ import (
     "errors"
     "net/http"
     "strconv"
-    "github.com/Wal-20/cli-chat-app/internal/config"
+    "strings"
+    "time"
     "github.com/Wal-20/cli-chat-app/internal/models"
-    "gorm.io/gorm"
+    "github.com/Wal-20/cli-chat-app/internal/services"
 )
 
 func SendMessage(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +33,13 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-    var requestBody struct { Content string `json:"content"` }
+    var requestBody struct {
+        Content  string `json:"content"`
+        ParentID uint   `json:"parent_id"`
+        ClientID string `json:"client_id"`
+        TTL      uint   `json:"ttl"`  // seconds; 0 uses the room default
+        Kind     string `json:"kind"` // "text" (default) or "action"
+    }
 
 	if err := decoder.Decode(&requestBody); err != nil {
 		http.Error(w, "Unable to decode request body", http.StatusInternalServerError)
@@ -46,8 +53,47 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
     chatroomIdUint := uint(chatroomID)
-    msg, sender, err := Svcs.Message.SendMessage(senderID, chatroomIdUint, requestBody.Content)
+    opts := services.SendOptions{
+        ClientID: requestBody.ClientID,
+        TTL:      time.Duration(requestBody.TTL) * time.Second,
+        Kind:     requestBody.Kind,
+    }
+    var msg models.Message
+    var sender string
+    if requestBody.ParentID != 0 {
+        msg, sender, err = Svcs.Message.SendReply(senderID, chatroomIdUint, requestBody.ParentID, requestBody.Content, opts)
+    } else {
+        msg, sender, err = Svcs.Message.SendMessage(senderID, chatroomIdUint, requestBody.Content, opts)
+    }
     if err != nil {
+        if errors.Is(err, services.ErrMessageNotFound) {
+            http.Error(w, "Thread root not found", http.StatusNotFound)
+            return
+        }
+        if errors.Is(err, services.ErrInvalidClientID) {
+            http.Error(w, "Invalid client id", http.StatusBadRequest)
+            return
+        }
+        if errors.Is(err, services.ErrInvalidTTL) {
+            http.Error(w, "Invalid message ttl", http.StatusBadRequest)
+            return
+        }
+        if errors.Is(err, services.ErrInvalidKind) {
+            http.Error(w, "Invalid message kind", http.StatusBadRequest)
+            return
+        }
+        if errors.Is(err, services.ErrMuted) {
+            http.Error(w, "You are muted in this chatroom", http.StatusForbidden)
+            return
+        }
+        if errors.Is(err, services.ErrCannotPost) {
+            http.Error(w, "Your role cannot post in this chatroom", http.StatusForbidden)
+            return
+        }
+        if errors.Is(err, services.ErrSlowMode) {
+            http.Error(w, "Slow mode is on; wait before posting again", http.StatusTooManyRequests)
+            return
+        }
         http.Error(w, "Unable to create messsage", http.StatusInternalServerError)
         return
     }
@@ -60,55 +106,282 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 
 
 func DeleteMessage(w http.ResponseWriter, r *http.Request) {
-	messageId := r.PathValue("messageId")
-	chatroomId := r.PathValue("id")
-	isAdmin := r.Context().Value("isAdmin").(bool)
+	canDelete := permissions(r).Has(models.PermDeleteMessages)
+	userId, ok := r.Context().Value("userID").(uint)
+
+	if !ok {
+		http.Error(w,"Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	if messageId == "" || chatroomId  == "" {
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
 		http.Error(w, "Please provide a valid id for message and chatroom", http.StatusBadRequest)
 		return
 	}
-	userId, ok := r.Context().Value("userID").(uint)
+	messageID, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || messageID == 0 {
+		http.Error(w, "Please provide a valid id for message and chatroom", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := Svcs.Message.DeleteMessage(userId, uint(chatroomID), uint(messageID), canDelete)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMessageNotFound):
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNotMessageAuthor):
+			http.Error(w, "Cannot delete another user's message", http.StatusForbidden)
+		default:
+			http.Error(w, "Error deleting message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Status":  "Message Deleted Successfully",
+		"Message": msg,
+	})
+}
 
+func UpdateMessage(w http.ResponseWriter, r *http.Request) {
+	editorID, ok := r.Context().Value("userID").(uint)
 	if !ok {
-		http.Error(w,"Unauthorized", http.StatusUnauthorized)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+	messageID, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || messageID == 0 {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
 		return
 	}
 
-	var message models.Message
-	if err := config.DB.First(&message, messageId).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Cannot fetch message, not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error fetching message", http.StatusInternalServerError)
+	var requestBody struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	content := strings.TrimSpace(requestBody.Content)
+	if content == "" {
+		http.Error(w, "Message content cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := Svcs.Message.EditMessage(editorID, uint(chatroomID), uint(messageID), content)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMessageNotFound):
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNotMessageAuthor):
+			http.Error(w, "Cannot edit another user's message", http.StatusForbidden)
+		case errors.Is(err, services.ErrNotEditable):
+			http.Error(w, "Polls cannot be edited", http.StatusBadRequest)
+		case errors.Is(err, services.ErrMuted):
+			http.Error(w, "You are muted in this chatroom", http.StatusForbidden)
+		case errors.Is(err, services.ErrCannotPost):
+			http.Error(w, "Your role cannot post in this chatroom", http.StatusForbidden)
+		default:
+			http.Error(w, "Error updating message", http.StatusInternalServerError)
 		}
 		return
 	}
 
-	if !isAdmin && message.UserId != userId {
-		http.Error(w, "Cannot delete another user's message", http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Status":  "success",
+		"Message": msg,
+	})
+}
+
+// GetThread returns a root message together with its replies.
+func GetThread(w http.ResponseWriter, r *http.Request) {
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
 		return
 	}
-	
-	if result := config.DB.Delete(messageId); result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	messageID, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || messageID == 0 {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := Svcs.Message.GetThread(uint(chatroomID), uint(messageID))
+	if err != nil {
+		if errors.Is(err, services.ErrMessageNotFound) {
 			http.Error(w, "Message not found", http.StatusNotFound)
 			return
-		} else {
-			http.Error(w, "Error deleting message", http.StatusInternalServerError)
-			return
 		}
+		http.Error(w, "Error fetching thread", http.StatusInternalServerError)
+		return
+	}
+	userID, _ := r.Context().Value("userID").(uint)
+	root := []models.MessageWithUser{thread.Root}
+	if err := Svcs.Poll.MarkVoted(userID, root); err == nil {
+		thread.Root = root[0]
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"Status": "Message Deleted Successfully",
+	json.NewEncoder(w).Encode(thread)
+}
+
+// AddReaction adds the caller's emoji reaction to a message.
+func AddReaction(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		Emoji string `json:"emoji"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	handleReaction(w, r, requestBody.Emoji, Svcs.Message.AddReaction)
+}
+
+// RemoveReaction withdraws the caller's emoji reaction from a message.
+func RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	handleReaction(w, r, r.PathValue("emoji"), Svcs.Message.RemoveReaction)
+}
+
+func handleReaction(w http.ResponseWriter, r *http.Request, emoji string, apply func(userID, chatroomID, messageID uint, emoji string) ([]models.ReactionCount, error)) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+	messageID, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || messageID == 0 {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	reactions, err := apply(userID, uint(chatroomID), uint(messageID), emoji)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidEmoji):
+			http.Error(w, "Invalid emoji", http.StatusBadRequest)
+		case errors.Is(err, services.ErrMessageNotFound):
+			http.Error(w, "Message not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Error updating reactions", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":    "success",
+		"Reactions": reactions,
 	})
 }
 
-func UpdateMessage(w http.ResponseWriter, r *http.Request) {
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "message": "not implemented yet",
-    })
+// PinMessage pins a message for everyone in the room. Roles with the pin permission only.
+func PinMessage(w http.ResponseWriter, r *http.Request) {
+	handlePin(w, r, true)
+}
+
+// UnpinMessage removes a message from the room's pins, like PinMessage.
+func UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	handlePin(w, r, false)
+}
+
+func handlePin(w http.ResponseWriter, r *http.Request, pin bool) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	canPin := permissions(r).Has(models.PermPin)
+
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+	messageID, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || messageID == 0 {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]any{"Status": "Message unpinned"}
+	if pin {
+		var pinned models.PinnedMessage
+		pinned, err = Svcs.Message.PinMessage(userID, uint(chatroomID), uint(messageID), canPin)
+		response = map[string]any{"Status": "Message pinned", "Pin": pinned}
+	} else {
+		err = Svcs.Message.UnpinMessage(uint(chatroomID), uint(messageID), canPin)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoPermission):
+			http.Error(w, "Your role cannot pin or unpin messages", http.StatusForbidden)
+		case errors.Is(err, services.ErrMessageNotFound):
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNotPinned):
+			http.Error(w, "Message is not pinned", http.StatusNotFound)
+		default:
+			http.Error(w, "Error updating pins", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPins lists the room's pinned messages, newest pin first.
+func GetPins(w http.ResponseWriter, r *http.Request) {
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	pins, err := Svcs.Message.GetPins(uint(chatroomID))
+	if err != nil {
+		http.Error(w, "Error fetching pins", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"Pins": pins})
+}
+
+// SearchMessages searches every room the caller has joined. The q parameter accepts
+// plain terms, "exact phrases" and the from:, in:, before: and after: operators.
+// Results are the 30 best of the newest 1000 matches, best first.
+func SearchMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	hits, err := Svcs.Message.Search(userID, r.URL.Query().Get("q"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptySearch):
+			http.Error(w, "Search query cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidSearch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Error searching messages", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"Results": hits})
 }
 
 // wsBroadcast removed: broadcasting handled in MessageService
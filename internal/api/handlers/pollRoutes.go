@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+// CreatePoll posts a poll to the room; the body is a models.CreatePollRequest.
+func CreatePoll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody models.CreatePollRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	msg, err := Svcs.Poll.Create(userID, uint(chatroomID), requestBody)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPoll):
+			http.Error(w, "A poll needs a question, 2-10 distinct options and a close time within 30 days", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidClientID):
+			http.Error(w, "Invalid client id", http.StatusBadRequest)
+		case errors.Is(err, services.ErrMuted):
+			http.Error(w, "You are muted in this chatroom", http.StatusForbidden)
+		case errors.Is(err, services.ErrCannotPost):
+			http.Error(w, "Your role cannot post in this chatroom", http.StatusForbidden)
+		case errors.Is(err, services.ErrSlowMode):
+			http.Error(w, "Slow mode is on; wait before posting again", http.StatusTooManyRequests)
+		default:
+			http.Error(w, "Unable to create poll", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":  "success",
+		"Message": msg,
+	})
+}
+
+// VotePoll replaces the caller's votes in a poll with the given options; an empty list
+// withdraws them.
+func VotePoll(w http.ResponseWriter, r *http.Request) {
+	userID, chatroomID, messageID, ok := pollPathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody struct {
+		OptionIDs []uint `json:"option_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	results, err := Svcs.Poll.Vote(userID, chatroomID, messageID, requestBody.OptionIDs)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Vote recorded", "Poll": results})
+}
+
+// ClosePoll stops a poll taking votes. Its author, or roles that may delete messages.
+func ClosePoll(w http.ResponseWriter, r *http.Request) {
+	userID, chatroomID, messageID, ok := pollPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := Svcs.Poll.Close(userID, chatroomID, messageID, permissions(r).Has(models.PermDeleteMessages))
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Poll closed", "Poll": results})
+}
+
+func pollPathIDs(w http.ResponseWriter, r *http.Request) (userID, chatroomID, messageID uint, ok bool) {
+	userID, ok = r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, 0, false
+	}
+	room, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || room == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	message, err := strconv.ParseUint(r.PathValue("messageId"), 10, 64)
+	if err != nil || message == 0 {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return userID, uint(room), uint(message), true
+}
+
+func writePollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrMessageNotFound), errors.Is(err, services.ErrPollNotFound):
+		http.Error(w, "Poll not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrPollClosed):
+		http.Error(w, "Poll is closed", http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidPollOption):
+		http.Error(w, "Invalid poll option", http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotMessageAuthor):
+		http.Error(w, "Only the poll's author or an admin can close it", http.StatusForbidden)
+	default:
+		http.Error(w, "Error updating poll", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+// GetRoles lists the room's roles, highest rank first.
+func GetRoles(w http.ResponseWriter, r *http.Request) {
+	_, chatroomID, ok := ownershipPathIDs(w, r)
+	if !ok {
+		return
+	}
+	roles, err := Svcs.Role.List(chatroomID)
+	if err != nil {
+		http.Error(w, "Unable to load roles", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Roles": roles, "Permissions": models.PermissionNames()})
+}
+
+// CreateRole adds a custom role. The body gives its name, permissions and rank.
+func CreateRole(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, ok := ownershipPathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody services.RoleInput
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	role, err := Svcs.Role.Create(actorID, chatroomID, requestBody)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Role created", "Role": role})
+}
+
+// UpdateRole changes the fields of a role given in the body.
+func UpdateRole(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, roleID, ok := rolePathIDs(w, r)
+	if !ok {
+		return
+	}
+	var requestBody services.RoleInput
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Unable to decode request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	role, err := Svcs.Role.Update(actorID, chatroomID, roleID, requestBody)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Role updated", "Role": role})
+}
+
+// DeleteRole removes a custom role; its members fall back to the member role.
+func DeleteRole(w http.ResponseWriter, r *http.Request) {
+	actorID, chatroomID, roleID, ok := rolePathIDs(w, r)
+	if !ok {
+		return
+	}
+	if err := Svcs.Role.Delete(actorID, chatroomID, roleID); err != nil {
+		writeMemberError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "Role deleted"})
+}
+
+// permissions returns what the caller's role allows in the room, as ChatroomMiddleware
+// put it in the request context.
+func permissions(r *http.Request) models.Permissions {
+	perms, _ := r.Context().Value("permissions").(models.Permissions)
+	return perms
+}
+
+func rolePathIDs(w http.ResponseWriter, r *http.Request) (actorID, chatroomID, roleID uint, ok bool) {
+	actorID, chatroomID, ok = ownershipPathIDs(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	role, err := strconv.ParseUint(r.PathValue("roleId"), 10, 64)
+	if err != nil || role == 0 {
+		http.Error(w, "No valid role ID provided", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return actorID, chatroomID, uint(role), true
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/services"
+)
+
+// ScheduleMessage stores a message to be posted in the room at send_at (RFC 3339).
+func ScheduleMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Content string    `json:"content"`
+		SendAt  time.Time `json:"send_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	scheduled, err := Svcs.Scheduled.Schedule(userID, uint(chatroomID), body.Content, body.SendAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptyMessage):
+			http.Error(w, "Message content cannot be empty", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidSendTime):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrTooManyScheduled):
+			http.Error(w, "Too many scheduled messages", http.StatusTooManyRequests)
+		default:
+			log.Printf("schedule message failed: %v", err)
+			http.Error(w, "Unable to schedule message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"Status":    "success",
+		"Scheduled": scheduled,
+	})
+}
+
+// GetScheduledMessages lists the caller's messages in the room that are still waiting to be sent.
+func GetScheduledMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+
+	scheduled, err := Svcs.Scheduled.ListPending(userID, uint(chatroomID))
+	if err != nil {
+		http.Error(w, "Error fetching scheduled messages", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Scheduled": scheduled})
+}
+
+// CancelScheduledMessage deletes one of the caller's pending scheduled messages.
+func CancelScheduledMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(uint)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatroomID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || chatroomID == 0 {
+		http.Error(w, "Invalid chatroom ID", http.StatusBadRequest)
+		return
+	}
+	scheduledID, err := strconv.ParseUint(r.PathValue("scheduledId"), 10, 64)
+	if err != nil || scheduledID == 0 {
+		http.Error(w, "Invalid scheduled message ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := Svcs.Scheduled.Cancel(userID, uint(chatroomID), uint(scheduledID)); err != nil {
+		if errors.Is(err, services.ErrScheduledNotFound) {
+			http.Error(w, "Scheduled message not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Unable to cancel scheduled message", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Status": "success"})
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/services"
 	"github.com/Wal-20/cli-chat-app/internal/utils"
 	"gorm.io/gorm"
 	"net/http"
@@ -73,10 +75,10 @@ func GetChatroomsByUser(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(uint)
 
 	var chatrooms []models.Chatroom
-	// Only return rooms the user actually joined
+	// Only return rooms the user actually joined; direct messages are listed separately
 	if err := config.DB.
 		Joins("JOIN user_chatrooms ON user_chatrooms.chatroom_id = chatrooms.id").
-		Where("user_chatrooms.user_id = ? AND user_chatrooms.is_joined = ?", userID, true).
+		Where("user_chatrooms.user_id = ? AND user_chatrooms.is_joined = ? AND chatrooms.is_direct = ?", userID, true, false).
 		Find(&chatrooms).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "No chatrooms found for user", http.StatusNotFound)
@@ -91,6 +93,21 @@ func GetChatroomsByUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetUnreadCounts returns unread and mention counts for each of the caller's rooms.
+func GetUnreadCounts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uint)
+
+	counts, err := Svcs.Chat.UnreadCounts(userID)
+	if err != nil {
+		http.Error(w, "Error retrieving unread counts", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"Unread": counts,
+	})
+}
+
 func Login(w http.ResponseWriter, r *http.Request) {
 	var body models.User
 	decoder := json.NewDecoder(r.Body)
@@ -271,6 +288,10 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Missing attributes", http.StatusBadRequest)
 		return
 	}
+	if services.IsPlaceholderName(userUpdate.Name) {
+		http.Error(w, services.ErrReservedName.Error(), http.StatusBadRequest)
+		return
+	}
 	user.Name = userUpdate.Name
 
 	hashedPassword, err := utils.HashPassword(userUpdate.Password)
@@ -307,8 +328,8 @@ func InviteUser(w http.ResponseWriter, r *http.Request) {
 	if idNum, err := strconv.ParseUint(userIdent, 10, 32); err == nil {
 		userIdNum = idNum
 	} else {
-		var u models.User
-		if err := config.DB.Where("name = ?", userIdent).First(&u).Error; err != nil {
+		u, err := repositories.DefaultUserRepository().FindByName(userIdent)
+		if err != nil {
 			http.Error(w, "Invalid user identifier: not found", http.StatusNotFound)
 			return
 		}
@@ -321,9 +342,8 @@ func InviteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isAdmin := r.Context().Value("isAdmin").(bool)
-	if !isAdmin {
-		http.Error(w, "You are not an admin", http.StatusUnauthorized)
+	if !permissions(r).Has(models.PermInvite) {
+		http.Error(w, "Your role cannot invite members", http.StatusForbidden)
 		return
 	}
 
@@ -342,6 +362,11 @@ func InviteUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error fetching user", http.StatusInternalServerError)
 		return
 	}
+	// Placeholders stand in for imported authors; nobody can sign in as one.
+	if targetUser.Placeholder {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 
 	var userChatroom models.UserChatroom
 	err = config.DB.Where("user_id = ? AND chatroom_id = ?", userIdNum, chatroomIdNum).First(&userChatroom).Error
@@ -416,180 +441,3 @@ func InviteUser(w http.ResponseWriter, r *http.Request) {
 		"User status": userChatroom,
 	})
 }
-
-func KickUser(w http.ResponseWriter, r *http.Request) {
-	isAdmin := r.Context().Value("isAdmin").(bool)
-	userId := r.PathValue("userId")
-	chatroomId := r.PathValue("id")
-
-	if userId == "" {
-		http.Error(w, "No valid user ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if chatroomId == "" {
-		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if !isAdmin {
-		http.Error(w, "You are not an admin", http.StatusUnauthorized)
-		return
-	}
-
-	var userChatroom models.UserChatroom
-	if err := config.DB.Where("user_id = ? AND chatroom_id = ?", userId, chatroomId).First(&userChatroom).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "user-chatroom association not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error retrieving user-chatroom association", http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if !userChatroom.IsJoined {
-		http.Error(w, "User already not part of this chatroom", http.StatusBadRequest)
-		return
-	}
-	userChatroom.IsJoined = false
-
-	if err := config.DB.Save(&userChatroom).Error; err != nil {
-		http.Error(w, "Error saving user-chatroom association", http.StatusInternalServerError)
-		return
-	}
-
-	_ = config.DB.Create(&models.Notification{
-		UserId:     userChatroom.UserID,
-		ChatroomId: userChatroom.ChatroomID,
-		Type:       "kick",
-		SenderId:   r.Context().Value("userID").(uint),
-		Content:    fmt.Sprintf("You have been kicked from chatroom %s", chatroomId),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}).Error
-
-	json.NewEncoder(w).Encode(map[string]any{
-		"Status":      "User kicked",
-		"User status": userChatroom,
-	})
-}
-
-// Owner makes user -> admin
-func PromoteUser(w http.ResponseWriter, r *http.Request) {
-	isOwner := r.Context().Value("isOwner").(bool)
-	userId := r.PathValue("userId")
-	chatroomId := r.PathValue("id")
-
-	if userId == "" {
-		http.Error(w, "No valid user ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if chatroomId == "" {
-		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if !isOwner {
-		http.Error(w, "You are not the owner", http.StatusUnauthorized)
-		return
-	}
-
-	var userChatroom models.UserChatroom
-	if err := config.DB.Where("user_id = ? AND chatroom_id = ?", userId, chatroomId).First(&userChatroom).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "user-chatroom association not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error retrieving user-chatroom association", http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if !userChatroom.IsJoined {
-		http.Error(w, "User not part of this chatroom", http.StatusBadRequest)
-		return
-	}
-	userChatroom.IsAdmin = true
-
-	if err := config.DB.Save(&userChatroom).Error; err != nil {
-		http.Error(w, "Error saving user-chatroom association", http.StatusInternalServerError)
-		return
-	}
-
-	_ = config.DB.Create(&models.Notification{
-		UserId:     userChatroom.UserID,
-		ChatroomId: userChatroom.ChatroomID,
-		Type:       "Promoted to Admin",
-		SenderId:   r.Context().Value("userID").(uint),
-		Content:    fmt.Sprintf("You have been promoted to admin in chatroom %s", chatroomId),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}).Error
-
-	json.NewEncoder(w).Encode(map[string]any{
-		"Status":      "User promoted to admin",
-		"User status": userChatroom,
-	})
-}
-
-func BanUser(w http.ResponseWriter, r *http.Request) {
-
-	isAdmin := r.Context().Value("isAdmin").(bool)
-	userId := r.PathValue("userId")
-	chatroomId := r.PathValue("id")
-
-	if userId == "" {
-		http.Error(w, "No valid user ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if chatroomId == "" {
-		http.Error(w, "No valid chatroom ID provided", http.StatusBadRequest)
-		return
-	}
-
-	if !isAdmin {
-		http.Error(w, "You are not an admin", http.StatusUnauthorized)
-		return
-	}
-
-	var userChatroom models.UserChatroom
-	if err := config.DB.Where("user_id = ? AND chatroom_id = ?", userId, chatroomId).First(&userChatroom).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "user-chatroom association not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error retrieving user-chatroom association", http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if userChatroom.IsBanned {
-		http.Error(w, "user already banned", http.StatusBadRequest)
-		return
-	}
-
-	userChatroom.IsBanned = true
-	userChatroom.IsInvited = false
-	userChatroom.IsJoined = false
-
-	if err := config.DB.Save(&userChatroom).Error; err != nil {
-		http.Error(w, "Error saving user-chatroom association", http.StatusInternalServerError)
-		return
-	}
-
-	_ = config.DB.Create(&models.Notification{
-		UserId:     userChatroom.UserID,
-		ChatroomId: userChatroom.ChatroomID,
-		Type:       "ban",
-		SenderId:   r.Context().Value("userID").(uint),
-		Content:    fmt.Sprintf("You have been banned from chatroom %s", chatroomId),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}).Error
-
-	json.NewEncoder(w).Encode(map[string]any{
-		"Status":      "User banned mn gher matrood!",
-		"User status": userChatroom,
-	})
-
-}
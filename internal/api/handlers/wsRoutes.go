@@ -1,9 +1,15 @@
 package handlers
 
 import (
+    "errors"
+    "log"
     "net/http"
+    "strings"
+    "time"
 
     "github.com/Wal-20/cli-chat-app/internal/api/ws"
+    "github.com/Wal-20/cli-chat-app/internal/models"
+    "github.com/Wal-20/cli-chat-app/internal/services"
 )
 
 // ChatroomWebSocket handles websocket upgrade for a chatroom.
@@ -11,3 +17,53 @@ func ChatroomWebSocket(w http.ResponseWriter, r *http.Request) {
     ws.ServeChatroomWS(w, r)
 }
 
+// handleSocketSend stores a message sent over a room's websocket and builds the ack or
+// nack for the sender. Membership is checked again because the socket may outlive it.
+func handleSocketSend(userID, chatroomID uint, req models.SendMessageRequest) ws.WsEvent {
+    nack := func(reason string) ws.WsEvent {
+        evt, _ := ws.NewEvent("message_nack", models.MessageNack{ClientID: req.ClientID, Error: reason})
+        return evt
+    }
+
+    content := strings.TrimSpace(req.Content)
+    switch {
+    case req.ClientID == "":
+        return nack("Missing client id")
+    case content == "":
+        return nack("Message content cannot be empty")
+    case !Svcs.Chat.IsActiveMember(userID, chatroomID):
+        return nack("You are not a member of this chatroom")
+    }
+
+    opts := services.SendOptions{ClientID: req.ClientID, TTL: time.Duration(req.TTL) * time.Second, Kind: req.Kind}
+    var msg models.Message
+    var err error
+    if req.ParentID != 0 {
+        msg, _, err = Svcs.Message.SendReply(userID, chatroomID, req.ParentID, content, opts)
+    } else {
+        msg, _, err = Svcs.Message.SendMessage(userID, chatroomID, content, opts)
+    }
+    if err != nil {
+        switch {
+        case errors.Is(err, services.ErrMessageNotFound):
+            return nack("Thread root not found")
+        case errors.Is(err, services.ErrInvalidClientID):
+            return nack("Invalid client id")
+        case errors.Is(err, services.ErrInvalidTTL):
+            return nack("Invalid message ttl")
+        case errors.Is(err, services.ErrInvalidKind):
+            return nack("Invalid message kind")
+        case errors.Is(err, services.ErrMuted):
+            return nack("You are muted in this chatroom")
+        case errors.Is(err, services.ErrCannotPost):
+            return nack("Your role cannot post in this chatroom")
+        case errors.Is(err, services.ErrSlowMode):
+            return nack("Slow mode is on; wait before posting again")
+        }
+        log.Printf("ws send_message failed: %v", err)
+        return nack("Unable to create message")
+    }
+
+    evt, _ := ws.NewEvent("message_ack", models.MessageAck{ClientID: req.ClientID, MessageID: msg.ID, CreatedAt: msg.CreatedAt, ExpiresAt: msg.ExpiresAt})
+    return evt
+}
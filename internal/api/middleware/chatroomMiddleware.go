@@ -3,22 +3,21 @@ package middleware
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
 	"github.com/Wal-20/cli-chat-app/internal/utils"
 	"gorm.io/gorm"
 )
 
-// membershipInfo is cached to avoid frequent DB lookups and to carry admin flag.
+// membershipInfo is cached to avoid frequent DB lookups and to carry the member's permissions.
 type membershipInfo struct {
-	IsMember bool
-	IsAdmin  bool
-	IsOwner  bool
+	IsMember    bool
+	Permissions models.Permissions
 }
 
 func ChatroomMiddleware(next http.Handler) http.Handler {
@@ -28,13 +27,12 @@ func ChatroomMiddleware(next http.Handler) http.Handler {
 		// Get chatroom ID from the request (query params, headers, or body)
 
 		chatroomIDStr := r.PathValue("id")
-		cacheKey := fmt.Sprintf("membership:%v:%s", userID, chatroomIDStr)
+		cacheKey := utils.MembershipKey(userID, chatroomIDStr)
 
 		if cached, found := utils.MembershipCache.Get(cacheKey); found {
 			if info, ok := cached.(membershipInfo); ok && info.IsMember {
-				// Ensure admin/owner status is propagated to downstream handlers
-				ctx := context.WithValue(r.Context(), "isAdmin", info.IsAdmin)
-				ctx = context.WithValue(ctx, "isOwner", info.IsOwner)
+				// Ensure the member's permissions are propagated to downstream handlers
+				ctx := context.WithValue(r.Context(), "permissions", info.Permissions)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
@@ -62,12 +60,17 @@ func ChatroomMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		utils.MembershipCache.Set(cacheKey, membershipInfo{IsMember: true, IsAdmin: userChatroom.IsAdmin, IsOwner: userChatroom.IsOwner}, time.Minute*5)
-		ctx := context.WithValue(r.Context(), "isAdmin", userChatroom.IsAdmin)
-		ctx = context.WithValue(ctx, "isOwner", userChatroom.IsOwner)
+		role, err := repositories.DefaultChatroomRepository().MemberRole(&userChatroom)
+		if err != nil {
+			http.Error(w, "Error retrieving chatroom role", http.StatusInternalServerError)
+			return
+		}
+
+		utils.MembershipCache.Set(cacheKey, membershipInfo{IsMember: true, Permissions: role.Permissions}, time.Minute*5)
+		ctx := context.WithValue(r.Context(), "permissions", role.Permissions)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// get the authenticated user from the context, check if the user is part of the chatroom, and add the user's admin/owner status
+// get the authenticated user from the context, check if the user is part of the chatroom, and add the permissions of the user's role
@@ -47,8 +47,13 @@ func NewServer() {
 	mux.HandleFunc("POST /api/users/refresh", handlers.RefreshToken)
 	mux.Handle("POST /api/users/update", middleware.AuthMiddleware(http.HandlerFunc(handlers.UpdateUser)))
 	mux.Handle("GET /api/users/chatrooms", middleware.AuthMiddleware(http.HandlerFunc(handlers.GetChatroomsByUser)))
+	mux.Handle("GET /api/users/chatrooms/unread", middleware.AuthMiddleware(http.HandlerFunc(handlers.GetUnreadCounts)))
 	mux.Handle("GET /api/users/notifications", middleware.AuthMiddleware(http.HandlerFunc(handlers.GetNotifications)))
 
+	// Direct message routes
+	mux.Handle("GET /api/dms", middleware.AuthMiddleware(http.HandlerFunc(handlers.GetDirectMessages)))
+	mux.Handle("POST /api/dms/{userId}", middleware.AuthMiddleware(http.HandlerFunc(handlers.OpenDirectMessage)))
+
 	// Admin routes
 	mux.Handle("POST /api/users/chatrooms/{id}/invite/{userId}", middleware.AuthMiddleware(
 		middleware.ChatroomMiddleware(
@@ -65,11 +70,46 @@ func NewServer() {
 			http.HandlerFunc(handlers.BanUser),
 		),
 	))
+	mux.Handle("POST /api/users/chatrooms/{id}/unban/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.UnbanUser),
+		),
+	))
 	mux.Handle("POST /api/users/chatrooms/{id}/promote/{userId}", middleware.AuthMiddleware(
 		middleware.ChatroomMiddleware(
 			http.HandlerFunc(handlers.PromoteUser),
 		),
 	))
+	mux.Handle("POST /api/users/chatrooms/{id}/demote/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.DemoteUser),
+		),
+	))
+	mux.Handle("POST /api/users/chatrooms/{id}/role/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.AssignRole),
+		),
+	))
+	mux.Handle("POST /api/users/chatrooms/{id}/transfer/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.OfferOwnership),
+		),
+	))
+	mux.Handle("DELETE /api/users/chatrooms/{id}/transfer/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.WithdrawOwnership),
+		),
+	))
+	mux.Handle("POST /api/users/chatrooms/{id}/mute/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.MuteUser),
+		),
+	))
+	mux.Handle("POST /api/users/chatrooms/{id}/unmute/{userId}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.UnmuteUser),
+		),
+	))
 
 	// Chatroom routes
 	mux.HandleFunc("GET /api/chatrooms", handlers.GetChatrooms)
@@ -80,14 +120,52 @@ func NewServer() {
 			http.HandlerFunc(handlers.DeleteChatroom),
 		),
 	))
-	mux.HandleFunc("GET /api/chatrooms/{id}/users", handlers.GetUsersByChatroom)
-	mux.HandleFunc("GET /api/chatrooms/{id}/messages", handlers.GetMessagesByChatroom)
+	mux.Handle("PATCH /api/chatrooms/{id}", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.UpdateChatroom),
+		),
+	))
+	mux.Handle("GET /api/chatrooms/{id}/users", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.GetUsersByChatroom),
+		),
+	))
+	mux.Handle("GET /api/chatrooms/{id}/messages", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.GetMessagesByChatroom),
+		),
+	))
+	mux.Handle("POST /api/chatrooms/{id}/ownership/accept", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.AcceptOwnership),
+		),
+	))
+	mux.Handle("POST /api/chatrooms/{id}/ownership/decline", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.DeclineOwnership),
+		),
+	))
 	mux.Handle("POST /api/chatrooms/{id}/join", middleware.AuthMiddleware(http.HandlerFunc(handlers.JoinChatroom)))
 	mux.Handle("POST /api/chatrooms/{id}/leave", middleware.AuthMiddleware(
 		middleware.ChatroomMiddleware(
 			http.HandlerFunc(handlers.LeaveChatroom),
 		),
 	))
+	mux.Handle("POST /api/chatrooms/{id}/message-ttl", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.SetMessageTTL),
+		),
+	))
+	mux.Handle("POST /api/chatrooms/{id}/topic", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.SetTopic),
+		),
+	))
+	mux.Handle("POST /api/chatrooms/{id}/read", middleware.AuthMiddleware(
+		middleware.ChatroomMiddleware(
+			http.HandlerFunc(handlers.MarkChatroomRead),
+		),
+	))
 
 	// Message routes
 	mux.Handle("POST /api/chatrooms/{id}/messages",
@@ -97,6 +175,27 @@ func NewServer() {
 			),
 		),
 	)
+	mux.Handle("POST /api/chatrooms/{id}/attachments",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.UploadAttachment),
+			),
+		),
+	)
+	mux.Handle("GET /api/chatrooms/{id}/attachments/{attachmentId}",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.DownloadAttachment),
+			),
+		),
+	)
+	mux.Handle("GET /api/chatrooms/{id}/attachments/{attachmentId}/thumbnail",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.DownloadThumbnail),
+			),
+		),
+	)
 	mux.Handle("DELETE /api/chatrooms/{id}/messages/{messageId}",
 		middleware.AuthMiddleware(
 			middleware.ChatroomMiddleware(
@@ -111,6 +210,139 @@ func NewServer() {
 			),
 		),
 	)
+	mux.Handle("GET /api/chatrooms/{id}/messages/{messageId}/thread",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.GetThread),
+			),
+		),
+	)
+	mux.Handle("POST /api/chatrooms/{id}/messages/{messageId}/reactions",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.AddReaction),
+			),
+		),
+	)
+	mux.Handle("DELETE /api/chatrooms/{id}/messages/{messageId}/reactions/{emoji}",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.RemoveReaction),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/chatrooms/{id}/export",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.ExportChatroom),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/chatrooms/{id}/polls",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.CreatePoll),
+			),
+		),
+	)
+	mux.Handle("POST /api/chatrooms/{id}/messages/{messageId}/poll/votes",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.VotePoll),
+			),
+		),
+	)
+	mux.Handle("POST /api/chatrooms/{id}/messages/{messageId}/poll/close",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.ClosePoll),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/chatrooms/{id}/messages/{messageId}/pin",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.PinMessage),
+			),
+		),
+	)
+	mux.Handle("DELETE /api/chatrooms/{id}/messages/{messageId}/pin",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.UnpinMessage),
+			),
+		),
+	)
+	mux.Handle("GET /api/chatrooms/{id}/pins",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.GetPins),
+			),
+		),
+	)
+	mux.Handle("GET /api/chatrooms/{id}/bans",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.GetBans),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/chatrooms/{id}/roles",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.GetRoles),
+			),
+		),
+	)
+	mux.Handle("POST /api/chatrooms/{id}/roles",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.CreateRole),
+			),
+		),
+	)
+	mux.Handle("PATCH /api/chatrooms/{id}/roles/{roleId}",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.UpdateRole),
+			),
+		),
+	)
+	mux.Handle("DELETE /api/chatrooms/{id}/roles/{roleId}",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.DeleteRole),
+			),
+		),
+	)
+
+	mux.Handle("POST /api/chatrooms/{id}/scheduled",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.ScheduleMessage),
+			),
+		),
+	)
+	mux.Handle("GET /api/chatrooms/{id}/scheduled",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.GetScheduledMessages),
+			),
+		),
+	)
+	mux.Handle("DELETE /api/chatrooms/{id}/scheduled/{scheduledId}",
+		middleware.AuthMiddleware(
+			middleware.ChatroomMiddleware(
+				http.HandlerFunc(handlers.CancelScheduledMessage),
+			),
+		),
+	)
+
+	mux.Handle("GET /api/search", middleware.AuthMiddleware(http.HandlerFunc(handlers.SearchMessages)))
 
 	// Notification routes
 	mux.Handle("DELETE /api/notifications/{id}",
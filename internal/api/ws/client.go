@@ -2,17 +2,30 @@ package ws
 
 import (
 	"encoding/json"
+	"log"
 
+	"github.com/Wal-20/cli-chat-app/internal/models"
 	"github.com/gorilla/websocket"
 )
 
 // Client represents a single websocket connection.
 type Client struct {
-	room *Room
-	conn *websocket.Conn
-	send chan []byte
+	room   *Room
+	conn   *websocket.Conn
+	send   chan []byte
+	userID uint // authenticated user behind the connection
 }
 
+// SendMessageHandler stores a message sent over the websocket and returns the event
+// ("message_ack" or "message_nack") to answer the sender with.
+type SendMessageHandler func(userID, roomID uint, req models.SendMessageRequest) WsEvent
+
+var sendMessageHandler SendMessageHandler
+
+// HandleSendMessage registers the handler for inbound "send_message" events. The ws
+// package cannot depend on the services, so the API layer wires this up at startup.
+func HandleSendMessage(h SendMessageHandler) { sendMessageHandler = h }
+
 type wsUserStatusPayload struct {
 	Username string `json:"username"`
 }
@@ -51,12 +64,32 @@ func (c *Client) readPump() {
 		case "joined", "left":
 			// Fan out ephemeral status events to everyone in the room.
 			c.room.broadcastChan <- data
+		case "send_message":
+			var req models.SendMessageRequest
+			if sendMessageHandler == nil || json.Unmarshal(evt.Data, &req) != nil {
+				continue
+			}
+			c.reply(sendMessageHandler(c.userID, c.room.id, req))
 		default:
 			// Ignore other incoming event types for now.
 		}
 	}
 }
 
+// reply sends an event to this connection only. A full send buffer drops the reply;
+// the sender retries and idempotency keys make that safe.
+func (c *Client) reply(evt WsEvent) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		log.Printf("ws marshal error: %v", err)
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
 func (c *Client) writePump() {
 	defer func() { _ = c.conn.Close() }()
 	for msg := range c.send {
@@ -64,8 +64,9 @@ func ServeChatroomWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, _ := r.Context().Value("userID").(uint)
 	room := GetRoom(uint(id64))
-	client := &Client{room: room, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{room: room, conn: conn, send: make(chan []byte, 256), userID: userID}
 	room.registerChan <- client
 
 	go client.writePump()
@@ -8,3 +8,12 @@ type WsEvent struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
 }
+
+// NewEvent builds an event of the given type carrying payload as JSON.
+func NewEvent(eventType string, payload any) (WsEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return WsEvent{}, err
+	}
+	return WsEvent{Type: eventType, Data: data}, nil
+}
@@ -35,7 +35,13 @@ func InitDB() error {
 		&models.User{},
 		&models.Chatroom{},
 		&models.UserChatroom{},
+		&models.Role{},
 		&models.Message{},
+		&models.Attachment{},
+		&models.MessageEdit{},
+		&models.Reaction{}, &models.Pin{},
+		&models.Poll{}, &models.PollOption{}, &models.PollVote{},
+		&models.ScheduledMessage{},
 		&models.Notification{},
 	)
 
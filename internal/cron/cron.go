@@ -4,16 +4,122 @@ import (
 	"log"
 	"time"
 
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
 	"github.com/Wal-20/cli-chat-app/internal/services"
+	"github.com/Wal-20/cli-chat-app/internal/storage"
 	"github.com/go-co-op/gocron"
 )
 
+// scheduledDeliveryInterval is how often due scheduled messages are posted, and so
+// roughly how late one can arrive.
+const scheduledDeliveryInterval = 15 * time.Second
+
+// expiryPurgeInterval is how often expired ephemeral messages are deleted. Reads already
+// hide them once they expire, so this only bounds how long they stay in the database.
+const expiryPurgeInterval = 10 * time.Second
+
+// muteLiftInterval is how often expired mutes are lifted and announced. Sending is
+// allowed again as soon as a mute runs out; this only delays the notification.
+const muteLiftInterval = 30 * time.Second
+
+// banLiftInterval is how often expired bans are lifted. A timed ban holds until then,
+// so it can outlast its time by up to this long.
+const banLiftInterval = 30 * time.Second
+
+// pollCloseInterval is how often polls past their close time are closed and announced.
+// Votes are refused as soon as the time passes; this only delays the final tallies.
+const pollCloseInterval = 15 * time.Second
+
+var (
+	messageService    *services.MessageService
+	scheduledMessages *services.ScheduledMessageService
+	attachments       *services.AttachmentService
+	polls             *services.PollService
+	members           *services.MemberService
+)
+
 func StartCronJobs() {
+	chatRepo := repositories.DefaultChatroomRepository()
+	msgRepo := repositories.DefaultMessageRepository()
+	messageService = services.NewMessageService(
+		msgRepo,
+		repositories.DefaultUserRepository(),
+		repositories.DefaultNotificationRepository(),
+		chatRepo,
+	)
+	scheduledMessages = services.NewScheduledMessageService(repositories.DefaultScheduledMessageRepository(), messageService, chatRepo)
+	blobs, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Blob store not configured: %v", err)
+	}
+	attachments = services.NewAttachmentService(blobs, messageService, msgRepo)
+	polls = services.NewPollService(repositories.DefaultPollRepository(), messageService)
+	members = services.NewMemberService(chatRepo)
+
 	s := gocron.NewScheduler(time.Local)
 	s.Every(1).Day().Do(dailyCleanup)
+	// A slow run must not overlap the next one, or a message could be delivered twice.
+	s.Every(scheduledDeliveryInterval).SingletonMode().Do(deliverScheduledMessages)
+	s.Every(expiryPurgeInterval).SingletonMode().Do(purgeExpiredMessages)
+	s.Every(pollCloseInterval).SingletonMode().Do(closeDuePolls)
+	s.Every(muteLiftInterval).SingletonMode().Do(liftExpiredMutes)
+	s.Every(banLiftInterval).SingletonMode().Do(liftExpiredBans)
 	s.StartAsync()
 }
 
+func deliverScheduledMessages() {
+	sent, failed, err := scheduledMessages.DeliverDue(time.Now())
+	if err != nil {
+		log.Printf("Failed to deliver scheduled messages: %v", err)
+		return
+	}
+	if sent > 0 || failed > 0 {
+		log.Printf("Delivered %v scheduled messages, %v failed", sent, failed)
+	}
+}
+
+func purgeExpiredMessages() {
+	purged, err := messageService.PurgeExpired(time.Now())
+	if err != nil {
+		log.Printf("Failed to purge expired messages: %v", err)
+		return
+	}
+	attachments.DeleteBlobs(purged)
+	if len(purged) > 0 {
+		log.Printf("Purged %v expired messages", len(purged))
+	}
+}
+
+func closeDuePolls() {
+	closed, err := polls.CloseDue(time.Now())
+	if err != nil {
+		log.Printf("Failed to close due polls: %v", err)
+	}
+	if closed > 0 {
+		log.Printf("Closed %v polls", closed)
+	}
+}
+
+func liftExpiredMutes() {
+	lifted, err := members.LiftExpiredMutes(time.Now())
+	if err != nil {
+		log.Printf("Failed to lift expired mutes: %v", err)
+	}
+	if lifted > 0 {
+		log.Printf("Lifted %v expired mutes", lifted)
+	}
+}
+
+func liftExpiredBans() {
+	lifted, err := members.LiftExpiredBans(time.Now())
+	if err != nil {
+		log.Printf("Failed to lift expired bans: %v", err)
+	}
+	if lifted > 0 {
+		log.Printf("Lifted %v expired bans", lifted)
+	}
+}
+
 func dailyCleanup() {
 	cleanupNotifications()
 	cleanupUserChatrooms()
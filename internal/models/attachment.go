@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is a file uploaded with a message. The bytes live in the blob store under
+// StorageKey; Preview holds what the TUI shows inline (the first lines of a text file,
+// the metadata of a PDF). Images also get a PNG thumbnail under ThumbnailKey and
+// ImagePreviews, block-character renditions at a few widths; a non-zero Width marks an
+// image with both.
+type Attachment struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID     uint           `gorm:"not null;uniqueIndex" json:"message_id"`
+	ChatroomID    uint           `gorm:"not null;index" json:"chatroom_id"`
+	Name          string         `gorm:"type:varchar(255);not null" json:"name"`
+	Size          int64          `gorm:"not null" json:"size"`
+	MimeType      string         `gorm:"type:varchar(127);not null" json:"mime_type"`
+	SHA256        string         `gorm:"type:char(64);not null" json:"sha256"`
+	StorageKey    string         `gorm:"type:varchar(255);not null" json:"-"`
+	Preview       string         `gorm:"type:text" json:"preview"`
+	Width         int            `gorm:"not null;default:0" json:"width,omitempty"`
+	Height        int            `gorm:"not null;default:0" json:"height,omitempty"`
+	ThumbnailKey  string         `gorm:"type:varchar(255);not null;default:''" json:"-"`
+	ImagePreviews []ImagePreview `gorm:"type:mediumtext;serializer:json" json:"image_previews,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ImagePreview is an image drawn with ANSI-coloured half blocks, Columns cells wide and
+// Rows lines tall.
+type ImagePreview struct {
+	Columns int    `json:"columns"`
+	Rows    int    `json:"rows"`
+	Art     string `json:"art"`
+}
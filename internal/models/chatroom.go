@@ -12,5 +12,30 @@ type Chatroom struct {
 	Users []User  `gorm:"many2many:user_chatrooms;" json:"users"`
 	IsPublic bool `gorm:"type(bool);default:false" json:"is_public"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	MessageTTL uint `gorm:"default:0" json:"message_ttl"` // seconds new messages live for, 0 to keep them
+	Topic string `gorm:"type:varchar(255);not null;default:''" json:"topic"`
+	SlowMode uint `gorm:"default:0" json:"slow_mode"` // seconds a member waits between messages, 0 for none
+	IsDirect bool `gorm:"default:false;index" json:"is_direct"` // two-person conversation, never listed publicly
+	DirectKey *string `gorm:"type:varchar(64);uniqueIndex" json:"-"` // "<lower user id>:<higher user id>" so each pair has one conversation
+	PendingOwnerID *uint `gorm:"default:null" json:"pending_owner_id"` // member offered ownership, until they answer or the offer expires
+	OwnerOfferExpires *time.Time `gorm:"default:null" json:"owner_offer_expires"`
 }
 
+// DirectConversation is a direct-message room as seen by one of its two members.
+type DirectConversation struct {
+	Chatroom Chatroom `json:"chatroom"`
+	PeerID uint `json:"peer_id"`
+	PeerName string `json:"peer_name"`
+}
+
+// RoomUpdatedPayload is broadcast as a "room_updated" websocket event when the room's
+// settings change.
+type RoomUpdatedPayload struct {
+	Chatroom Chatroom `json:"chatroom"`
+}
+
+// TopicUpdatedPayload is broadcast as a "topic_updated" websocket event.
+type TopicUpdatedPayload struct {
+	ChatroomID uint `json:"chatroom_id"`
+	Topic string `json:"topic"`
+}
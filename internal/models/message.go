@@ -4,18 +4,126 @@ import (
 	"time"
 )
 
+// Message kinds. Action messages come from /me and render as "* user does something";
+// poll messages carry a Poll and ask its question.
+const (
+	MessageKindText   = "text"
+	MessageKindAction = "action"
+	MessageKindPoll   = "poll"
+)
+
 // this is used for sending the message to the backend
 type Message struct {
-	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	ChatroomID uint      `gorm:"foreignKey:ID" json:"chatroomId"`
-	UserId     uint      `gorm:"foreignKey:ID" json:"userID"`
-	Content    string    `gorm:"type(text)" json:"content"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID         uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatroomID uint        `gorm:"foreignKey:ID;uniqueIndex:idx_message_room_client,priority:2" json:"chatroomId"`
+	UserId     uint        `gorm:"foreignKey:ID;uniqueIndex:idx_message_room_client,priority:1" json:"userID"`
+	ParentID   *uint       `gorm:"index;default:null" json:"parent_id"` // thread root this message replies to
+	Content    string      `gorm:"type(text)" json:"content"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	EditedAt   *time.Time  `gorm:"default:null" json:"edited_at"`
+	DeletedAt  *time.Time  `gorm:"default:null" json:"deleted_at"`
+	DeletedBy  *uint       `gorm:"default:null" json:"deleted_by"`
+	ClientID   *string     `gorm:"type:varchar(64);uniqueIndex:idx_message_room_client,priority:3;default:null" json:"client_id"` // sender-generated idempotency key, unique per sender and room
+	ExpiresAt  *time.Time  `gorm:"index;default:null" json:"expires_at"`                                                          // ephemeral messages are purged once this passes
+	Kind       string      `gorm:"type:varchar(16);not null;default:text" json:"kind"`
+	Attachment *Attachment `gorm:"foreignKey:MessageID" json:"attachment,omitempty"`
+	Poll       *Poll       `gorm:"foreignKey:MessageID" json:"poll,omitempty"`
 }
 
 // this is used for retrieving the messages on the client
 type MessageWithUser struct {
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	Username  string    `json:"username"`
+	ID         uint            `json:"id"`
+	Content    string          `json:"content"`
+	CreatedAt  time.Time       `json:"created_at"`
+	EditedAt   *time.Time      `json:"edited_at"`
+	DeletedAt  *time.Time      `json:"deleted_at"`
+	ParentID   *uint           `json:"parent_id"`
+	ReplyCount int             `json:"reply_count"`
+	Reactions  []ReactionCount `gorm:"-" json:"reactions"`
+	DeletedBy  string          `json:"deleted_by"` // name of whoever removed the message (author or admin)
+	Username   string          `json:"username"`
+	ClientID   string          `json:"client_id,omitempty"` // idempotency key the sender attached, if any
+	ExpiresAt  *time.Time      `json:"expires_at"`
+	Kind       string          `json:"kind"`
+	Attachment *Attachment     `gorm:"-" json:"attachment"`
+	Poll       *PollResults    `gorm:"-" json:"poll"`
+}
+
+// Thread is a root message together with all of its replies, oldest first.
+type Thread struct {
+	Root    MessageWithUser   `json:"root"`
+	Replies []MessageWithUser `json:"replies"`
+}
+
+// ThreadReplyPayload is broadcast as a "thread_reply" websocket event.
+type ThreadReplyPayload struct {
+	RootID     uint            `json:"root_id"`
+	ReplyCount int             `json:"reply_count"`
+	Message    MessageWithUser `json:"message"`
+}
+
+// SendMessageRequest is the payload of the inbound "send_message" websocket event.
+// ClientID is generated by the sender and makes retries safe: a message is stored once
+// per sender, room and key.
+type SendMessageRequest struct {
+	ClientID string `json:"client_id"`
+	Content  string `json:"content"`
+	ParentID uint   `json:"parent_id"`
+	TTL      uint   `json:"ttl"`  // seconds until the message expires, 0 for the room default
+	Kind     string `json:"kind"` // MessageKindText when empty
+}
+
+// MessageAck answers a "send_message" event once the message is stored ("message_ack").
+type MessageAck struct {
+	ClientID  string     `json:"client_id"`
+	MessageID uint       `json:"message_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// MessageNack answers a "send_message" event that was rejected ("message_nack").
+type MessageNack struct {
+	ClientID string `json:"client_id"`
+	Error    string `json:"error"`
+}
+
+// MessageExpiredPayload is broadcast as a "message_expired" websocket event once an
+// ephemeral message has been purged. ParentID is set for thread replies.
+type MessageExpiredPayload struct {
+	MessageID uint  `json:"message_id"`
+	ParentID  *uint `json:"parent_id"`
+}
+
+// SearchHit is one result of a cross-room search. Snippet is an excerpt of the content
+// around the best match; Highlights are the byte ranges within it that matched.
+type SearchHit struct {
+	Message       MessageWithUser `json:"message"`
+	ChatroomID    uint            `json:"chatroom_id"`
+	ChatroomTitle string          `json:"chatroom_title"`
+	Snippet       string          `json:"snippet"`
+	Highlights    []TextSpan      `json:"highlights"`
+	Score         float64         `json:"score"`
+}
+
+// ExportedMessage is a message as it appears in a transcript export, with the earlier
+// versions of its content and a link to its attachment. It is one line of a JSON lines
+// export.
+type ExportedMessage struct {
+	MessageWithUser
+	ChatroomID    uint          `json:"chatroom_id"`
+	Edits         []MessageEdit `json:"edits,omitempty"` // previous versions, oldest first
+	AttachmentURL string        `json:"attachment_url,omitempty"`
+}
+
+// TextSpan is a half-open byte range [Start, End) within a string.
+type TextSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// MessagePage is one window of a chatroom's history, oldest message first.
+// NextCursor is the message id to pass as the next cursor, nil when there is nothing more to load.
+type MessagePage struct {
+	Messages   []MessageWithUser `json:"Messages"`
+	NextCursor *uint             `json:"next_cursor"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// MessageEdit keeps a previous version of a message each time its author edits it.
+type MessageEdit struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID uint      `gorm:"not null;index" json:"message_id"`
+	EditorID  uint      `gorm:"not null" json:"editor_id"`
+	Content   string    `gorm:"type(text)" json:"content"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
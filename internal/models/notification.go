@@ -10,6 +10,7 @@ type Notification struct {
 	ChatroomId uint      `json:"chatroom"`
 	Type       string    `json:"type"`
 	SenderId   uint      `json:"senderId"`
+	MessageId  *uint     `gorm:"default:null" json:"messageId"` // message the notification points at (mentions, thread replies)
 	Content    string    `gorm:"type(text)" json:"content"`
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
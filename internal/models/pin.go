@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Pin marks a message as pinned in its chatroom. A message can be pinned once.
+type Pin struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatroomID uint      `gorm:"not null;index" json:"chatroom_id"`
+	MessageID  uint      `gorm:"not null;uniqueIndex" json:"message_id"`
+	PinnedBy   uint      `gorm:"not null" json:"pinned_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PinnedMessage is a pinned message with who pinned it and when. It is also the
+// payload of the "message_pinned" websocket event.
+type PinnedMessage struct {
+	Message  MessageWithUser `json:"message"`
+	PinnedBy string          `json:"pinned_by"`
+	PinnedAt time.Time       `json:"pinned_at"`
+}
+
+// PinRemovedPayload is broadcast as a "message_unpinned" websocket event.
+type PinRemovedPayload struct {
+	MessageID uint `json:"message_id"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// Poll is attached to a message of kind MessageKindPoll. The message content is the
+// question, so polls show up in search and history like any other message.
+type Poll struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID   uint         `gorm:"not null;uniqueIndex" json:"message_id"`
+	ChatroomID  uint         `gorm:"not null;index" json:"chatroom_id"`
+	MultiChoice bool         `gorm:"not null;default:false" json:"multi_choice"`
+	Anonymous   bool         `gorm:"not null;default:false" json:"anonymous"`
+	ClosesAt    *time.Time   `gorm:"index;default:null" json:"closes_at"` // the poll closes itself then, if set
+	ClosedAt    *time.Time   `gorm:"default:null" json:"closed_at"`
+	Options     []PollOption `gorm:"foreignKey:PollID" json:"options"`
+}
+
+// PollOption is one answer of a poll; Position orders them as the author listed them.
+type PollOption struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	PollID   uint   `gorm:"not null;index" json:"poll_id"`
+	Position int    `gorm:"not null" json:"position"`
+	Text     string `gorm:"type:varchar(100);not null" json:"text"`
+}
+
+// PollVote is a user's vote for one option. Multiple-choice polls allow several per user.
+type PollVote struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PollID    uint      `gorm:"not null;index" json:"poll_id"`
+	OptionID  uint      `gorm:"not null;index:idx_poll_vote,unique" json:"option_id"`
+	UserID    uint      `gorm:"not null;index:idx_poll_vote,unique" json:"user_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PollResults is a poll with its tallies as clients see it. It is also broadcast as a
+// "poll_updated" websocket event, without MyVotes.
+type PollResults struct {
+	MessageID   uint                `json:"message_id"`
+	MultiChoice bool                `json:"multi_choice"`
+	Anonymous   bool                `json:"anonymous"`
+	ClosesAt    *time.Time          `json:"closes_at"`
+	Closed      bool                `json:"closed"`
+	Voters      int                 `json:"voters"` // users who voted at all
+	Options     []PollOptionResults `json:"options"`
+	MyVotes     []uint              `json:"my_votes,omitempty"` // options the requesting user picked
+}
+
+// PollOptionResults is the tally of one option. Voters is left out of anonymous polls.
+type PollOptionResults struct {
+	ID     uint     `json:"id"`
+	Text   string   `json:"text"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
+
+// CreatePollRequest is the body of POST /api/chatrooms/{id}/polls. ClientID makes the
+// request idempotent like SendMessage.
+type CreatePollRequest struct {
+	Question    string     `json:"question"`
+	Options     []string   `json:"options"`
+	MultiChoice bool       `json:"multi_choice"`
+	Anonymous   bool       `json:"anonymous"`
+	ClosesAt    *time.Time `json:"closes_at"`
+	ClientID    string     `json:"client_id"`
+}
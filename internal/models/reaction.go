@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Reaction is a single user's emoji reaction to a message.
+type Reaction struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID uint      `gorm:"not null;index:idx_reaction,unique" json:"message_id"`
+	UserID    uint      `gorm:"not null;index:idx_reaction,unique" json:"user_id"`
+	Emoji     string    `gorm:"type:varchar(32);not null;index:idx_reaction,unique" json:"emoji"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ReactionCount aggregates the reactions on a message for one emoji.
+type ReactionCount struct {
+	Emoji     string   `json:"emoji"`
+	Count     int      `json:"count"`
+	Usernames []string `json:"usernames"`
+}
+
+// ReactionUpdatePayload is broadcast as a "reaction_updated" websocket event.
+type ReactionUpdatePayload struct {
+	MessageID uint            `json:"message_id"`
+	Reactions []ReactionCount `json:"reactions"`
+}
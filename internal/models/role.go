@@ -0,0 +1,141 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Permissions is a set of things a member may do in a room, stored as a bitset.
+type Permissions uint32
+
+const (
+	PermSend Permissions = 1 << iota
+	PermInvite
+	PermKick
+	PermBan
+	PermMute
+	PermPin
+	PermManageRoles
+	PermDeleteMessages // delete, and close polls in, other members' messages
+	PermManageRoom     // change the room's topic, message ttl and settings
+)
+
+// AllPermissions is what the owner holds.
+const AllPermissions = PermSend | PermInvite | PermKick | PermBan | PermMute | PermPin | PermManageRoles | PermDeleteMessages | PermManageRoom
+
+// ModeratorPermissions make a member one of the room's moderators: they may address
+// everyone with @here and are reached by @admins.
+const ModeratorPermissions = PermKick | PermBan | PermMute | PermDeleteMessages | PermManageRoles | PermManageRoom
+
+// permissionNames are the names permissions have in the API, in bit order.
+var permissionNames = []string{"send", "invite", "kick", "ban", "mute", "pin", "manage_roles", "delete_messages", "manage_room"}
+
+// Has reports whether every permission in want is in the set.
+func (p Permissions) Has(want Permissions) bool { return p&want == want }
+
+// Moderates reports whether the set holds any of the ModeratorPermissions.
+func (p Permissions) Moderates() bool { return p&ModeratorPermissions != 0 }
+
+// Names lists the permissions in the set.
+func (p Permissions) Names() []string {
+	names := []string{}
+	for i, name := range permissionNames {
+		if p&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ParsePermission looks up a permission by its API name.
+func ParsePermission(name string) (Permissions, error) {
+	for i, known := range permissionNames {
+		if name == known {
+			return 1 << i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown permission %q", name)
+}
+
+// PermissionNames lists every permission name, in bit order.
+func PermissionNames() []string { return append([]string(nil), permissionNames...) }
+
+// MarshalJSON writes the set as a list of names, e.g. ["send","pin"].
+func (p Permissions) MarshalJSON() ([]byte, error) { return json.Marshal(p.Names()) }
+
+func (p *Permissions) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*p = 0
+	for _, name := range names {
+		perm, err := ParsePermission(name)
+		if err != nil {
+			return err
+		}
+		*p |= perm
+	}
+	return nil
+}
+
+// Names of the built-in roles every room has.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Ranks of the built-in roles. Members can act only on members of a lower rank, and
+// grant only roles below their own.
+const (
+	OwnerRank  = 100
+	AdminRank  = 50
+	MemberRank = 0
+)
+
+// Role is a named set of permissions in one room. The built-in owner, admin and member
+// roles are held through UserChatroom.IsOwner, UserChatroom.IsAdmin and by default;
+// custom roles through UserChatroom.RoleID.
+type Role struct {
+	ID          uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatroomID  uint        `gorm:"not null;index:idx_role_name,unique" json:"chatroom_id"`
+	Name        string      `gorm:"type:varchar(50);not null;index:idx_role_name,unique" json:"name"`
+	Permissions Permissions `gorm:"not null;default:0" json:"permissions"`
+	Rank        uint        `gorm:"not null;default:0" json:"rank"`
+	BuiltIn     bool        `gorm:"default:false" json:"built_in"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BuiltInRoles returns the roles a room starts with. They keep the permissions the
+// owner and admin flags always carried: admins moderate, only the owner manages roles.
+func BuiltInRoles(chatroomID uint) []Role {
+	return []Role{
+		{ChatroomID: chatroomID, Name: RoleOwner, Permissions: AllPermissions, Rank: OwnerRank, BuiltIn: true},
+		{ChatroomID: chatroomID, Name: RoleAdmin, Permissions: AllPermissions &^ PermManageRoles, Rank: AdminRank, BuiltIn: true},
+		{ChatroomID: chatroomID, Name: RoleMember, Permissions: PermSend, Rank: MemberRank, BuiltIn: true},
+	}
+}
+
+// BuiltInRoleName names the built-in role a member holds, or "" when they hold a custom one.
+func (uc UserChatroom) BuiltInRoleName() string {
+	switch {
+	case uc.IsOwner:
+		return RoleOwner
+	case uc.RoleID != nil:
+		return ""
+	case uc.IsAdmin:
+		return RoleAdmin
+	default:
+		return RoleMember
+	}
+}
+
+// RolesUpdatedPayload is broadcast as a "roles_updated" websocket event when a room's
+// roles are created, changed or deleted.
+type RolesUpdatedPayload struct {
+	ChatroomID uint   `json:"chatroom_id"`
+	Roles      []Role `json:"roles"`
+}
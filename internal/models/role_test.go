@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPermissionsJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms Permissions
+		json  string
+	}{
+		{name: "none", perms: 0, json: `[]`},
+		{name: "some", perms: PermSend | PermPin, json: `["send","pin"]`},
+		{name: "all", perms: AllPermissions, json: `["send","invite","kick","ban","mute","pin","manage_roles","delete_messages","manage_room"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.perms)
+			if err != nil || string(data) != tt.json {
+				t.Fatalf("Marshal = %s, %v; want %s", data, err, tt.json)
+			}
+			var back Permissions
+			if err := json.Unmarshal(data, &back); err != nil || back != tt.perms {
+				t.Errorf("Unmarshal(%s) = %v, %v; want %v", data, back, err, tt.perms)
+			}
+		})
+	}
+}
+
+func TestPermissionsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Permissions
+		wantErr bool
+	}{
+		{name: "order and repeats do not matter", json: `["pin","send","pin"]`, want: PermSend | PermPin},
+		{name: "replaces the old set", json: `["kick"]`, want: PermKick},
+		{name: "unknown name", json: `["send","fly"]`, wantErr: true},
+		{name: "not a list", json: `3`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := AllPermissions
+			err := json.Unmarshal([]byte(tt.json), &p)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshal(%s) = %v, want an error", tt.json, p)
+				}
+				return
+			}
+			if err != nil || p != tt.want {
+				t.Errorf("Unmarshal(%s) = %v, %v; want %v", tt.json, p, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsModerates(t *testing.T) {
+	tests := []struct {
+		perms Permissions
+		want  bool
+	}{
+		{perms: 0, want: false},
+		{perms: PermSend | PermInvite | PermPin, want: false},
+		{perms: PermSend | PermMute, want: true},
+		{perms: PermManageRoom, want: true},
+		{perms: AllPermissions, want: true},
+	}
+	for _, tt := range tests {
+		if got := tt.perms.Moderates(); got != tt.want {
+			t.Errorf("%v.Moderates() = %v, want %v", tt.perms.Names(), got, tt.want)
+		}
+	}
+}
+
+func TestBuiltInRoleName(t *testing.T) {
+	custom := uint(7)
+	tests := []struct {
+		name string
+		uc   UserChatroom
+		want string
+	}{
+		{name: "owner", uc: UserChatroom{IsOwner: true, IsAdmin: true}, want: RoleOwner},
+		{name: "admin", uc: UserChatroom{IsAdmin: true}, want: RoleAdmin},
+		{name: "member", uc: UserChatroom{}, want: RoleMember},
+		{name: "custom role", uc: UserChatroom{IsAdmin: true, RoleID: &custom}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := tt.uc.BuiltInRoleName(); got != tt.want {
+			t.Errorf("%s: BuiltInRoleName() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
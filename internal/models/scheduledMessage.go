@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ScheduledPending = "pending"
+	ScheduledSent    = "sent"
+	ScheduledFailed  = "failed"
+)
+
+// ScheduledMessage is a message written now and posted to its chatroom at SendAt.
+type ScheduledMessage struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatroomID uint       `gorm:"not null;index" json:"chatroom_id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Content    string     `gorm:"type:text;not null" json:"content"`
+	SendAt     time.Time  `gorm:"not null;index:idx_scheduled_due" json:"send_at"`
+	Status     string     `gorm:"type:varchar(16);not null;default:pending;index:idx_scheduled_due" json:"status"`
+	MessageID  *uint      `gorm:"default:null" json:"message_id"` // the posted message, once sent
+	Error      string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	Attempts   uint       `gorm:"not null;default:0" json:"attempts"` // failed deliveries so far
+	RetryAt    *time.Time `gorm:"default:null" json:"retry_at"`       // not tried again before this
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	SentAt     *time.Time `gorm:"default:null" json:"sent_at"`
+}
@@ -5,11 +5,12 @@ import (
 )
 
 type User struct {
-	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
-	Password  string     `gorm:"type:varchar(100);not null" json:"password"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	LastLogin *time.Time `gorm:"type:datetime" json:"last_login"`
-	Chatrooms []Chatroom `gorm:"many2many:user_chatrooms;" json:"chatrooms"`
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	Password    string     `gorm:"type:varchar(100);not null" json:"password"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastLogin   *time.Time `gorm:"type:datetime" json:"last_login"`
+	Placeholder bool       `gorm:"default:false" json:"placeholder"` // stands in for an author of imported history; has no password, so cannot sign in
+	Chatrooms   []Chatroom `gorm:"many2many:user_chatrooms;" json:"chatrooms"`
 }
@@ -11,11 +11,41 @@ type UserChatroom struct {
 	ChatroomID    uint       `gorm:"not null;index:idx_user_chatroom,unique" json:"chatroom_id"`
 	IsAdmin       bool       `gorm:"default:false" json:"is_admin"`
 	IsOwner       bool       `gorm:"default:false" json:"is_owner"`
+	RoleID        *uint      `gorm:"default:null;index" json:"role_id"` // custom role; nil holds the built-in role IsOwner and IsAdmin give
 	IsJoined      bool       `gorm:"default:false" json:"is_joined"`
 	IsBanned      bool       `gorm:"default:false" json:"is_banned"`
 	LastJoinTime  *time.Time `gorm:"autoUpdateTime" json:"last_join_time"`
 	IsInvited     bool       `gorm:"default:false" json:"is_invited"`
 	InviteExpires *time.Time `gorm:"default:null" json:"invite_expires_at"`
+	LastReadID    *uint      `gorm:"default:null" json:"last_read_message_id"` // newest message the user has seen
+	IsMuted       bool       `gorm:"default:false" json:"is_muted"`
+	MutedUntil    *time.Time `gorm:"default:null;index" json:"muted_until"` // nil mutes until an admin lifts it
+	MuteReason    string     `gorm:"type:varchar(255);not null;default:''" json:"mute_reason"`
+	BannedUntil   *time.Time `gorm:"default:null;index" json:"banned_until"` // nil bans until an admin lifts it; the cleanup job lifts timed bans
+	BanReason     string     `gorm:"type:varchar(255);not null;default:''" json:"ban_reason"`
+	BannedBy      *uint      `gorm:"default:null" json:"banned_by"` // admin who placed the ban
+	BannedSince   *time.Time `gorm:"default:null" json:"banned_since"`
 	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// MutedAt reports whether the member's mute is in force at the given time. A timed mute
+// stops applying when it runs out, even before the cleanup job lifts it.
+func (uc UserChatroom) MutedAt(now time.Time) bool {
+	return uc.IsMuted && (uc.MutedUntil == nil || now.Before(*uc.MutedUntil))
+}
+
+// MemberUpdatedPayload is broadcast as a "member_updated" websocket event when a
+// member's standing in a room changes. Action says what happened, e.g. "muted".
+type MemberUpdatedPayload struct {
+	Action string       `json:"action"`
+	Member UserChatroom `json:"member"`
+}
+
+// UnreadCount summarizes what a member has not read yet in one of their rooms.
+type UnreadCount struct {
+	ChatroomID uint  `json:"chatroom_id"`
+	Unread     int64 `json:"unread"`
+	Mentions   int64 `json:"mentions"`
+	LastReadID *uint `json:"last_read_message_id"`
+}
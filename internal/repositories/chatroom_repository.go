@@ -1,9 +1,13 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatroomRepository interface {
@@ -13,10 +17,37 @@ type ChatroomRepository interface {
 	CreateUserChatroom(uc *models.UserChatroom) error
 	SaveUserChatroom(uc *models.UserChatroom) error
 	CountJoinedUsers(chatroomID any) (int64, error)
+	ListJoinedMembers(chatroomID any) ([]models.UserChatroom, error)
+	MarkRead(userID, chatroomID, messageID uint) error
+	UnreadCounts(userID uint) ([]models.UnreadCount, error)
 	DeleteChatroomByID(id any) error
 	DeleteUserChatroomsByChatroomID(chatroomID any) error
 	SaveNotification(n *models.Notification) error
 	SaveChatroom(c *models.Chatroom) error
+	FindDirect(key string) (*models.Chatroom, error)
+	CreateDirect(room *models.Chatroom, members []models.UserChatroom) error
+	CreateWithOwner(room *models.Chatroom, owner models.UserChatroom) error
+	SaveMute(uc *models.UserChatroom) error
+	ExpiredMutes(now time.Time, limit int) ([]models.UserChatroom, error)
+	LiftExpiredMute(id uint, now time.Time) (bool, error)
+	SaveBan(uc *models.UserChatroom) error
+	ListBanned(chatroomID uint) ([]models.UserChatroom, error)
+	ExpiredBans(now time.Time, limit int) ([]models.UserChatroom, error)
+	LiftExpiredBan(id uint, now time.Time) (bool, error)
+	OfferOwnership(chatroomID, ownerID, userID uint, expires time.Time) (bool, error)
+	WithdrawOwnershipOffer(chatroomID, userID uint) (bool, error)
+	AcceptOwnership(chatroomID, userID uint, now time.Time) (uint, error)
+	HandOverOwnership(leaving *models.UserChatroom) (*models.UserChatroom, error)
+	EnsureBuiltInRoles(chatroomID uint) error
+	ListRoles(chatroomID uint) ([]models.Role, error)
+	FindRole(chatroomID, roleID uint) (*models.Role, error)
+	FindRoleByName(chatroomID uint, name string) (*models.Role, error)
+	CreateRole(role *models.Role) error
+	SaveRole(role *models.Role) error
+	DeleteRole(role *models.Role) error
+	AssignRole(uc *models.UserChatroom, roleID *uint, isAdmin bool) error
+	MemberRole(uc *models.UserChatroom) (models.Role, error)
+	ListDirect(userID uint) ([]models.DirectConversation, error)
 }
 
 type GormChatroomRepository struct{ db *gorm.DB }
@@ -44,7 +75,7 @@ func (r *GormChatroomRepository) GetPublicChatroomsNotJoined(userID uint) ([]mod
 		Select("chatroom_id").
 		Where("user_id = ? AND is_banned = ?", userID, true)
 	err := r.db.Preload("Users").
-		Where("id NOT IN (?) AND id NOT IN (?) AND is_public = ?", joinedSub, bannedSub, true).
+		Where("id NOT IN (?) AND id NOT IN (?) AND is_public = ? AND is_direct = ?", joinedSub, bannedSub, true, false).
 		Find(&chatrooms).Error
 	return chatrooms, err
 }
@@ -70,8 +101,51 @@ func (r *GormChatroomRepository) CountJoinedUsers(chatroomID any) (int64, error)
 	return count, err
 }
 
+// ListJoinedMembers returns the memberships of everyone currently in the room and not banned.
+func (r *GormChatroomRepository) ListJoinedMembers(chatroomID any) ([]models.UserChatroom, error) {
+	var members []models.UserChatroom
+	err := r.db.Where("chatroom_id = ? AND is_joined = ? AND is_banned = ?", chatroomID, true, false).Find(&members).Error
+	return members, err
+}
+
+// MarkRead moves the member's read marker forward to messageID; it never moves backwards.
+// UpdateColumn is used so the membership's timestamps (notably LastJoinTime) are left alone.
+func (r *GormChatroomRepository) MarkRead(userID, chatroomID, messageID uint) error {
+	return r.db.Model(&models.UserChatroom{}).
+		Where("user_id = ? AND chatroom_id = ? AND (last_read_id IS NULL OR last_read_id < ?)", userID, chatroomID, messageID).
+		UpdateColumn("last_read_id", messageID).Error
+}
+
+// UnreadCounts returns, for every room the user has joined, the number of top-level
+// messages from others past their read marker that have not expired, and how many of
+// those mention them.
+func (r *GormChatroomRepository) UnreadCounts(userID uint) ([]models.UnreadCount, error) {
+	var counts []models.UnreadCount
+	unreadSub := `(SELECT COUNT(*) FROM messages
+		WHERE messages.chatroom_id = user_chatrooms.chatroom_id
+		AND messages.id > COALESCE(user_chatrooms.last_read_id, 0)
+		AND messages.parent_id IS NULL AND messages.deleted_at IS NULL
+		AND (messages.expires_at IS NULL OR messages.expires_at > ?)
+		AND messages.user_id <> user_chatrooms.user_id)`
+	mentionSub := `(SELECT COUNT(*) FROM notifications
+		WHERE notifications.user_id = user_chatrooms.user_id
+		AND notifications.chatroom_id = user_chatrooms.chatroom_id
+		AND notifications.type = 'mention'
+		AND notifications.message_id > COALESCE(user_chatrooms.last_read_id, 0))`
+	err := r.db.Model(&models.UserChatroom{}).
+		Select("user_chatrooms.chatroom_id, "+unreadSub+" AS unread, "+mentionSub+" AS mentions, user_chatrooms.last_read_id", time.Now()).
+		Where("user_chatrooms.user_id = ? AND user_chatrooms.is_joined = ? AND user_chatrooms.is_banned = ?", userID, true, false).
+		Scan(&counts).Error
+	return counts, err
+}
+
 func (r *GormChatroomRepository) DeleteChatroomByID(id any) error {
-	return r.db.Where("id = ?", id).Delete(&models.Chatroom{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("chatroom_id = ?", id).Delete(&models.Role{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Chatroom{}).Error
+	})
 }
 func (r *GormChatroomRepository) DeleteUserChatroomsByChatroomID(chatroomID any) error {
 	return r.db.Where("chatroom_id = ?", chatroomID).Delete(&models.UserChatroom{}).Error
@@ -81,4 +155,320 @@ func (r *GormChatroomRepository) SaveNotification(n *models.Notification) error
 }
 func (r *GormChatroomRepository) SaveChatroom(c *models.Chatroom) error { return r.db.Save(c).Error }
 
+// FindDirect returns the direct-message room with the given pair key.
+func (r *GormChatroomRepository) FindDirect(key string) (*models.Chatroom, error) {
+	var c models.Chatroom
+	if err := r.db.Where("is_direct = ? AND direct_key = ?", true, key).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateDirect stores a direct-message room together with its two memberships. The
+// unique pair key makes a concurrent duplicate fail instead of creating a second room.
+func (r *GormChatroomRepository) CreateDirect(room *models.Chatroom, members []models.UserChatroom) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			members[i].ChatroomID = room.Id
+		}
+		return tx.Select("UserID", "Name", "ChatroomID", "IsJoined", "LastJoinTime").Create(&members).Error
+	})
+}
+
+// SaveMute writes the member's mute fields only. UpdateColumns leaves the membership's
+// timestamps alone, as in MarkRead.
+func (r *GormChatroomRepository) SaveMute(uc *models.UserChatroom) error {
+	return r.db.Model(uc).UpdateColumns(map[string]any{
+		"is_muted":    uc.IsMuted,
+		"muted_until": uc.MutedUntil,
+		"mute_reason": uc.MuteReason,
+	}).Error
+}
+
+// ExpiredMutes returns up to limit members whose timed mute has run out.
+func (r *GormChatroomRepository) ExpiredMutes(now time.Time, limit int) ([]models.UserChatroom, error) {
+	var members []models.UserChatroom
+	err := r.db.
+		Where("is_muted = ? AND muted_until IS NOT NULL AND muted_until <= ?", true, now).
+		Order("muted_until ASC").Limit(limit).
+		Find(&members).Error
+	return members, err
+}
+
+// LiftExpiredMute clears a mute that has run out by now. It reports false when the
+// member was unmuted or muted again in the meantime.
+func (r *GormChatroomRepository) LiftExpiredMute(id uint, now time.Time) (bool, error) {
+	res := r.db.Model(&models.UserChatroom{}).
+		Where("id = ? AND is_muted = ? AND muted_until IS NOT NULL AND muted_until <= ?", id, true, now).
+		UpdateColumns(map[string]any{"is_muted": false, "muted_until": nil, "mute_reason": ""})
+	return res.RowsAffected > 0, res.Error
+}
+
+// SaveBan writes the member's ban and membership flags only, like SaveMute.
+func (r *GormChatroomRepository) SaveBan(uc *models.UserChatroom) error {
+	return r.db.Model(uc).UpdateColumns(map[string]any{
+		"is_banned":    uc.IsBanned,
+		"is_joined":    uc.IsJoined,
+		"is_invited":   uc.IsInvited,
+		"is_admin":     uc.IsAdmin,
+		"role_id":      uc.RoleID,
+		"banned_until": uc.BannedUntil,
+		"ban_reason":   uc.BanReason,
+		"banned_by":    uc.BannedBy,
+		"banned_since": uc.BannedSince,
+	}).Error
+}
+
+// ListBanned returns the room's banned members, most recently banned first.
+func (r *GormChatroomRepository) ListBanned(chatroomID uint) ([]models.UserChatroom, error) {
+	var members []models.UserChatroom
+	err := r.db.
+		Where("chatroom_id = ? AND is_banned = ?", chatroomID, true).
+		Order("banned_since DESC, id DESC").
+		Find(&members).Error
+	return members, err
+}
+
+// ExpiredBans returns up to limit members whose timed ban has run out.
+func (r *GormChatroomRepository) ExpiredBans(now time.Time, limit int) ([]models.UserChatroom, error) {
+	var members []models.UserChatroom
+	err := r.db.
+		Where("is_banned = ? AND banned_until IS NOT NULL AND banned_until <= ?", true, now).
+		Order("banned_until ASC").Limit(limit).
+		Find(&members).Error
+	return members, err
+}
+
+// LiftExpiredBan clears a ban that has run out by now. It reports false when the member
+// was unbanned or banned again in the meantime.
+func (r *GormChatroomRepository) LiftExpiredBan(id uint, now time.Time) (bool, error) {
+	res := r.db.Model(&models.UserChatroom{}).
+		Where("id = ? AND is_banned = ? AND banned_until IS NOT NULL AND banned_until <= ?", id, true, now).
+		UpdateColumns(map[string]any{"is_banned": false, "banned_until": nil, "ban_reason": "", "banned_by": nil, "banned_since": nil})
+	return res.RowsAffected > 0, res.Error
+}
+
+// AssignRole gives the member a custom role, or with a nil roleID the built-in admin
+// or member role.
+func (r *GormChatroomRepository) AssignRole(uc *models.UserChatroom, roleID *uint, isAdmin bool) error {
+	if err := r.db.Model(uc).UpdateColumns(map[string]any{"role_id": roleID, "is_admin": isAdmin}).Error; err != nil {
+		return err
+	}
+	uc.RoleID, uc.IsAdmin = roleID, isAdmin
+	return nil
+}
+
+// EnsureBuiltInRoles stores whichever of the room's built-in roles are missing.
+func (r *GormChatroomRepository) EnsureBuiltInRoles(chatroomID uint) error {
+	roles := models.BuiltInRoles(chatroomID)
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&roles).Error
+}
+
+// ListRoles returns the room's roles, highest rank first.
+func (r *GormChatroomRepository) ListRoles(chatroomID uint) ([]models.Role, error) {
+	if err := r.EnsureBuiltInRoles(chatroomID); err != nil {
+		return nil, err
+	}
+	var roles []models.Role
+	err := r.db.Where("chatroom_id = ?", chatroomID).Order("`rank` DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *GormChatroomRepository) FindRole(chatroomID, roleID uint) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("id = ? AND chatroom_id = ?", roleID, chatroomID).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *GormChatroomRepository) FindRoleByName(chatroomID uint, name string) (*models.Role, error) {
+	if err := r.EnsureBuiltInRoles(chatroomID); err != nil {
+		return nil, err
+	}
+	var role models.Role
+	if err := r.db.Where("chatroom_id = ? AND name = ?", chatroomID, name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *GormChatroomRepository) CreateRole(role *models.Role) error { return r.db.Create(role).Error }
+
+func (r *GormChatroomRepository) SaveRole(role *models.Role) error { return r.db.Save(role).Error }
+
+// DeleteRole deletes a custom role; the members who held it fall back to the member role.
+func (r *GormChatroomRepository) DeleteRole(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserChatroom{}).Where("role_id = ?", role.ID).
+			UpdateColumn("role_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+}
+
+// MemberRole returns the role whose permissions the member holds. A custom role that
+// has since been deleted counts as the member role, and a built-in role the room has
+// not stored yet as its defaults.
+func (r *GormChatroomRepository) MemberRole(uc *models.UserChatroom) (models.Role, error) {
+	name := uc.BuiltInRoleName()
+	if name == "" {
+		role, err := r.FindRole(uc.ChatroomID, *uc.RoleID)
+		if err == nil {
+			return *role, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Role{}, err
+		}
+		name = models.RoleMember
+	}
+	var role models.Role
+	err := r.db.Where("chatroom_id = ? AND name = ? AND built_in = ?", uc.ChatroomID, name, true).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		for _, builtIn := range models.BuiltInRoles(uc.ChatroomID) {
+			if builtIn.Name == name {
+				return builtIn, nil
+			}
+		}
+	}
+	return role, err
+}
+
+// OfferOwnership records that the room's owner offers it to userID until expires,
+// replacing any earlier offer. It reports false when ownerID no longer owns the room.
+func (r *GormChatroomRepository) OfferOwnership(chatroomID, ownerID, userID uint, expires time.Time) (bool, error) {
+	res := r.db.Model(&models.Chatroom{}).
+		Where("id = ? AND owner_id = ?", chatroomID, ownerID).
+		UpdateColumns(map[string]any{"pending_owner_id": userID, "owner_offer_expires": expires})
+	return res.RowsAffected > 0, res.Error
+}
+
+// WithdrawOwnershipOffer drops the room's ownership offer to userID. It reports false
+// when there was none.
+func (r *GormChatroomRepository) WithdrawOwnershipOffer(chatroomID, userID uint) (bool, error) {
+	res := r.db.Model(&models.Chatroom{}).
+		Where("id = ? AND pending_owner_id = ?", chatroomID, userID).
+		UpdateColumns(map[string]any{"pending_owner_id": nil, "owner_offer_expires": nil})
+	return res.RowsAffected > 0, res.Error
+}
+
+// AcceptOwnership makes userID the room's owner if they hold an unexpired offer and are
+// still a member. The room row is locked while the roles change, so the room always has
+// exactly one owner. It returns the previous owner, or 0 when there was no such offer.
+func (r *GormChatroomRepository) AcceptOwnership(chatroomID, userID uint, now time.Time) (uint, error) {
+	var previous uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var room models.Chatroom
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, chatroomID).Error; err != nil {
+			return err
+		}
+		if room.PendingOwnerID == nil || *room.PendingOwnerID != userID ||
+			room.OwnerOfferExpires == nil || !now.Before(*room.OwnerOfferExpires) {
+			return nil
+		}
+		var member models.UserChatroom
+		err := tx.Where("user_id = ? AND chatroom_id = ? AND is_joined = ? AND is_banned = ?", userID, chatroomID, true, false).
+			First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := makeOwner(tx, chatroomID, &member); err != nil {
+			return err
+		}
+		previous = room.OwnerId
+		return nil
+	})
+	return previous, err
+}
+
+// HandOverOwnership saves the leaving owner's membership and makes the longest-standing
+// admin, or else member, the owner in the same transaction. It returns the new owner,
+// or nil when nobody is left.
+func (r *GormChatroomRepository) HandOverOwnership(leaving *models.UserChatroom) (*models.UserChatroom, error) {
+	var successor *models.UserChatroom
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Chatroom{}, leaving.ChatroomID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(leaving).Error; err != nil {
+			return err
+		}
+		var next models.UserChatroom
+		err := tx.Where("chatroom_id = ? AND is_joined = ? AND is_banned = ?", leaving.ChatroomID, true, false).
+			Order("is_admin DESC, last_join_time ASC").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := makeOwner(tx, leaving.ChatroomID, &next); err != nil {
+			return err
+		}
+		successor = &next
+		return nil
+	})
+	return successor, err
+}
+
+// makeOwner makes member the room's one owner, and an admin, and drops any pending
+// ownership offer. The previous owner stays an admin, whatever custom role the new
+// owner held is dropped.
+func makeOwner(tx *gorm.DB, chatroomID uint, member *models.UserChatroom) error {
+	if err := tx.Model(&models.UserChatroom{}).
+		Where("chatroom_id = ? AND is_owner = ? AND id <> ?", chatroomID, true, member.ID).
+		UpdateColumn("is_owner", false).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(member).UpdateColumns(map[string]any{"is_owner": true, "is_admin": true, "role_id": nil}).Error; err != nil {
+		return err
+	}
+	member.IsOwner, member.IsAdmin, member.RoleID = true, true, nil
+	return tx.Model(&models.Chatroom{}).Where("id = ?", chatroomID).
+		UpdateColumns(map[string]any{"owner_id": member.UserID, "pending_owner_id": nil, "owner_offer_expires": nil}).Error
+}
+
+// CreateWithOwner creates a room and makes owner its owner and first member.
+func (r *GormChatroomRepository) CreateWithOwner(room *models.Chatroom, owner models.UserChatroom) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+		owner.ChatroomID = room.Id
+		return tx.Select("UserID", "Name", "ChatroomID", "IsJoined", "IsAdmin", "IsOwner", "LastJoinTime").Create(&owner).Error
+	})
+}
+
+// ListDirect returns the direct-message rooms the user is in, most recently active first.
+func (r *GormChatroomRepository) ListDirect(userID uint) ([]models.DirectConversation, error) {
+	var rows []struct {
+		models.Chatroom
+		PeerID   uint
+		PeerName string
+	}
+	err := r.db.Table("chatrooms").
+		Select("chatrooms.*, peer.user_id AS peer_id, peer.name AS peer_name").
+		Joins("JOIN user_chatrooms me ON me.chatroom_id = chatrooms.id AND me.user_id = ? AND me.is_joined = ?", userID, true).
+		Joins("JOIN user_chatrooms peer ON peer.chatroom_id = chatrooms.id AND peer.user_id <> ?", userID).
+		Where("chatrooms.is_direct = ?", true).
+		Order("COALESCE((SELECT MAX(messages.id) FROM messages WHERE messages.chatroom_id = chatrooms.id), 0) DESC, chatrooms.id DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	conversations := make([]models.DirectConversation, len(rows))
+	for i, row := range rows {
+		conversations[i] = models.DirectConversation{Chatroom: row.Chatroom, PeerID: row.PeerID, PeerName: row.PeerName}
+	}
+	return conversations, nil
+}
+
 func DefaultChatroomRepository() ChatroomRepository { return NewChatroomRepository(config.DB) }
@@ -1,13 +1,81 @@
 package repositories
 
 import (
+    "errors"
+    "slices"
+    "strings"
+    "time"
+
     "github.com/Wal-20/cli-chat-app/internal/config"
     "github.com/Wal-20/cli-chat-app/internal/models"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 )
 
 type MessageRepository interface {
     Create(message *models.Message) error
+    FindByID(id uint) (*models.Message, error)
+    FindByClientID(userID, chatroomID uint, clientID string) (*models.Message, error)
+    LastSentAt(userID, chatroomID uint) (time.Time, error)
+    SaveEdit(message *models.Message, edit *models.MessageEdit) error
+    SoftDelete(message *models.Message) error
+    ListByChatroom(chatroomID uint, query MessageQuery) (models.MessagePage, error)
+    FindWithUser(id uint) (*models.MessageWithUser, error)
+    ListReplies(rootID uint) ([]models.MessageWithUser, error)
+    CountReplies(rootID uint) (int64, error)
+    ThreadParticipantIDs(rootID uint) ([]uint, error)
+    AddReaction(reaction *models.Reaction) error
+    RemoveReaction(messageID, userID uint, emoji string) error
+    ReactionCounts(messageIDs []uint) (map[uint][]models.ReactionCount, error)
+    Attachments(messageIDs []uint) (map[uint]*models.Attachment, error)
+    FindAttachment(id uint) (*models.Attachment, error)
+    Polls(messageIDs []uint) (map[uint]*models.PollResults, error)
+    AddPin(pin *models.Pin) (bool, error)
+    FindPin(messageID uint) (*models.Pin, error)
+    RemovePin(chatroomID, messageID uint) (bool, error)
+    ListPins(chatroomID uint) ([]models.PinnedMessage, error)
+    Search(userID uint, filter SearchFilter) ([]models.SearchHit, error)
+    PurgeExpired(now time.Time, limit int) ([]models.Message, error)
+    ExportBatch(chatroomID uint, filter ExportFilter, afterID uint, limit int) ([]models.MessageWithUser, error)
+    EditHistory(messageIDs []uint) (map[uint][]models.MessageEdit, error)
+    Import(message *models.Message) (bool, error)
+}
+
+const (
+    DefaultMessagePageSize = 20
+    MaxMessagePageSize     = 100
+)
+
+// MessageQuery selects a page of a chatroom's history. Before and After are message-id
+// cursors (exclusive); when neither is set the newest page is returned. Thread replies
+// only show up in the main history when searching.
+type MessageQuery struct {
+    Before uint
+    After  uint
+    Limit  int
+    Search string
+}
+
+// SearchFilter narrows a search across every room the user has joined. All Terms and
+// Phrases must appear in the content; From matches the author's name exactly and Room
+// matches the start of the room title. Before and After are exclusive bounds. BeforeID,
+// when set, pages on from the oldest match of the previous page.
+type SearchFilter struct {
+    Terms    []string
+    Phrases  []string
+    From     string
+    Room     string
+    Before   *time.Time
+    After    *time.Time
+    BeforeID uint
+    Limit    int
+}
+
+// ExportFilter bounds a transcript export by creation time: From is inclusive and To
+// exclusive, and either may be nil.
+type ExportFilter struct {
+    From *time.Time
+    To   *time.Time
 }
 
 type GormMessageRepository struct { db *gorm.DB }
@@ -16,5 +84,424 @@ func NewMessageRepository(db *gorm.DB) *GormMessageRepository { return &GormMess
 
 func (r *GormMessageRepository) Create(message *models.Message) error { return r.db.Create(message).Error }
 
-func DefaultMessageRepository() MessageRepository { return NewMessageRepository(config.DB) }
+func (r *GormMessageRepository) FindByID(id uint) (*models.Message, error) {
+    var m models.Message
+    if err := r.db.First(&m, id).Error; err != nil { return nil, err }
+    return &m, nil
+}
+
+// FindByClientID looks up the message a sender stored in the room under an idempotency key.
+func (r *GormMessageRepository) FindByClientID(userID, chatroomID uint, clientID string) (*models.Message, error) {
+    var m models.Message
+    if err := r.db.Where("user_id = ? AND chatroom_id = ? AND client_id = ?", userID, chatroomID, clientID).First(&m).Error; err != nil { return nil, err }
+    return &m, nil
+}
+
+// LastSentAt returns when the user last posted in the room, deleted messages included.
+func (r *GormMessageRepository) LastSentAt(userID, chatroomID uint) (time.Time, error) {
+    var m models.Message
+    if err := r.db.Select("created_at").Where("user_id = ? AND chatroom_id = ?", userID, chatroomID).Order("id DESC").First(&m).Error; err != nil { return time.Time{}, err }
+    return m.CreatedAt, nil
+}
+
+// Import stores a message brought over from another chat system unless an earlier import
+// stored it already, which its ClientID tells. The author is left out of that check, so
+// an import run again with other author mappings does not store messages twice.
+// message.ID is set either way; the result reports whether a row was added.
+func (r *GormMessageRepository) Import(message *models.Message) (bool, error) {
+    var existing models.Message
+    err := r.db.Select("id").Where("chatroom_id = ? AND client_id = ?", message.ChatroomID, *message.ClientID).First(&existing).Error
+    if err == nil {
+        message.ID = existing.ID
+        return false, nil
+    }
+    if !errors.Is(err, gorm.ErrRecordNotFound) { return false, err }
+    return true, r.db.Create(message).Error
+}
+
+// SaveEdit stores the previous version and the updated message in a single transaction.
+func (r *GormMessageRepository) SaveEdit(message *models.Message, edit *models.MessageEdit) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(edit).Error; err != nil { return err }
+        return tx.Model(message).Updates(map[string]any{
+            "content":   message.Content,
+            "edited_at": message.EditedAt,
+        }).Error
+    })
+}
+
+// SoftDelete flags the message as removed; the row is kept so clients can render a tombstone.
+func (r *GormMessageRepository) SoftDelete(message *models.Message) error {
+    return r.db.Model(message).Updates(map[string]any{
+        "deleted_at": message.DeletedAt,
+        "deleted_by": message.DeletedBy,
+    }).Error
+}
+
+func (r *GormMessageRepository) ListByChatroom(chatroomID uint, query MessageQuery) (models.MessagePage, error) {
+    limit := query.Limit
+    if limit <= 0 { limit = DefaultMessagePageSize }
+    if limit > MaxMessagePageSize { limit = MaxMessagePageSize }
+
+    q := r.withUserQuery().Where("messages.chatroom_id = ?", chatroomID)
+
+    if query.Search != "" {
+        q = q.Where("messages.deleted_at IS NULL AND messages.content LIKE ?", "%"+escapeLike(query.Search)+"%")
+    } else {
+        q = q.Where("messages.parent_id IS NULL")
+    }
+
+    // Ids are monotonic, so they double as a stable keyset for paging.
+    forward := query.After != 0 && query.Before == 0
+    switch {
+    case forward:
+        q = q.Where("messages.id > ?", query.After).Order("messages.id ASC")
+    case query.Before != 0:
+        q = q.Where("messages.id < ?", query.Before).Order("messages.id DESC")
+    default:
+        q = q.Order("messages.id DESC")
+    }
+
+    var messages []models.MessageWithUser
+    // Fetch one extra row to learn whether another page exists.
+    if err := q.Limit(limit + 1).Scan(&messages).Error; err != nil {
+        return models.MessagePage{}, err
+    }
+
+    hasMore := len(messages) > limit
+    if hasMore { messages = messages[:limit] }
+    if !forward { slices.Reverse(messages) }
+
+    if err := r.attachDetails(messages); err != nil {
+        return models.MessagePage{}, err
+    }
+
+    page := models.MessagePage{Messages: messages}
+    if hasMore && len(messages) > 0 {
+        cursor := messages[0].ID
+        if forward { cursor = messages[len(messages)-1].ID }
+        page.NextCursor = &cursor
+    }
+    return page, nil
+}
+
+func (r *GormMessageRepository) FindWithUser(id uint) (*models.MessageWithUser, error) {
+    var m models.MessageWithUser
+    res := r.withUserQuery().Where("messages.id = ?", id).Limit(1).Scan(&m)
+    if res.Error != nil { return nil, res.Error }
+    if res.RowsAffected == 0 { return nil, gorm.ErrRecordNotFound }
+    single := []models.MessageWithUser{m}
+    if err := r.attachDetails(single); err != nil { return nil, err }
+    return &single[0], nil
+}
+
+func (r *GormMessageRepository) ListReplies(rootID uint) ([]models.MessageWithUser, error) {
+    var replies []models.MessageWithUser
+    if err := r.withUserQuery().Where("messages.parent_id = ?", rootID).Order("messages.id ASC").Scan(&replies).Error; err != nil {
+        return nil, err
+    }
+    return replies, r.attachDetails(replies)
+}
+
+func (r *GormMessageRepository) CountReplies(rootID uint) (int64, error) {
+    var count int64
+    err := r.db.Model(&models.Message{}).Where("parent_id = ? AND deleted_at IS NULL", rootID).Count(&count).Error
+    return count, err
+}
+
+// ThreadParticipantIDs returns the authors of the root message and of every live reply.
+func (r *GormMessageRepository) ThreadParticipantIDs(rootID uint) ([]uint, error) {
+    var ids []uint
+    err := r.db.Model(&models.Message{}).
+        Distinct("user_id").
+        Where("(id = ? OR parent_id = ?) AND deleted_at IS NULL", rootID, rootID).
+        Pluck("user_id", &ids).Error
+    return ids, err
+}
+
+// AddReaction stores a reaction; reacting twice with the same emoji is a no-op.
+func (r *GormMessageRepository) AddReaction(reaction *models.Reaction) error {
+    return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+func (r *GormMessageRepository) RemoveReaction(messageID, userID uint, emoji string) error {
+    return r.db.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+        Delete(&models.Reaction{}).Error
+}
+
+// ReactionCounts aggregates reactions per message, keeping emojis in the order they were first used.
+func (r *GormMessageRepository) ReactionCounts(messageIDs []uint) (map[uint][]models.ReactionCount, error) {
+    counts := make(map[uint][]models.ReactionCount)
+    if len(messageIDs) == 0 { return counts, nil }
+
+    var rows []struct {
+        MessageID uint
+        Emoji     string
+        Username  string
+    }
+    err := r.db.Table("reactions").
+        Select("reactions.message_id, reactions.emoji, users.name AS username").
+        Joins("JOIN users ON reactions.user_id = users.id").
+        Where("reactions.message_id IN ?", messageIDs).
+        Order("reactions.id ASC").
+        Scan(&rows).Error
+    if err != nil { return nil, err }
 
+    for _, row := range rows {
+        list := counts[row.MessageID]
+        idx := slices.IndexFunc(list, func(c models.ReactionCount) bool { return c.Emoji == row.Emoji })
+        if idx < 0 {
+            list = append(list, models.ReactionCount{Emoji: row.Emoji})
+            idx = len(list) - 1
+        }
+        list[idx].Count++
+        list[idx].Usernames = append(list[idx].Usernames, row.Username)
+        counts[row.MessageID] = list
+    }
+    return counts, nil
+}
+
+// AddPin pins a message; it reports false when the message was already pinned.
+func (r *GormMessageRepository) AddPin(pin *models.Pin) (bool, error) {
+    res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+    return res.RowsAffected > 0, res.Error
+}
+
+// FindPin returns the pin on a message.
+func (r *GormMessageRepository) FindPin(messageID uint) (*models.Pin, error) {
+    var pin models.Pin
+    if err := r.db.Where("message_id = ?", messageID).First(&pin).Error; err != nil { return nil, err }
+    return &pin, nil
+}
+
+// RemovePin unpins a message; it reports false when the message was not pinned.
+func (r *GormMessageRepository) RemovePin(chatroomID, messageID uint) (bool, error) {
+    res := r.db.Where("chatroom_id = ? AND message_id = ?", chatroomID, messageID).Delete(&models.Pin{})
+    return res.RowsAffected > 0, res.Error
+}
+
+// ListPins returns the room's pinned messages, most recently pinned first. Pins of
+// deleted messages are left out.
+func (r *GormMessageRepository) ListPins(chatroomID uint) ([]models.PinnedMessage, error) {
+    var pins []struct {
+        MessageID uint
+        PinnedBy  string
+        CreatedAt time.Time
+    }
+    err := r.db.Table("pins").
+        Select("pins.message_id, users.name AS pinned_by, pins.created_at").
+        Joins("JOIN users ON pins.pinned_by = users.id").
+        Where("pins.chatroom_id = ?", chatroomID).
+        Order("pins.id DESC").
+        Scan(&pins).Error
+    if err != nil || len(pins) == 0 { return nil, err }
+
+    ids := make([]uint, len(pins))
+    for i, p := range pins { ids[i] = p.MessageID }
+    var messages []models.MessageWithUser
+    if err := r.withUserQuery().Where("messages.id IN ? AND messages.deleted_at IS NULL", ids).Scan(&messages).Error; err != nil {
+        return nil, err
+    }
+    if err := r.attachDetails(messages); err != nil { return nil, err }
+
+    byID := make(map[uint]models.MessageWithUser, len(messages))
+    for _, m := range messages { byID[m.ID] = m }
+    result := make([]models.PinnedMessage, 0, len(pins))
+    for _, p := range pins {
+        if m, ok := byID[p.MessageID]; ok {
+            result = append(result, models.PinnedMessage{Message: m, PinnedBy: p.PinnedBy, PinnedAt: p.CreatedAt})
+        }
+    }
+    return result, nil
+}
+
+// Search returns the newest live messages matching filter, limited to rooms the user
+// has joined and is not banned from. Ranking is left to the caller.
+func (r *GormMessageRepository) Search(userID uint, filter SearchFilter) ([]models.SearchHit, error) {
+    q := r.withUserQuery().
+        Select(messageWithUserColumns+", messages.chatroom_id, chatrooms.title AS chatroom_title").
+        Joins("JOIN chatrooms ON chatrooms.id = messages.chatroom_id").
+        Joins("JOIN user_chatrooms ON user_chatrooms.chatroom_id = messages.chatroom_id AND user_chatrooms.user_id = ?", userID).
+        Where("user_chatrooms.is_joined = ? AND user_chatrooms.is_banned = ? AND messages.deleted_at IS NULL", true, false)
+
+    for _, text := range append(append([]string(nil), filter.Terms...), filter.Phrases...) {
+        q = q.Where("messages.content LIKE ?", "%"+escapeLike(text)+"%")
+    }
+    if filter.From != "" { q = q.Where("users.name = ?", filter.From) }
+    if filter.Room != "" { q = q.Where("chatrooms.title LIKE ?", escapeLike(filter.Room)+"%") }
+    if filter.Before != nil { q = q.Where("messages.created_at < ?", *filter.Before) }
+    if filter.After != nil { q = q.Where("messages.created_at > ?", *filter.After) }
+    if filter.BeforeID != 0 { q = q.Where("messages.id < ?", filter.BeforeID) }
+
+    limit := filter.Limit
+    if limit <= 0 || limit > MaxMessagePageSize { limit = MaxMessagePageSize }
+
+    var rows []struct {
+        models.MessageWithUser
+        ChatroomID    uint
+        ChatroomTitle string
+    }
+    if err := q.Order("messages.id DESC").Limit(limit).Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+
+    hits := make([]models.SearchHit, len(rows))
+    for i, row := range rows {
+        hits[i] = models.SearchHit{Message: row.MessageWithUser, ChatroomID: row.ChatroomID, ChatroomTitle: row.ChatroomTitle}
+    }
+    return hits, nil
+}
+
+// ExportBatch returns up to limit messages of the room after afterID, thread replies
+// included, oldest first, with their details.
+func (r *GormMessageRepository) ExportBatch(chatroomID uint, filter ExportFilter, afterID uint, limit int) ([]models.MessageWithUser, error) {
+    q := r.withUserQuery().Where("messages.chatroom_id = ? AND messages.id > ?", chatroomID, afterID)
+    if filter.From != nil { q = q.Where("messages.created_at >= ?", *filter.From) }
+    if filter.To != nil { q = q.Where("messages.created_at < ?", *filter.To) }
+    var messages []models.MessageWithUser
+    if err := q.Order("messages.id ASC").Limit(limit).Scan(&messages).Error; err != nil {
+        return nil, err
+    }
+    return messages, r.attachDetails(messages)
+}
+
+// EditHistory returns the previous versions of the given messages, oldest first, keyed
+// by message id.
+func (r *GormMessageRepository) EditHistory(messageIDs []uint) (map[uint][]models.MessageEdit, error) {
+    byMessage := make(map[uint][]models.MessageEdit)
+    if len(messageIDs) == 0 { return byMessage, nil }
+    var edits []models.MessageEdit
+    if err := r.db.Where("message_id IN ?", messageIDs).Order("id ASC").Find(&edits).Error; err != nil {
+        return nil, err
+    }
+    for _, edit := range edits { byMessage[edit.MessageID] = append(byMessage[edit.MessageID], edit) }
+    return byMessage, nil
+}
+
+// liveMessageIDs lists the messages that are not deleted; tombstones carry no reactions,
+// attachments or polls.
+func liveMessageIDs(messages []models.MessageWithUser) []uint {
+    ids := make([]uint, 0, len(messages))
+    for _, m := range messages {
+        if m.DeletedAt == nil { ids = append(ids, m.ID) }
+    }
+    return ids
+}
+
+// attachDetails fills in reactions, attachments and polls. Deleted messages keep none.
+func (r *GormMessageRepository) attachDetails(messages []models.MessageWithUser) error {
+    ids := liveMessageIDs(messages)
+    counts, err := r.ReactionCounts(ids)
+    if err != nil { return err }
+    attachments, err := r.Attachments(ids)
+    if err != nil { return err }
+    polls, err := r.Polls(ids)
+    if err != nil { return err }
+    for i := range messages {
+        messages[i].Reactions = counts[messages[i].ID]
+        messages[i].Attachment = attachments[messages[i].ID]
+        messages[i].Poll = polls[messages[i].ID]
+    }
+    return nil
+}
+
+// Attachments returns the attachments of the given messages, keyed by message id.
+func (r *GormMessageRepository) Attachments(messageIDs []uint) (map[uint]*models.Attachment, error) {
+    byMessage := make(map[uint]*models.Attachment)
+    if len(messageIDs) == 0 { return byMessage, nil }
+    var rows []models.Attachment
+    if err := r.db.Where("message_id IN ?", messageIDs).Find(&rows).Error; err != nil { return nil, err }
+    for i := range rows { byMessage[rows[i].MessageID] = &rows[i] }
+    return byMessage, nil
+}
+
+// Polls returns the tallied polls of the given messages, keyed by message id.
+func (r *GormMessageRepository) Polls(messageIDs []uint) (map[uint]*models.PollResults, error) {
+    return pollResults(r.db, messageIDs)
+}
+
+func (r *GormMessageRepository) FindAttachment(id uint) (*models.Attachment, error) {
+    var a models.Attachment
+    if err := r.db.First(&a, id).Error; err != nil { return nil, err }
+    return &a, nil
+}
+
+// messageWithUserColumns are the columns of models.MessageWithUser. Deleted messages are
+// kept as tombstones: their content is never sent back.
+const messageWithUserColumns = "messages.id, CASE WHEN messages.deleted_at IS NULL THEN messages.content ELSE '' END AS content, " +
+    "messages.created_at, messages.edited_at, messages.deleted_at, messages.parent_id, messages.expires_at, messages.kind, " +
+    "users.name AS username, COALESCE(deleters.name, '') AS deleted_by, COALESCE(messages.client_id, '') AS client_id, " +
+    "(SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id AND replies.deleted_at IS NULL) AS reply_count"
+
+// withUserQuery selects messages shaped as models.MessageWithUser. Expired messages are
+// left out even before the cleanup job has purged them.
+func (r *GormMessageRepository) withUserQuery() *gorm.DB {
+    return r.db.
+        Table("messages").
+        Select(messageWithUserColumns).
+        Joins("JOIN users ON messages.user_id = users.id").
+        Joins("LEFT JOIN users AS deleters ON messages.deleted_by = deleters.id").
+        Where("(messages.expires_at IS NULL OR messages.expires_at > ?)", time.Now())
+}
+
+// PurgeExpired permanently deletes up to limit messages whose expiry has passed, along
+// with the replies in their threads and everything attached to them (reactions, pins,
+// edit history, notifications, attachment rows and polls). It returns the messages that were
+// deleted, with their attachments set so the caller can remove the blobs.
+func (r *GormMessageRepository) PurgeExpired(now time.Time, limit int) ([]models.Message, error) {
+    var purged []models.Message
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        var expired []models.Message
+        if err := tx.Select("id", "chatroom_id", "parent_id").
+            Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+            Order("id ASC").Limit(limit).
+            Find(&expired).Error; err != nil {
+            return err
+        }
+        if len(expired) == 0 { return nil }
+
+        ids := make([]uint, 0, len(expired))
+        for _, m := range expired { ids = append(ids, m.ID) }
+        var replies []models.Message
+        if err := tx.Select("id", "chatroom_id", "parent_id").
+            Where("parent_id IN ? AND id NOT IN ?", ids, ids).
+            Find(&replies).Error; err != nil {
+            return err
+        }
+        expired = append(expired, replies...)
+        for _, m := range replies { ids = append(ids, m.ID) }
+
+        var attachments []models.Attachment
+        if err := tx.Where("message_id IN ?", ids).Find(&attachments).Error; err != nil { return err }
+        for i := range attachments {
+            for j := range expired {
+                if expired[j].ID == attachments[i].MessageID { expired[j].Attachment = &attachments[i] }
+            }
+        }
+
+        polls := tx.Model(&models.Poll{}).Select("id").Where("message_id IN ?", ids)
+        for _, model := range []any{&models.PollVote{}, &models.PollOption{}} {
+            if err := tx.Where("poll_id IN (?)", polls).Delete(model).Error; err != nil {
+                return err
+            }
+        }
+        for _, model := range []any{&models.Poll{}, &models.Reaction{}, &models.Pin{}, &models.MessageEdit{}, &models.Notification{}, &models.Attachment{}} {
+            if err := tx.Where("message_id IN ?", ids).Delete(model).Error; err != nil {
+                return err
+            }
+        }
+        if err := tx.Where("id IN ?", ids).Delete(&models.Message{}).Error; err != nil {
+            return err
+        }
+        purged = expired
+        return nil
+    })
+    return purged, err
+}
+
+// escapeLike makes user input safe to embed in a LIKE pattern.
+func escapeLike(s string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func DefaultMessageRepository() MessageRepository { return NewMessageRepository(config.DB) }
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+func TestLiveMessageIDs(t *testing.T) {
+	deleted := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		messages []models.MessageWithUser
+		want     []uint
+	}{
+		{name: "none", messages: nil, want: []uint{}},
+		{name: "all live", messages: []models.MessageWithUser{{ID: 1}, {ID: 2}}, want: []uint{1, 2}},
+		{name: "tombstones get no details", messages: []models.MessageWithUser{{ID: 1, DeletedAt: &deleted}, {ID: 2}, {ID: 3, DeletedAt: &deleted}}, want: []uint{2}},
+	}
+	for _, tt := range tests {
+		if got := liveMessageIDs(tt.messages); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: liveMessageIDs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
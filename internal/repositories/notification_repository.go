@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"github.com/Wal-20/cli-chat-app/internal/config"
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	CreateMany(notifications []models.Notification) error
+}
+
+type GormNotificationRepository struct{ db *gorm.DB }
+
+func NewNotificationRepository(db *gorm.DB) *GormNotificationRepository {
+	return &GormNotificationRepository{db: db}
+}
+
+func (r *GormNotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *GormNotificationRepository) CreateMany(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.Create(&notifications).Error
+}
+
+func DefaultNotificationRepository() NotificationRepository {
+	return NewNotificationRepository(config.DB)
+}
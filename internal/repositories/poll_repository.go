@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/config"
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"gorm.io/gorm"
+)
+
+type PollRepository interface {
+	FindByMessageID(messageID uint) (*models.Poll, error)
+	SetVotes(pollID, userID uint, optionIDs []uint) error
+	Results(messageIDs []uint) (map[uint]*models.PollResults, error)
+	VotedOptions(userID uint, messageIDs []uint) (map[uint][]uint, error)
+	Close(pollID uint, at time.Time) (bool, error)
+	DueToClose(now time.Time, limit int) ([]models.Poll, error)
+}
+
+type GormPollRepository struct{ db *gorm.DB }
+
+func NewPollRepository(db *gorm.DB) *GormPollRepository { return &GormPollRepository{db: db} }
+
+// FindByMessageID loads the poll of a message with its options in order.
+func (r *GormPollRepository) FindByMessageID(messageID uint) (*models.Poll, error) {
+	var poll models.Poll
+	err := r.db.Preload("Options", orderedOptions).Where("message_id = ?", messageID).First(&poll).Error
+	if err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// SetVotes replaces the user's votes in a poll; no options withdraws them.
+func (r *GormPollRepository) SetVotes(pollID, userID uint, optionIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("poll_id = ? AND user_id = ?", pollID, userID).Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		if len(optionIDs) == 0 {
+			return nil
+		}
+		votes := make([]models.PollVote, len(optionIDs))
+		for i, optionID := range optionIDs {
+			votes[i] = models.PollVote{PollID: pollID, OptionID: optionID, UserID: userID}
+		}
+		return tx.Create(&votes).Error
+	})
+}
+
+// Results tallies the polls of the given messages, keyed by message id.
+func (r *GormPollRepository) Results(messageIDs []uint) (map[uint]*models.PollResults, error) {
+	return pollResults(r.db, messageIDs)
+}
+
+// VotedOptions returns the options the user picked in the polls of the given messages,
+// keyed by message id.
+func (r *GormPollRepository) VotedOptions(userID uint, messageIDs []uint) (map[uint][]uint, error) {
+	voted := make(map[uint][]uint)
+	if len(messageIDs) == 0 {
+		return voted, nil
+	}
+	var rows []struct {
+		MessageID uint
+		OptionID  uint
+	}
+	err := r.db.Table("poll_votes").
+		Select("polls.message_id, poll_votes.option_id").
+		Joins("JOIN polls ON polls.id = poll_votes.poll_id").
+		Where("poll_votes.user_id = ? AND polls.message_id IN ?", userID, messageIDs).
+		Order("poll_votes.option_id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		voted[row.MessageID] = append(voted[row.MessageID], row.OptionID)
+	}
+	return voted, nil
+}
+
+// Close marks a poll closed at the given time. It reports false when it already was.
+func (r *GormPollRepository) Close(pollID uint, at time.Time) (bool, error) {
+	res := r.db.Model(&models.Poll{}).Where("id = ? AND closed_at IS NULL", pollID).Update("closed_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// DueToClose returns up to limit open polls whose close time has passed.
+func (r *GormPollRepository) DueToClose(now time.Time, limit int) ([]models.Poll, error) {
+	var polls []models.Poll
+	err := r.db.
+		Where("closed_at IS NULL AND closes_at IS NOT NULL AND closes_at <= ?", now).
+		Order("closes_at ASC").Limit(limit).
+		Find(&polls).Error
+	return polls, err
+}
+
+func orderedOptions(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }
+
+// pollVoteRow is a vote with the voter's name, for tallies.
+type pollVoteRow struct {
+	PollID   uint
+	OptionID uint
+	UserID   uint
+	Username string
+}
+
+// pollResults loads and tallies the polls of the given messages, keyed by message id.
+func pollResults(db *gorm.DB, messageIDs []uint) (map[uint]*models.PollResults, error) {
+	byMessage := make(map[uint]*models.PollResults)
+	if len(messageIDs) == 0 {
+		return byMessage, nil
+	}
+	var polls []models.Poll
+	if err := db.Preload("Options", orderedOptions).Where("message_id IN ?", messageIDs).Find(&polls).Error; err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return byMessage, nil
+	}
+
+	pollIDs := make([]uint, len(polls))
+	for i, poll := range polls {
+		pollIDs[i] = poll.ID
+	}
+	var votes []pollVoteRow
+	err := db.Table("poll_votes").
+		Select("poll_votes.poll_id, poll_votes.option_id, poll_votes.user_id, users.name AS username").
+		Joins("JOIN users ON users.id = poll_votes.user_id").
+		Where("poll_votes.poll_id IN ?", pollIDs).
+		Order("poll_votes.id ASC").
+		Scan(&votes).Error
+	if err != nil {
+		return nil, err
+	}
+	byPoll := make(map[uint][]pollVoteRow)
+	for _, vote := range votes {
+		byPoll[vote.PollID] = append(byPoll[vote.PollID], vote)
+	}
+
+	now := time.Now()
+	for _, poll := range polls {
+		byMessage[poll.MessageID] = tallyPoll(poll, byPoll[poll.ID], now)
+	}
+	return byMessage, nil
+}
+
+// tallyPoll counts the votes of a poll as of now. Voter names are left out of
+// anonymous polls.
+func tallyPoll(poll models.Poll, votes []pollVoteRow, now time.Time) *models.PollResults {
+	results := &models.PollResults{
+		MessageID:   poll.MessageID,
+		MultiChoice: poll.MultiChoice,
+		Anonymous:   poll.Anonymous,
+		ClosesAt:    poll.ClosesAt,
+		Closed:      poll.ClosedAt != nil || (poll.ClosesAt != nil && !now.Before(*poll.ClosesAt)),
+		Options:     make([]models.PollOptionResults, len(poll.Options)),
+	}
+	index := make(map[uint]int, len(poll.Options))
+	for i, option := range poll.Options {
+		results.Options[i] = models.PollOptionResults{ID: option.ID, Text: option.Text}
+		index[option.ID] = i
+	}
+	voters := make(map[uint]bool)
+	for _, vote := range votes {
+		i, ok := index[vote.OptionID]
+		if !ok {
+			continue
+		}
+		results.Options[i].Votes++
+		if !poll.Anonymous {
+			results.Options[i].Voters = append(results.Options[i].Voters, vote.Username)
+		}
+		voters[vote.UserID] = true
+	}
+	results.Voters = len(voters)
+	return results
+}
+
+func DefaultPollRepository() PollRepository { return NewPollRepository(config.DB) }
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/config"
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"gorm.io/gorm"
+)
+
+type ScheduledMessageRepository interface {
+	Create(scheduled *models.ScheduledMessage) error
+	ListPending(userID, chatroomID uint) ([]models.ScheduledMessage, error)
+	CountPending(userID uint) (int64, error)
+	Cancel(id, userID, chatroomID uint) (bool, error)
+	Due(now time.Time, limit int) ([]models.ScheduledMessage, error)
+	MarkSent(id, messageID uint, sentAt time.Time) error
+	Retry(id, attempts uint, retryAt time.Time, reason string) error
+	MarkFailed(id uint, reason string) error
+}
+
+type GormScheduledMessageRepository struct{ db *gorm.DB }
+
+func NewScheduledMessageRepository(db *gorm.DB) *GormScheduledMessageRepository {
+	return &GormScheduledMessageRepository{db: db}
+}
+
+func (r *GormScheduledMessageRepository) Create(scheduled *models.ScheduledMessage) error {
+	return r.db.Create(scheduled).Error
+}
+
+// ListPending returns the user's messages still waiting to be sent in a room, soonest first.
+func (r *GormScheduledMessageRepository) ListPending(userID, chatroomID uint) ([]models.ScheduledMessage, error) {
+	var scheduled []models.ScheduledMessage
+	err := r.db.
+		Where("user_id = ? AND chatroom_id = ? AND status = ?", userID, chatroomID, models.ScheduledPending).
+		Order("send_at ASC, id ASC").
+		Find(&scheduled).Error
+	return scheduled, err
+}
+
+func (r *GormScheduledMessageRepository) CountPending(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ScheduledMessage{}).
+		Where("user_id = ? AND status = ?", userID, models.ScheduledPending).
+		Count(&count).Error
+	return count, err
+}
+
+// Cancel deletes a pending scheduled message owned by the user. It reports false when
+// there was nothing to cancel, including when the message has already been sent.
+func (r *GormScheduledMessageRepository) Cancel(id, userID, chatroomID uint) (bool, error) {
+	result := r.db.
+		Where("id = ? AND user_id = ? AND chatroom_id = ? AND status = ?", id, userID, chatroomID, models.ScheduledPending).
+		Delete(&models.ScheduledMessage{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Due returns pending messages whose send time has passed, oldest first. Messages put
+// back with Retry are left out until their retry time.
+func (r *GormScheduledMessageRepository) Due(now time.Time, limit int) ([]models.ScheduledMessage, error) {
+	var scheduled []models.ScheduledMessage
+	err := r.db.
+		Where("status = ? AND send_at <= ? AND (retry_at IS NULL OR retry_at <= ?)", models.ScheduledPending, now, now).
+		Order("send_at ASC, id ASC").
+		Limit(limit).
+		Find(&scheduled).Error
+	return scheduled, err
+}
+
+func (r *GormScheduledMessageRepository) MarkSent(id, messageID uint, sentAt time.Time) error {
+	return r.db.Model(&models.ScheduledMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": models.ScheduledSent, "message_id": messageID, "sent_at": sentAt}).Error
+}
+
+// Retry leaves a message pending until retryAt, recording how many deliveries failed and why.
+func (r *GormScheduledMessageRepository) Retry(id, attempts uint, retryAt time.Time, reason string) error {
+	return r.db.Model(&models.ScheduledMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"attempts": attempts, "retry_at": retryAt, "error": reason}).Error
+}
+
+func (r *GormScheduledMessageRepository) MarkFailed(id uint, reason string) error {
+	return r.db.Model(&models.ScheduledMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": models.ScheduledFailed, "error": reason}).Error
+}
+
+func DefaultScheduledMessageRepository() ScheduledMessageRepository {
+	return NewScheduledMessageRepository(config.DB)
+}
@@ -10,6 +10,7 @@ type UserRepository interface {
     FindByID(id uint) (*models.User, error)
     FindByName(name string) (*models.User, error)
     Create(user *models.User) error
+    FindOrCreatePlaceholder(name string) (*models.User, error)
     Save(user *models.User) error
     DeleteByID(id uint) error
     GetChatroomsByUserID(userID uint) ([]models.Chatroom, error)
@@ -25,14 +26,23 @@ func (r *GormUserRepository) FindByID(id uint) (*models.User, error) {
     return &u, nil
 }
 
+// FindByName looks up a user who can sign in; placeholders for imported authors are skipped.
 func (r *GormUserRepository) FindByName(name string) (*models.User, error) {
     var u models.User
-    if err := r.db.Where("name = ?", name).First(&u).Error; err != nil { return nil, err }
+    if err := r.db.Where("name = ? AND placeholder = ?", name, false).First(&u).Error; err != nil { return nil, err }
     return &u, nil
 }
 
 func (r *GormUserRepository) Create(user *models.User) error { return r.db.Create(user).Error }
 
+// FindOrCreatePlaceholder returns the placeholder user with the given name, creating it
+// on first use.
+func (r *GormUserRepository) FindOrCreatePlaceholder(name string) (*models.User, error) {
+    u := models.User{Name: name, Placeholder: true}
+    if err := r.db.Where("name = ? AND placeholder = ?", name, true).FirstOrCreate(&u).Error; err != nil { return nil, err }
+    return &u, nil
+}
+
 func (r *GormUserRepository) Save(user *models.User) error { return r.db.Save(user).Error }
 
 func (r *GormUserRepository) DeleteByID(id uint) error { return r.db.Delete(&models.User{}, id).Error }
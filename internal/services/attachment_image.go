@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+const (
+	// thumbnailSize bounds the longer side of an image thumbnail, in pixels.
+	thumbnailSize = 512
+	// maxPreviewRows keeps tall images from taking over the conversation.
+	maxPreviewRows = 24
+	// maxImagePixels skips previews for images too large to decode safely.
+	maxImagePixels = 40_000_000
+)
+
+// imagePreviewColumns are the widths renditions are drawn at; clients pick the widest
+// that fits.
+var imagePreviewColumns = []int{24, 48, 80}
+
+var imageMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+func isImageMimeType(mimeType string) bool {
+	return imageMimeTypes[mimeType]
+}
+
+// imagePreview is what an image upload adds to its attachment.
+type imagePreview struct {
+	width, height int
+	thumbnail     []byte // PNG
+	renditions    []models.ImagePreview
+}
+
+// buildImagePreview decodes an image (the first frame of a GIF) and draws its thumbnail
+// and renditions.
+func buildImagePreview(r io.ReadSeeker) (*imagePreview, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("image is %dx%d pixels", cfg.Width, cfg.Height)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &imagePreview{width: cfg.Width, height: cfg.Height}
+	tw, th := fitWithin(cfg.Width, cfg.Height, thumbnailSize, thumbnailSize)
+	thumbnail := resizeImage(img, tw, th)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumbnail); err != nil {
+		return nil, err
+	}
+	preview.thumbnail = buf.Bytes()
+
+	// Renditions are drawn from the thumbnail, which has more than enough pixels.
+	for _, columns := range imagePreviewColumns {
+		// Each cell shows two pixels stacked, so pixels stay square on a 1:2 cell.
+		w, h := fitWithin(tw, th, columns, maxPreviewRows*2)
+		if n := len(preview.renditions); n > 0 && preview.renditions[n-1].Columns == w {
+			break // capped by height; wider renditions would be identical
+		}
+		preview.renditions = append(preview.renditions, models.ImagePreview{
+			Columns: w,
+			Rows:    (h + 1) / 2,
+			Art:     halfBlockArt(resizeImage(thumbnail, w, h)),
+		})
+	}
+	return preview, nil
+}
+
+// fitWithin scales width x height down (never up) to fit maxW x maxH, keeping the
+// aspect ratio.
+func fitWithin(width, height, maxW, maxH int) (int, int) {
+	w, h := width, height
+	if w > maxW {
+		w, h = maxW, height*maxW/width
+	}
+	if h > maxH {
+		w, h = width*maxH/height, maxH
+	}
+	return max(w, 1), max(h, 1)
+}
+
+// resizeImage scales src to w x h by averaging the source pixels each destination pixel
+// covers, which is slow but smooth when shrinking.
+func resizeImage(src image.Image, w, h int) *image.NRGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					// Premultiplied sums so transparent pixels don't darken their neighbours.
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			c := color.NRGBA{A: uint8(a / n >> 8)}
+			if a > 0 {
+				c.R, c.G, c.B = uint8(r*0xffff/a>>8), uint8(g*0xffff/a>>8), uint8(bl*0xffff/a>>8)
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// halfBlockArt draws img with "▀" cells, the top pixel as the foreground colour and the
+// bottom one as the background, in the 256-colour palette most terminals support.
+// Transparent pixels are left to the terminal's background.
+func halfBlockArt(img *image.NRGBA) string {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	var b strings.Builder
+	for y := 0; y < h; y += 2 {
+		fg, bg := -1, -1 // current SGR state; -1 is the terminal default
+		for x := 0; x < w; x++ {
+			top := xterm256(img.NRGBAAt(x, y))
+			bottom := -1
+			if y+1 < h {
+				bottom = xterm256(img.NRGBAAt(x, y+1))
+			}
+			glyph := "▀"
+			switch {
+			case top < 0 && bottom < 0:
+				glyph = " "
+			case top < 0:
+				glyph, top, bottom = "▄", bottom, -1
+			}
+			if glyph == " " {
+				top = fg // the foreground doesn't show
+			}
+			if top != fg || bottom != bg {
+				b.WriteString(sgr(top, bottom))
+				fg, bg = top, bottom
+			}
+			b.WriteString(glyph)
+		}
+		b.WriteString("\x1b[0m")
+		if y+2 < h {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func sgr(fg, bg int) string {
+	codes := []string{"0"}
+	if fg >= 0 {
+		codes = append(codes, fmt.Sprintf("38;5;%d", fg))
+	}
+	if bg >= 0 {
+		codes = append(codes, fmt.Sprintf("48;5;%d", bg))
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// cubeLevels are the channel values of the xterm 6x6x6 colour cube.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// xterm256 maps a colour to the nearest of xterm colours 16-255, or -1 when it is
+// mostly transparent.
+func xterm256(c color.NRGBA) int {
+	if c.A < 128 {
+		return -1
+	}
+	r, g, b := int(c.R), int(c.G), int(c.B)
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sqDist(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	grayIdx := min(max((r+g+b)/3-8, 0)/10, 23)
+	gray := 8 + 10*grayIdx
+	if sqDist(r, g, b, gray, gray, gray) < cubeDist {
+		return 232 + grayIdx
+	}
+	return cube
+}
+
+func nearestLevel(v int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(v-level) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func sqDist(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Previews are built once at upload and stored with the attachment, so clients can show
+// them inline without downloading the file.
+
+const (
+	previewLines      = 10
+	previewLineLength = 200
+)
+
+// textMimeTypes are non-text/* types that are still worth previewing as text.
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/toml":       true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+}
+
+// detectMimeType trusts the content when sniffing recognises a format and falls back
+// to the file extension for plain text and unknown binary data.
+func detectMimeType(name string, head []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	byExt, _, _ := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(name))))
+	if byExt != "" && (sniffed == "text/plain" || sniffed == "application/octet-stream") {
+		return byExt
+	}
+	return sniffed
+}
+
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || textMimeTypes[mimeType]
+}
+
+func buildPreview(r io.Reader, mimeType string) string {
+	switch {
+	case isTextMimeType(mimeType):
+		return textPreview(r)
+	case mimeType == "application/pdf":
+		data, err := io.ReadAll(io.LimitReader(r, MaxAttachmentSize))
+		if err != nil {
+			return ""
+		}
+		return pdfPreview(data)
+	}
+	return ""
+}
+
+// textPreview returns the first previewLines lines, with long lines cut and control
+// characters (terminal escapes included) removed.
+func textPreview(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var lines []string
+	for len(lines) < previewLines && scanner.Scan() {
+		line := strings.ToValidUTF8(scanner.Text(), "�")
+		line = strings.ReplaceAll(line, "\t", "    ")
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, line)
+		if utf8.RuneCountInString(line) > previewLineLength {
+			line = string([]rune(line)[:previewLineLength]) + "…"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	pdfVersionPattern  = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+	pdfPagePattern     = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfCountPattern    = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfEncryptPattern  = regexp.MustCompile(`/Encrypt\s`)
+	pdfInfoFields      = []string{"Title", "Author", "Subject", "Creator", "Producer", "CreationDate"}
+	pdfLiteralPatterns = map[string]*regexp.Regexp{}
+	pdfHexPatterns     = map[string]*regexp.Regexp{}
+)
+
+func init() {
+	for _, field := range pdfInfoFields {
+		pdfLiteralPatterns[field] = regexp.MustCompile(`/` + field + `\s*\(((?:\\.|[^\\)])*)\)`)
+		pdfHexPatterns[field] = regexp.MustCompile(`/` + field + `\s*<([0-9A-Fa-f\s]*)>`)
+	}
+}
+
+// pdfPreview summarises a PDF's version, page count and document information. It reads
+// the raw file, so metadata inside compressed object streams is not found.
+func pdfPreview(data []byte) string {
+	version := "?"
+	if m := pdfVersionPattern.FindSubmatch(data[:min(len(data), 1024)]); m != nil {
+		version = string(m[1])
+	}
+	summary := "PDF " + version
+	if pages := pdfPageCount(data); pages > 0 {
+		summary += fmt.Sprintf(" · %d pages", pages)
+		if pages == 1 {
+			summary = "PDF " + version + " · 1 page"
+		}
+	}
+	if pdfEncryptPattern.Match(data) {
+		summary += " · encrypted"
+	}
+
+	lines := []string{summary}
+	for _, field := range pdfInfoFields {
+		value := pdfInfoValue(data, field)
+		if value == "" {
+			continue
+		}
+		label := field
+		if field == "CreationDate" {
+			label, value = "Created", pdfDate(value)
+		}
+		lines = append(lines, label+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func pdfPageCount(data []byte) int {
+	if n := len(pdfPagePattern.FindAllIndex(data, -1)); n > 0 {
+		return n
+	}
+	// Pages inside object streams are invisible; the page tree root still has a count.
+	count := 0
+	for _, m := range pdfCountPattern.FindAllSubmatch(data, -1) {
+		for _, group := range m[1:] {
+			if n, err := strconv.Atoi(string(group)); err == nil && n > count {
+				count = n
+			}
+		}
+	}
+	return count
+}
+
+// pdfInfoValue returns the last value of a document information field, since
+// incremental updates append newer versions.
+func pdfInfoValue(data []byte, field string) string {
+	var raw []byte
+	if all := pdfLiteralPatterns[field].FindAllSubmatch(data, -1); len(all) > 0 {
+		raw = unescapePDFString(all[len(all)-1][1])
+	} else if all := pdfHexPatterns[field].FindAllSubmatch(data, -1); len(all) > 0 {
+		digits := bytes.Join(bytes.Fields(all[len(all)-1][1]), nil)
+		if len(digits)%2 == 1 {
+			digits = append(digits, '0')
+		}
+		raw, _ = hex.DecodeString(string(digits))
+	}
+	value := decodePDFText(raw)
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > previewLineLength {
+		value = string([]rune(value)[:previewLineLength]) + "…"
+	}
+	return value
+}
+
+// unescapePDFString resolves the backslash escapes of a PDF literal string.
+func unescapePDFString(s []byte) []byte {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\n':
+			// line continuation
+		default:
+			if c >= '0' && c <= '7' {
+				n, j := 0, i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					n = n*8 + int(s[j]-'0')
+				}
+				out = append(out, byte(n))
+				i = j - 1
+				continue
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// decodePDFText decodes a PDF text string: UTF-16BE with a byte order mark, UTF-8 with
+// one, or PDFDocEncoding, which is close enough to Latin-1 for display.
+func decodePDFText(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		b = b[2:]
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return strings.ToValidUTF8(string(b[3:]), "�")
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// pdfDate turns "D:20240102150405+01'00'" into "2024-01-02 15:04"; anything it does
+// not recognise is returned unchanged.
+func pdfDate(s string) string {
+	digits := strings.TrimPrefix(s, "D:")
+	if len(digits) < 8 {
+		return s
+	}
+	for _, c := range digits[:8] {
+		if c < '0' || c > '9' {
+			return s
+		}
+	}
+	date := digits[:4] + "-" + digits[4:6] + "-" + digits[6:8]
+	if len(digits) >= 12 {
+		date += " " + digits[8:10] + ":" + digits[10:12]
+	}
+	return date
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/storage"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	ErrEmptyAttachment    = errors.New("attachment is empty")
+	ErrInvalidFileName    = errors.New("invalid file name")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// MaxAttachmentSize bounds a single upload.
+const MaxAttachmentSize = 25 << 20
+
+// maxFileNameLength matches the width of the attachments.name column.
+const maxFileNameLength = 255
+
+type AttachmentService struct {
+	blobs    storage.BlobStore
+	messages *MessageService
+	repo     repositories.MessageRepository
+}
+
+func NewAttachmentService(blobs storage.BlobStore, messages *MessageService, repo repositories.MessageRepository) *AttachmentService {
+	return &AttachmentService{blobs: blobs, messages: messages, repo: repo}
+}
+
+// AttachmentUpload is a file posted to a room. Caption becomes the message content and
+// ClientID makes the upload idempotent like SendMessage.
+type AttachmentUpload struct {
+	Name     string
+	Body     io.Reader
+	Caption  string
+	ClientID string
+}
+
+// Upload stores the file in the blob store and posts a message carrying it. The file is
+// spooled to disk first to measure, hash and preview it before anything is stored.
+func (s *AttachmentService) Upload(ctx context.Context, senderID, chatroomID uint, upload AttachmentUpload) (models.Message, string, error) {
+	name := cleanFileName(upload.Name)
+	if name == "" {
+		return models.Message{}, "", ErrInvalidFileName
+	}
+	// SendMessage checks these too; checking first saves storing a file nobody will see.
+	if err := checkCanPost(s.messages.chatrooms, senderID, chatroomID); err != nil {
+		return models.Message{}, "", err
+	}
+	if err := s.messages.checkSlowMode(senderID, chatroomID, ""); err != nil {
+		return models.Message{}, "", err
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(upload.Body, MaxAttachmentSize+1))
+	switch {
+	case err != nil:
+		return models.Message{}, "", err
+	case size > MaxAttachmentSize:
+		return models.Message{}, "", ErrAttachmentTooLarge
+	case size == 0:
+		return models.Message{}, "", ErrEmptyAttachment
+	}
+
+	head := make([]byte, 512)
+	n, _ := tmp.ReadAt(head, 0)
+	mimeType := detectMimeType(name, head[:n])
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return models.Message{}, "", err
+	}
+	preview := buildPreview(tmp, mimeType)
+
+	attachment := &models.Attachment{
+		ChatroomID: chatroomID,
+		Name:       name,
+		Size:       size,
+		MimeType:   mimeType,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		StorageKey: fmt.Sprintf("chatrooms/%d/%s", chatroomID, randomKey()),
+		Preview:    preview,
+	}
+	if isImageMimeType(mimeType) {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return models.Message{}, "", err
+		}
+		// An image that can't be decoded is still shared, just without a preview.
+		if image, err := buildImagePreview(tmp); err == nil {
+			thumbnailKey := attachment.StorageKey + ".thumb.png"
+			if err := s.blobs.Put(ctx, thumbnailKey, bytes.NewReader(image.thumbnail), int64(len(image.thumbnail)), "image/png"); err != nil {
+				return models.Message{}, "", err
+			}
+			attachment.Width, attachment.Height = image.width, image.height
+			attachment.ThumbnailKey = thumbnailKey
+			attachment.ImagePreviews = image.renditions
+		} else {
+			log.Printf("No preview for image %q: %v", name, err)
+		}
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err == nil {
+		err = s.blobs.Put(ctx, attachment.StorageKey, tmp, size, mimeType)
+	}
+	if err != nil {
+		s.deleteBlobs(attachment)
+		return models.Message{}, "", err
+	}
+
+	msg, sender, err := s.messages.SendMessage(senderID, chatroomID, upload.Caption, SendOptions{ClientID: upload.ClientID, Attachment: attachment})
+	if err != nil || msg.Attachment != attachment {
+		// Either nothing was stored or a retry matched an earlier upload; this copy is unused.
+		s.deleteBlobs(attachment)
+	}
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	if msg.Attachment != attachment {
+		existing, err := s.repo.Attachments([]uint{msg.ID})
+		if err != nil {
+			return models.Message{}, "", err
+		}
+		msg.Attachment = existing[msg.ID]
+	}
+	return msg, sender, nil
+}
+
+// Open returns an attachment of the room and its contents. Attachments of deleted or
+// expired messages are gone as far as readers are concerned.
+func (s *AttachmentService) Open(ctx context.Context, chatroomID, attachmentID uint) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.find(chatroomID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.openBlob(ctx, attachment, attachment.StorageKey)
+}
+
+// OpenThumbnail returns the PNG thumbnail of an image attachment.
+func (s *AttachmentService) OpenThumbnail(ctx context.Context, chatroomID, attachmentID uint) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.find(chatroomID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment.ThumbnailKey == "" {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	return s.openBlob(ctx, attachment, attachment.ThumbnailKey)
+}
+
+func (s *AttachmentService) find(chatroomID, attachmentID uint) (*models.Attachment, error) {
+	attachment, err := s.repo.FindAttachment(attachmentID)
+	if err != nil || attachment.ChatroomID != chatroomID {
+		return nil, ErrAttachmentNotFound
+	}
+	msg, err := s.repo.FindByID(attachment.MessageID)
+	if err != nil || msg.DeletedAt != nil || (msg.ExpiresAt != nil && !msg.ExpiresAt.After(time.Now())) {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+func (s *AttachmentService) openBlob(ctx context.Context, attachment *models.Attachment, key string) (*models.Attachment, io.ReadCloser, error) {
+	body, err := s.blobs.Get(ctx, key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, body, nil
+}
+
+// DeleteBlobs removes the stored files of messages that no longer exist, such as those
+// returned by MessageService.PurgeExpired.
+func (s *AttachmentService) DeleteBlobs(messages []models.Message) {
+	for _, msg := range messages {
+		if msg.Attachment != nil {
+			s.deleteBlobs(msg.Attachment)
+		}
+	}
+}
+
+func (s *AttachmentService) deleteBlobs(attachment *models.Attachment) {
+	s.deleteBlob(attachment.StorageKey)
+	if attachment.ThumbnailKey != "" {
+		s.deleteBlob(attachment.ThumbnailKey)
+	}
+}
+
+func (s *AttachmentService) deleteBlob(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", key, err)
+	}
+}
+
+// cleanFileName keeps the base name of an uploaded file, without control characters.
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(name, ""))
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	for utf8.RuneCountInString(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func randomKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCleanFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "report.pdf", want: "report.pdf"},
+		{name: "unix path", in: "/home/alice/photo.jpg", want: "photo.jpg"},
+		{name: "windows path", in: `C:\Users\bob\notes.txt`, want: "notes.txt"},
+		{name: "traversal", in: "../../etc/passwd", want: "passwd"},
+		{name: "control characters", in: "bad\x00na\nme\x7f.txt", want: "badname.txt"},
+		{name: "invalid utf-8", in: "caf\xff\xfe.txt", want: "caf.txt"},
+		{name: "surrounding spaces", in: "  spaced.txt  ", want: "spaced.txt"},
+		{name: "dot", in: ".", want: ""},
+		{name: "dot dot", in: "..", want: ""},
+		{name: "empty", in: "", want: ""},
+		{name: "only a directory", in: "dir/", want: "dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanFileName(tt.in); got != tt.want {
+				t.Errorf("cleanFileName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanFileNameTruncatesByRune(t *testing.T) {
+	got := cleanFileName(strings.Repeat("é", maxFileNameLength+10))
+	if n := utf8.RuneCountInString(got); n != maxFileNameLength || !utf8.ValidString(got) {
+		t.Errorf("got %d runes (valid utf-8: %v), want %d", n, utf8.ValidString(got), maxFileNameLength)
+	}
+}
@@ -43,6 +43,9 @@ func (s *AuthService) Login(username, password string) (access, refresh string,
 }
 
 func (s *AuthService) Register(username, password string) (access, refresh string, user models.User, err error) {
+	if IsPlaceholderName(username) {
+		return "", "", models.User{}, ErrReservedName
+	}
 	if err := utils.ValidatePassword(password); err != nil {
 		{
 			return "", "", models.User{}, err
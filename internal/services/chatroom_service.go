@@ -1,15 +1,47 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/models"
 	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/utils"
 
 	"gorm.io/gorm"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// MaxTopicLength matches the width of the chatrooms.topic column.
+const MaxTopicLength = 255
+
+// Limits on a room's settings. MaxTitleLength matches the width of chatrooms.title.
+const (
+	MaxTitleLength  = 100
+	MaxRoomCapacity = 1000
+	MaxSlowMode     = 6 * time.Hour
+)
+
+var (
+	ErrInvalidTopic    = errors.New("invalid topic")
+	ErrInvalidTitle    = errors.New("invalid title")
+	ErrInvalidCapacity = errors.New("invalid capacity")
+	ErrInvalidSlowMode = errors.New("invalid slow mode")
+	ErrDirectSettings  = errors.New("direct conversations have no settings")
+)
+
+// RoomSettings holds the settings of a room to change; nil fields are left alone.
+type RoomSettings struct {
+	Title        *string `json:"title"`
+	Topic        *string `json:"topic"`
+	IsPublic     *bool   `json:"is_public"`
+	MaxUserCount *uint   `json:"maxUserCount"`
+	SlowMode     *uint   `json:"slow_mode"`   // seconds; 0 turns it off
+	MessageTTL   *uint   `json:"message_ttl"` // seconds; 0 keeps messages
+}
+
 type ChatroomService struct {
 	repo repositories.ChatroomRepository
 }
@@ -22,6 +54,127 @@ func (s *ChatroomService) GetPublicChatrooms(userID uint) ([]models.Chatroom, er
 	return s.repo.GetPublicChatroomsNotJoined(userID)
 }
 
+// IsActiveMember reports whether the user has joined the room and is not banned from it.
+func (s *ChatroomService) IsActiveMember(userID, chatroomID uint) bool {
+	uc, err := s.repo.FindUserChatroom(userID, chatroomID)
+	return err == nil && uc.IsJoined && !uc.IsBanned
+}
+
+// MarkRead records that the user has seen everything up to messageID in the room.
+func (s *ChatroomService) MarkRead(userID, chatroomID, messageID uint) error {
+	return s.repo.MarkRead(userID, chatroomID, messageID)
+}
+
+// SetMessageTTL sets how long new messages in the room live for; zero keeps them.
+// Messages already sent keep the expiry they were created with.
+func (s *ChatroomService) SetMessageTTL(chatroomID uint, ttl time.Duration) (*models.Chatroom, error) {
+	if ttl < 0 || ttl > MaxMessageTTL || ttl%time.Second != 0 {
+		return nil, ErrInvalidTTL
+	}
+	room, err := s.repo.FindByID(chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	room.MessageTTL = uint(ttl / time.Second)
+	if err := s.repo.SaveChatroom(room); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// SetTopic changes the room's topic and tells everyone connected to it. An empty
+// topic clears it.
+func (s *ChatroomService) SetTopic(chatroomID uint, topic string) (*models.Chatroom, error) {
+	topic, ok := cleanLine(topic, MaxTopicLength)
+	if !ok {
+		return nil, ErrInvalidTopic
+	}
+	room, err := s.repo.FindByID(chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	room.Topic = topic
+	if err := s.repo.SaveChatroom(room); err != nil {
+		return nil, err
+	}
+	broadcast(room.Id, "topic_updated", models.TopicUpdatedPayload{ChatroomID: room.Id, Topic: topic})
+	return room, nil
+}
+
+// UpdateSettings changes any of the room's title, topic, visibility, capacity, slow
+// mode and default message ttl, and tells everyone connected to it with "room_updated".
+// Every given setting is checked before any is applied; the capacity cannot drop below
+// the members already in the room.
+func (s *ChatroomService) UpdateSettings(chatroomID uint, in RoomSettings) (*models.Chatroom, error) {
+	room, err := s.repo.FindByID(chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.IsDirect {
+		return nil, ErrDirectSettings
+	}
+	next := *room
+	if in.Title != nil {
+		title, ok := cleanLine(*in.Title, MaxTitleLength)
+		if !ok || title == "" {
+			return nil, ErrInvalidTitle
+		}
+		next.Title = title
+	}
+	if in.Topic != nil {
+		topic, ok := cleanLine(*in.Topic, MaxTopicLength)
+		if !ok {
+			return nil, ErrInvalidTopic
+		}
+		next.Topic = topic
+	}
+	if in.IsPublic != nil {
+		next.IsPublic = *in.IsPublic
+	}
+	if in.MaxUserCount != nil {
+		joined, err := s.repo.CountJoinedUsers(chatroomID)
+		if err != nil {
+			return nil, err
+		}
+		if *in.MaxUserCount < 2 || *in.MaxUserCount > MaxRoomCapacity || int64(*in.MaxUserCount) < joined {
+			return nil, ErrInvalidCapacity
+		}
+		next.MaxUserCount = *in.MaxUserCount
+	}
+	if in.SlowMode != nil {
+		if time.Duration(*in.SlowMode)*time.Second > MaxSlowMode {
+			return nil, ErrInvalidSlowMode
+		}
+		next.SlowMode = *in.SlowMode
+	}
+	if in.MessageTTL != nil {
+		if time.Duration(*in.MessageTTL)*time.Second > MaxMessageTTL {
+			return nil, ErrInvalidTTL
+		}
+		next.MessageTTL = *in.MessageTTL
+	}
+
+	if err := s.repo.SaveChatroom(&next); err != nil {
+		return nil, err
+	}
+	if next.Topic != room.Topic {
+		broadcast(next.Id, "topic_updated", models.TopicUpdatedPayload{ChatroomID: next.Id, Topic: next.Topic})
+	}
+	broadcast(next.Id, "room_updated", models.RoomUpdatedPayload{Chatroom: next})
+	return &next, nil
+}
+
+// cleanLine trims a one-line setting and reports whether it fits in limit characters.
+func cleanLine(value string, limit int) (string, bool) {
+	value = strings.TrimSpace(value)
+	return value, !strings.ContainsAny(value, "\r\n") && utf8.RuneCountInString(value) <= limit
+}
+
+// UnreadCounts returns unread and mention counts for every room the user has joined.
+func (s *ChatroomService) UnreadCounts(userID uint) ([]models.UnreadCount, error) {
+	return s.repo.UnreadCounts(userID)
+}
+
 func (s *ChatroomService) JoinChatroom(userID uint, username string, chatroomID string) (*models.Chatroom, error) {
 	chatroom, err := s.repo.FindByID(chatroomID)
 	if err != nil {
@@ -56,8 +209,15 @@ func (s *ChatroomService) LeaveChatroom(userID uint, chatroomID string, wasOwner
 	if wasOwner || uc.IsOwner {
 		uc.IsOwner = false
 		uc.IsAdmin = false
-	}
-	if err := s.repo.SaveUserChatroom(uc); err != nil {
+		successor, err := s.repo.HandOverOwnership(uc)
+		if err != nil {
+			return nil, err
+		}
+		if successor != nil {
+			utils.ForgetMembership(successor.UserID, successor.ChatroomID)
+			broadcast(successor.ChatroomID, "member_updated", models.MemberUpdatedPayload{Action: "owner_changed", Member: *successor})
+		}
+	} else if err := s.repo.SaveUserChatroom(uc); err != nil {
 		return nil, err
 	}
 
@@ -79,7 +239,8 @@ func RemoveOldUserChatrooms() (int64, error) {
 	threshold := time.Now().AddDate(0, 0, -60) // older than 2 months
 
 	result := config.DB.
-		Where("created_at < ? AND (is_banned = true OR is_joined = false)", threshold).
+		// Bans are kept until they are lifted; deleting one would let the user back in.
+		Where("created_at < ? AND is_joined = false AND is_banned = false", threshold).
 		Delete(&models.UserChatroom{})
 
 	if result.Error != nil {
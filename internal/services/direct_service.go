@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDirectWithSelf = errors.New("cannot start a conversation with yourself")
+	ErrUserNotFound   = errors.New("user not found")
+)
+
+// DirectMessageService manages two-person conversations. They are ordinary chatrooms
+// flagged IsDirect, with exactly the two users as members and no admins.
+type DirectMessageService struct {
+	chatrooms     repositories.ChatroomRepository
+	users         repositories.UserRepository
+	notifications repositories.NotificationRepository
+}
+
+func NewDirectMessageService(c repositories.ChatroomRepository, u repositories.UserRepository, n repositories.NotificationRepository) *DirectMessageService {
+	return &DirectMessageService{chatrooms: c, users: u, notifications: n}
+}
+
+// directKey identifies the conversation between two users regardless of who opens it.
+func directKey(a, b uint) string {
+	return fmt.Sprintf("%d:%d", min(a, b), max(a, b))
+}
+
+// Open returns the conversation between the user and peer (a user id or name), creating
+// it on first use. Either side who had left is brought back in, and the peer is notified
+// whenever they were not in the conversation already. created reports a new room.
+func (s *DirectMessageService) Open(userID uint, peer string) (conv models.DirectConversation, created bool, err error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return conv, false, err
+	}
+	target, err := s.findUser(peer)
+	if err != nil {
+		return conv, false, err
+	}
+	if target.ID == userID {
+		return conv, false, ErrDirectWithSelf
+	}
+
+	key := directKey(userID, target.ID)
+	room, err := s.chatrooms.FindDirect(key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		room, err = s.create(user, target, key)
+		created = err == nil
+	}
+	if err != nil {
+		return conv, false, err
+	}
+
+	peerRejoined := created
+	if !created {
+		if _, err := s.ensureJoined(user, room.Id); err != nil {
+			return conv, false, err
+		}
+		if peerRejoined, err = s.ensureJoined(target, room.Id); err != nil {
+			return conv, false, err
+		}
+	}
+	if peerRejoined {
+		n := newNotification(target.ID, room.Id, userID, "dm", fmt.Sprintf("%s sent you a direct message", user.Name))
+		if err := s.notifications.Create(&n); err != nil {
+			log.Printf("direct message notification failed: %v", err)
+		}
+	}
+	return models.DirectConversation{Chatroom: *room, PeerID: target.ID, PeerName: target.Name}, created, nil
+}
+
+// List returns the user's conversations, most recently active first.
+func (s *DirectMessageService) List(userID uint) ([]models.DirectConversation, error) {
+	return s.chatrooms.ListDirect(userID)
+}
+
+func (s *DirectMessageService) findUser(ident string) (*models.User, error) {
+	var user *models.User
+	var err error
+	if id, perr := strconv.ParseUint(ident, 10, 32); perr == nil {
+		user, err = s.users.FindByID(uint(id))
+	} else {
+		user, err = s.users.FindByName(ident)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (s *DirectMessageService) create(user, peer *models.User, key string) (*models.Chatroom, error) {
+	now := time.Now()
+	room := &models.Chatroom{
+		OwnerId:      user.ID,
+		Title:        user.Name + " & " + peer.Name,
+		MaxUserCount: 2,
+		IsDirect:     true,
+		DirectKey:    &key,
+	}
+	members := []models.UserChatroom{
+		{UserID: user.ID, Name: user.Name, IsJoined: true, LastJoinTime: &now},
+		{UserID: peer.ID, Name: peer.Name, IsJoined: true, LastJoinTime: &now},
+	}
+	if err := s.chatrooms.CreateDirect(room, members); err != nil {
+		// Both users may have opened the conversation at once; the other request won.
+		if existing, ferr := s.chatrooms.FindDirect(key); ferr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+// ensureJoined puts the user back into the conversation, recreating a membership the
+// cleanup job removed. It reports whether anything changed.
+func (s *DirectMessageService) ensureJoined(user *models.User, chatroomID uint) (bool, error) {
+	now := time.Now()
+	uc, err := s.chatrooms.FindUserChatroom(user.ID, chatroomID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, s.chatrooms.CreateUserChatroom(&models.UserChatroom{UserID: user.ID, Name: user.Name, ChatroomID: chatroomID, IsJoined: true, LastJoinTime: &now})
+	}
+	if err != nil || uc.IsJoined {
+		return false, err
+	}
+	uc.IsJoined = true
+	uc.IsInvited = false
+	uc.LastJoinTime = &now
+	return true, s.chatrooms.SaveUserChatroom(uc)
+}
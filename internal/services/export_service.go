@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+)
+
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// exportBatchSize is how many messages are loaded at a time while exporting, which
+// keeps memory flat however long the history is.
+const exportBatchSize = 500
+
+// ExportFormat is a transcript format.
+type ExportFormat string
+
+const (
+	ExportJSONLines ExportFormat = "jsonl"
+	ExportMarkdown  ExportFormat = "md"
+	ExportHTML      ExportFormat = "html"
+)
+
+// ParseExportFormat accepts a format name or its usual file extension.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "jsonl", "json", "ndjson":
+		return ExportJSONLines, nil
+	case "md", "markdown":
+		return ExportMarkdown, nil
+	case "html", "htm":
+		return ExportHTML, nil
+	}
+	return "", ErrInvalidExportFormat
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportMarkdown:
+		return "text/markdown; charset=utf-8"
+	case ExportHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// ExportOptions select what a transcript covers. AttachmentURL turns an attachment into
+// the link the transcript shows for it.
+type ExportOptions struct {
+	Format        ExportFormat
+	From          *time.Time // inclusive
+	To            *time.Time // exclusive
+	AttachmentURL func(attachment *models.Attachment) string
+}
+
+type ExportService struct {
+	messages  repositories.MessageRepository
+	chatrooms repositories.ChatroomRepository
+}
+
+func NewExportService(m repositories.MessageRepository, c repositories.ChatroomRepository) *ExportService {
+	return &ExportService{messages: m, chatrooms: c}
+}
+
+// Room loads the chatroom to export, so callers can name the transcript before it starts.
+func (s *ExportService) Room(chatroomID uint) (*models.Chatroom, error) {
+	return s.chatrooms.FindByID(chatroomID)
+}
+
+// Export writes the room's complete history, thread replies included, to w in the
+// requested format. Messages are read in batches and written as they arrive, so the
+// transcript can be streamed straight to the client.
+func (s *ExportService) Export(ctx context.Context, w io.Writer, room *models.Chatroom, opts ExportOptions) error {
+	chatroomID := room.Id
+	tw, err := newTranscriptWriter(opts.Format, w)
+	if err != nil {
+		return err
+	}
+	if err := tw.begin(room, opts); err != nil {
+		return err
+	}
+
+	filter := repositories.ExportFilter{From: opts.From, To: opts.To}
+	var after uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := s.messages.ExportBatch(chatroomID, filter, after, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		// Deleted messages keep their tombstone only, not the versions before it.
+		var live []uint
+		for _, message := range batch {
+			if message.DeletedAt == nil {
+				live = append(live, message.ID)
+			}
+		}
+		edits, err := s.messages.EditHistory(live)
+		if err != nil {
+			return err
+		}
+		for _, message := range batch {
+			exported := models.ExportedMessage{MessageWithUser: message, ChatroomID: chatroomID, Edits: edits[message.ID]}
+			if message.Attachment != nil && opts.AttachmentURL != nil {
+				exported.AttachmentURL = opts.AttachmentURL(message.Attachment)
+			}
+			if err := tw.message(exported); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		after = batch[len(batch)-1].ID
+	}
+	return tw.end()
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxIRCLineLength bounds a log line; IRC itself allows far less.
+const maxIRCLineLength = 64 << 10
+
+var (
+	// irssi: "--- Log opened Tue Oct 01 09:00:00 2024" and "--- Day changed Wed Oct 02 2024"
+	irssiLogOpened = regexp.MustCompile(`^--- Log opened \w{3} (\w{3} \d{2} \d{2}:\d{2}:\d{2} \d{4})$`)
+	irssiDayChange = regexp.MustCompile(`^--- Day changed \w{3} (\w{3} \d{2} \d{4})$`)
+	// irssi: "09:15 <@alice> hi" and "09:16  * bob waves"
+	irssiMessage = regexp.MustCompile(`^(\d{2}:\d{2}(?::\d{2})?) <[ @+%&~]?([^>]+)> ?(.*)$`)
+	irssiAction  = regexp.MustCompile(`^(\d{2}:\d{2}(?::\d{2})?)\s+\* (\S+) ?(.*)$`)
+	// weechat: "2024-10-01 09:15:00<TAB>@alice<TAB>hi"
+	weechatLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\t([^\t]*)\t(.*)$`)
+)
+
+// weechatEvents are the prefixes WeeChat logs joins, parts, mode changes and other
+// server notices under.
+var weechatEvents = map[string]bool{"-->": true, "<--": true, "--": true, "=!=": true, "": true}
+
+// ReadIRCLog reads the messages and actions of an irssi or WeeChat channel log, whose
+// times are in loc. Joins, quits and other events are left out.
+func ReadIRCLog(r io.Reader, source ImportSource, loc *time.Location) ([]ImportedMessage, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxIRCLineLength)
+
+	var history []ImportedMessage
+	// Identical lines in the same second are told apart by how many came before.
+	seen := make(map[string]int)
+	add := func(sentAt time.Time, nick, text string, action bool) {
+		text = strings.TrimRight(text, " ")
+		if nick == "" || text == "" {
+			return
+		}
+		key := fmt.Sprintf("irc:%s\x00%s\x00%s", sentAt.UTC().Format(time.RFC3339), nick, text)
+		seen[key]++
+		history = append(history, ImportedMessage{
+			Key:     fmt.Sprintf("%s\x00%d", key, seen[key]),
+			Author:  nick,
+			SentAt:  sentAt,
+			Content: text,
+			Action:  action,
+		})
+	}
+
+	var day time.Time // irssi logs give the date once, then times of day
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch source {
+		case ImportWeechat:
+			match := weechatLine.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			sentAt, err := time.ParseInLocation(time.DateTime, match[1], loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, n, err)
+			}
+			prefix := strings.TrimSpace(match[2])
+			switch {
+			case weechatEvents[prefix]:
+			case prefix == "*":
+				nick, text, _ := strings.Cut(match[3], " ")
+				add(sentAt, nick, text, true)
+			default:
+				add(sentAt, strings.TrimLeft(prefix, "@+%&~"), match[3], false)
+			}
+
+		case ImportIrssi:
+			if match := irssiLogOpened.FindStringSubmatch(line); match != nil {
+				opened, err := time.ParseInLocation("Jan 02 15:04:05 2006", match[1], loc)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, n, err)
+				}
+				day = time.Date(opened.Year(), opened.Month(), opened.Day(), 0, 0, 0, 0, loc)
+				continue
+			}
+			if match := irssiDayChange.FindStringSubmatch(line); match != nil {
+				changed, err := time.ParseInLocation("Jan 02 2006", match[1], loc)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, n, err)
+				}
+				day = changed
+				continue
+			}
+			match, action := irssiMessage.FindStringSubmatch(line), false
+			if match == nil {
+				match, action = irssiAction.FindStringSubmatch(line), true
+			}
+			if match == nil {
+				continue
+			}
+			if day.IsZero() {
+				return nil, fmt.Errorf("%w: line %d: message before the log gives a date", ErrInvalidImport, n)
+			}
+			sentAt, err := irssiTime(day, match[1], loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, n, err)
+			}
+			add(sentAt, match[2], match[3], action)
+
+		default:
+			return nil, ErrInvalidImportSource
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return history, nil
+}
+
+// irssiTime places an irssi time of day, "15:04" or "15:04:05", on the given day.
+func irssiTime(day time.Time, clock string, loc *time.Location) (time.Time, error) {
+	layout := "15:04"
+	if len(clock) > len(layout) {
+		layout = time.TimeOnly
+	}
+	t, err := time.Parse(layout, clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadIRCLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  ImportSource
+		log     string
+		want    []ImportedMessage
+		wantErr error
+	}{
+		{
+			name:   "irssi messages and actions",
+			source: ImportIrssi,
+			log: "--- Log opened Tue Oct 01 09:00:00 2024\n" +
+				"09:15 <@alice> hi all\r\n" +
+				"09:16  * bob waves\n" +
+				"09:17 -!- carol [~c@host] has joined #ops\n" +
+				"09:18:30 <+carol> seconds too\n",
+			want: []ImportedMessage{
+				{Author: "alice", SentAt: time.Date(2024, 10, 1, 9, 15, 0, 0, time.UTC), Content: "hi all"},
+				{Author: "bob", SentAt: time.Date(2024, 10, 1, 9, 16, 0, 0, time.UTC), Content: "waves", Action: true},
+				{Author: "carol", SentAt: time.Date(2024, 10, 1, 9, 18, 30, 0, time.UTC), Content: "seconds too"},
+			},
+		},
+		{
+			name:   "irssi day change",
+			source: ImportIrssi,
+			log: "--- Log opened Tue Oct 01 23:50:00 2024\n" +
+				"23:59 <alice> late\n" +
+				"--- Day changed Wed Oct 02 2024\n" +
+				"00:01 <alice> early\n",
+			want: []ImportedMessage{
+				{Author: "alice", SentAt: time.Date(2024, 10, 1, 23, 59, 0, 0, time.UTC), Content: "late"},
+				{Author: "alice", SentAt: time.Date(2024, 10, 2, 0, 1, 0, 0, time.UTC), Content: "early"},
+			},
+		},
+		{
+			name:    "irssi message before a date",
+			source:  ImportIrssi,
+			log:     "09:15 <alice> hi\n",
+			wantErr: ErrInvalidImport,
+		},
+		{
+			name:   "weechat messages, actions and events",
+			source: ImportWeechat,
+			log: "2024-10-01 09:15:00\t@alice\thi all\n" +
+				"2024-10-01 09:15:30\t-->\tbob (~b@host) has joined #ops\n" +
+				"2024-10-01 09:16:00\t *\tbob waves\n" +
+				"2024-10-01 09:17:00\t--\tMode #ops [+o bob]\n" +
+				"2024-10-01 09:18:00\tcarol\t  \n",
+			want: []ImportedMessage{
+				{Author: "alice", SentAt: time.Date(2024, 10, 1, 9, 15, 0, 0, time.UTC), Content: "hi all"},
+				{Author: "bob", SentAt: time.Date(2024, 10, 1, 9, 16, 0, 0, time.UTC), Content: "waves", Action: true},
+			},
+		},
+		{
+			name:    "unknown source",
+			source:  ImportSlack,
+			log:     "anything\n",
+			wantErr: ErrInvalidImportSource,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := ReadIRCLog(strings.NewReader(tt.log), tt.source, time.UTC)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range history {
+				history[i].Key = "" // keys are covered by TestReadIRCLogKeys
+			}
+			if !reflect.DeepEqual(history, tt.want) {
+				t.Errorf("got %+v, want %+v", history, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadIRCLogKeys(t *testing.T) {
+	log := "2024-10-01 09:15:00\talice\tok\n" +
+		"2024-10-01 09:15:00\talice\tok\n" +
+		"2024-10-01 09:15:01\talice\tok\n"
+	history, err := ReadIRCLog(strings.NewReader(log), ImportWeechat, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, m := range history {
+		if seen[m.Key] {
+			t.Errorf("key %q is used twice", m.Key)
+		}
+		seen[m.Key] = true
+	}
+	again, _ := ReadIRCLog(strings.NewReader(log), ImportWeechat, time.UTC)
+	for i := range again {
+		if again[i].Key != history[i].Key {
+			t.Errorf("re-reading the log changed key %d: %q, then %q", i, history[i].Key, again[i].Key)
+		}
+	}
+}
+
+func TestIrssiTime(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	day := time.Date(2024, 10, 1, 0, 0, 0, 0, loc)
+	tests := []struct {
+		clock   string
+		want    string
+		wantErr bool
+	}{
+		{clock: "09:15", want: "2024-10-01T09:15:00+02:00"},
+		{clock: "23:59:59", want: "2024-10-01T23:59:59+02:00"},
+		{clock: "25:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := irssiTime(day, tt.clock, loc)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("irssiTime(%q) = %v, want an error", tt.clock, got)
+			}
+			continue
+		}
+		if err != nil || got.Format(time.RFC3339) != tt.want {
+			t.Errorf("irssiTime(%q) = %v, %v; want %s", tt.clock, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidImportSource = errors.New("invalid import source")
+	ErrInvalidImport       = errors.New("history could not be read")
+	ErrReservedName        = errors.New("names ending in @irc or @slack are reserved for imported authors")
+)
+
+// ImportSource is a chat system whose history can be imported.
+type ImportSource string
+
+const (
+	ImportSlack   ImportSource = "slack"   // a Slack export ZIP
+	ImportIrssi   ImportSource = "irssi"   // an irssi log file
+	ImportWeechat ImportSource = "weechat" // a WeeChat log file
+)
+
+func ParseImportSource(name string) (ImportSource, error) {
+	switch source := ImportSource(strings.ToLower(name)); source {
+	case ImportSlack, ImportIrssi, ImportWeechat:
+		return source, nil
+	}
+	return "", ErrInvalidImportSource
+}
+
+// network is what placeholder users are named after: "alice@irc" for a nick from
+// either IRC client, since the nick is the same person whichever logged it.
+func (s ImportSource) network() string {
+	if s == ImportSlack {
+		return "slack"
+	}
+	return "irc"
+}
+
+// IsPlaceholderName reports whether name has the form of a placeholder user's, which
+// real users may not take.
+func IsPlaceholderName(name string) bool {
+	for _, source := range []ImportSource{ImportSlack, ImportIrssi} {
+		if strings.HasSuffix(strings.ToLower(name), "@"+source.network()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportedMessage is a message read from another system's history, before it is stored.
+type ImportedMessage struct {
+	Key       string // identifies the message within its source, so re-runs can skip it
+	Author    string
+	SentAt    time.Time
+	EditedAt  *time.Time
+	Content   string
+	Action    bool   // an IRC /me or a Slack me_message
+	ThreadKey string // Key of the thread root this replies to, if any
+}
+
+// ImportOptions tune how a history is stored. Users maps author names in the source to
+// existing usernames; other authors are matched to the user with their exact name, or
+// else to a placeholder.
+type ImportOptions struct {
+	Users map[string]string
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported     int
+	Skipped      int      // stored by an earlier run
+	Placeholders []string // placeholder users the messages were attributed to
+}
+
+// importClientPrefix marks the ClientID of imported messages, which is what makes
+// running an import again harmless.
+const importClientPrefix = "import-"
+
+type ImportService struct {
+	messages  repositories.MessageRepository
+	users     repositories.UserRepository
+	chatrooms repositories.ChatroomRepository
+}
+
+func NewImportService(m repositories.MessageRepository, u repositories.UserRepository, c repositories.ChatroomRepository) *ImportService {
+	return &ImportService{messages: m, users: u, chatrooms: c}
+}
+
+// CreateRoom creates the room a history is imported into, owned by the named user.
+func (s *ImportService) CreateRoom(title, ownerName string, public bool) (*models.Chatroom, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > 100 {
+		return nil, fmt.Errorf("%w: room title must be 1-100 characters", ErrInvalidImport)
+	}
+	owner, err := s.realUser(ownerName)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	room := &models.Chatroom{Title: title, OwnerId: owner.ID, IsPublic: public}
+	member := models.UserChatroom{UserID: owner.ID, Name: owner.Name, IsJoined: true, LastJoinTime: &now, IsAdmin: true, IsOwner: true}
+	if err := s.chatrooms.CreateWithOwner(room, member); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+func (s *ImportService) Room(chatroomID uint) (*models.Chatroom, error) {
+	return s.chatrooms.FindByID(chatroomID)
+}
+
+// Import stores a history, oldest message first, in the room. Messages keep their
+// original times and go through the message repository like any other, so search,
+// paging and exports treat them the same; nobody is notified. Messages stored by an
+// earlier run of the same import are skipped. Replies whose thread root is not part of
+// the history are stored as ordinary messages.
+//
+// Members' read markers are moved past the imported messages so they do not show up
+// as unread.
+func (s *ImportService) Import(room *models.Chatroom, source ImportSource, history []ImportedMessage, opts ImportOptions) (ImportResult, error) {
+	var result ImportResult
+	authors := make(map[string]uint)
+	ids := make(map[string]uint, len(history))
+	var last uint
+	for _, imported := range history {
+		authorID, ok := authors[imported.Author]
+		if !ok {
+			user, placeholder, err := s.author(source, imported.Author, opts)
+			if err != nil {
+				return result, err
+			}
+			authorID = user.ID
+			authors[imported.Author] = authorID
+			if placeholder {
+				result.Placeholders = append(result.Placeholders, user.Name)
+			}
+		}
+
+		clientID := importClientID(room.Id, imported.Key)
+		kind := models.MessageKindText
+		if imported.Action {
+			kind = models.MessageKindAction
+		}
+		message := models.Message{
+			ChatroomID: room.Id,
+			UserId:     authorID,
+			Content:    imported.Content,
+			CreatedAt:  imported.SentAt.UTC(),
+			EditedAt:   imported.EditedAt,
+			ClientID:   &clientID,
+			Kind:       kind,
+		}
+		if rootID, ok := ids[imported.ThreadKey]; ok && imported.ThreadKey != "" {
+			message.ParentID = &rootID
+		}
+		created, err := s.messages.Import(&message)
+		if err != nil {
+			return result, err
+		}
+		if created {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+		// Only roots can be replied to: a reply to a reply joins the root's thread.
+		if message.ParentID == nil {
+			ids[imported.Key] = message.ID
+		} else {
+			ids[imported.Key] = *message.ParentID
+		}
+		last = max(last, message.ID)
+	}
+
+	if result.Imported > 0 {
+		members, err := s.chatrooms.ListJoinedMembers(room.Id)
+		if err != nil {
+			return result, err
+		}
+		for _, member := range members {
+			if err := s.chatrooms.MarkRead(member.UserID, room.Id, last); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// author finds the user an author's messages are attributed to, and reports whether it
+// is a placeholder.
+func (s *ImportService) author(source ImportSource, name string, opts ImportOptions) (*models.User, bool, error) {
+	if mapped, ok := opts.Users[name]; ok {
+		user, err := s.realUser(mapped)
+		return user, false, err
+	}
+	user, err := s.realUser(name)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, false, err
+	}
+	placeholder := name + "@" + source.network()
+	if utf8.RuneCountInString(placeholder) > 100 {
+		return nil, false, fmt.Errorf("%w: author name %q is too long", ErrInvalidImport, name)
+	}
+	user, err = s.users.FindOrCreatePlaceholder(placeholder)
+	return user, true, err
+}
+
+// realUser looks up a user who can sign in by name.
+func (s *ImportService) realUser(name string) (*models.User, error) {
+	user, err := s.users.FindByName(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	return user, err
+}
+
+// importClientID derives a message's idempotency key from the room and its source key.
+func importClientID(chatroomID uint, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s", chatroomID, key)))
+	return importClientPrefix + hex.EncodeToString(sum[:20])
+}
@@ -0,0 +1,21 @@
+package services
+
+import "testing"
+
+func TestIsPlaceholderName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "alice", want: false},
+		{name: "alice@slack", want: true},
+		{name: "Alice@IRC", want: true},
+		{name: "alice@example.com", want: false},
+		{name: "slack", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsPlaceholderName(tt.name); got != tt.want {
+			t.Errorf("IsPlaceholderName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
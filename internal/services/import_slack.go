@@ -0,0 +1,235 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackMessage is the part of a message in a Slack export that an import uses.
+type slackMessage struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	User        string `json:"user"`
+	Username    string `json:"username"` // set on bot messages
+	Text        string `json:"text"`
+	UserProfile struct {
+		Name string `json:"name"`
+	} `json:"user_profile"`
+	Edited *struct {
+		TS string `json:"ts"`
+	} `json:"edited"`
+	Files []struct {
+		Name      string `json:"name"`
+		Permalink string `json:"permalink"`
+	} `json:"files"`
+}
+
+// slackKeptSubtypes are the message subtypes that carry conversation. Joins, topic
+// changes and other channel events are left out.
+var slackKeptSubtypes = []string{"", "me_message", "thread_broadcast", "bot_message", "file_share"}
+
+// slackLink matches Slack's <…> markup for mentions, channels and links.
+var slackLink = regexp.MustCompile(`<([^<>]*)>`)
+
+// ReadSlackExport reads the history of one channel from a Slack export ZIP, oldest
+// message first. channel may be left empty when the export holds a single channel. It
+// also returns the channel's name.
+func ReadSlackExport(r io.ReaderAt, size int64, channel string) ([]ImportedMessage, string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	// Day files live in a folder per channel: general/2024-01-31.json.
+	days := make(map[string][]*zip.File)
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		dir, name := path.Split(f.Name)
+		dir = strings.TrimSuffix(dir, "/")
+		if dir == "" {
+			files[name] = f
+		} else if path.Ext(name) == ".json" && !strings.Contains(dir, "/") {
+			days[dir] = append(days[dir], f)
+		}
+	}
+	if channel == "" && len(days) == 1 {
+		for name := range days {
+			channel = name
+		}
+	}
+	channel = strings.TrimPrefix(channel, "#")
+	if days[channel] == nil {
+		names := make([]string, 0, len(days))
+		for name := range days {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		if channel == "" {
+			return nil, "", fmt.Errorf("%w: choose a channel: %s", ErrInvalidImport, strings.Join(names, ", "))
+		}
+		return nil, "", fmt.Errorf("%w: no channel %q in the export; it has %s", ErrInvalidImport, channel, strings.Join(names, ", "))
+	}
+
+	// users.json and the channel listings all hold objects with an id and a name.
+	type named struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	var users, channels []named
+	if err := readSlackJSON(files["users.json"], &users); err != nil {
+		return nil, "", err
+	}
+	for _, listing := range []string{"channels.json", "groups.json", "mpims.json"} {
+		var more []named
+		if err := readSlackJSON(files[listing], &more); err != nil {
+			return nil, "", err
+		}
+		channels = append(channels, more...)
+	}
+	userNames := make(map[string]string, len(users))
+	for _, user := range users {
+		userNames[user.ID] = user.Name
+	}
+	channelNames := make(map[string]string, len(channels))
+	channelID := channel
+	for _, c := range channels {
+		channelNames[c.ID] = c.Name
+		if c.Name == channel {
+			channelID = c.ID
+		}
+	}
+
+	var raw []slackMessage
+	for _, f := range days[channel] {
+		var day []slackMessage
+		if err := readSlackJSON(f, &day); err != nil {
+			return nil, "", err
+		}
+		raw = append(raw, day...)
+	}
+	slices.SortStableFunc(raw, func(a, b slackMessage) int {
+		return slackTime(a.TS).Compare(slackTime(b.TS))
+	})
+
+	key := func(ts string) string { return "slack:" + channelID + ":" + ts }
+	var history []ImportedMessage
+	for _, m := range raw {
+		if m.Type != "message" || m.TS == "" || !slices.Contains(slackKeptSubtypes, m.Subtype) {
+			continue
+		}
+		content := slackText(m.Text, userNames, channelNames)
+		for _, file := range m.Files {
+			content = strings.TrimSpace(content + "\n📎 " + file.Name + " " + file.Permalink)
+		}
+		if content == "" {
+			continue
+		}
+		author := userNames[m.User]
+		if author == "" {
+			author = m.UserProfile.Name
+		}
+		if author == "" {
+			author = m.Username
+		}
+		if author == "" {
+			author = m.User
+		}
+		if author == "" {
+			continue
+		}
+
+		imported := ImportedMessage{
+			Key:     key(m.TS),
+			Author:  author,
+			SentAt:  slackTime(m.TS),
+			Content: content,
+			Action:  m.Subtype == "me_message",
+		}
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			imported.ThreadKey = key(m.ThreadTS)
+		}
+		if m.Edited != nil {
+			if edited := slackTime(m.Edited.TS); !edited.IsZero() {
+				imported.EditedAt = &edited
+			}
+		}
+		history = append(history, imported)
+	}
+	return history, channel, nil
+}
+
+// readSlackJSON decodes one file of the export; a missing file leaves v alone.
+func readSlackJSON(f *zip.File, v any) error {
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidImport, f.Name, err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidImport, f.Name, err)
+	}
+	return nil
+}
+
+// slackTime reads a Slack timestamp such as "1700000000.123456", in seconds and
+// microseconds.
+func slackTime(ts string) time.Time {
+	secs, micros, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	us, _ := strconv.ParseInt(micros, 10, 64)
+	return time.Unix(s, us*1000).UTC()
+}
+
+// slackText turns Slack's markup into plain text: mentions and channels become
+// @name and #channel, and links keep their label and address.
+func slackText(text string, users, channels map[string]string) string {
+	text = slackLink.ReplaceAllStringFunc(text, func(match string) string {
+		target, label, hasLabel := strings.Cut(match[1:len(match)-1], "|")
+		switch {
+		case strings.HasPrefix(target, "@"):
+			if name, ok := users[target[1:]]; ok {
+				return "@" + name
+			}
+			if hasLabel {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+			return target
+		case strings.HasPrefix(target, "#"):
+			if hasLabel {
+				return "#" + label
+			}
+			if name, ok := channels[target[1:]]; ok {
+				return "#" + name
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			if hasLabel {
+				return label
+			}
+			command, _, _ := strings.Cut(target[1:], "^")
+			return "@" + command
+		}
+		address := strings.TrimPrefix(target, "mailto:")
+		if hasLabel && label != address {
+			return label + " (" + address + ")"
+		}
+		return address
+	})
+	return strings.TrimSpace(strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(text))
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadSlackExport(t *testing.T) {
+	edited := time.Unix(1704067260, 0).UTC()
+	tests := []struct {
+		name        string
+		files       map[string]string // zipped into the export; ignored when data is set
+		data        []byte
+		channel     string
+		want        []ImportedMessage
+		wantChannel string
+		wantErr     error
+	}{
+		{
+			name: "full export",
+			files: map[string]string{
+				"users.json":    `[{"id":"U1","name":"alice"},{"id":"U2","name":"bob"}]`,
+				"channels.json": `[{"id":"C1","name":"general"},{"id":"C2","name":"random"}]`,
+				"general/2024-01-02.json": `[
+					{"type":"message","ts":"1704153600.000200","user":"U2","text":"reply to <@U1>","thread_ts":"1704067200.000100"},
+					{"type":"message","subtype":"channel_join","ts":"1704153601.000000","user":"U2","text":"<@U2> has joined"}
+				]`,
+				"general/2024-01-01.json": `[
+					{"type":"message","ts":"1704067200.000100","user":"U1","text":"hello &lt;all&gt;","edited":{"ts":"1704067260.000000"}},
+					{"type":"message","subtype":"me_message","ts":"1704067300.000000","user":"U1","text":"waves"},
+					{"type":"message","subtype":"bot_message","ts":"1704067400.000000","username":"deploybot","text":"deployed"}
+				]`,
+				"random/2024-01-01.json": `[{"type":"message","ts":"1704067200.000100","user":"U1","text":"elsewhere"}]`,
+			},
+			channel: "#general",
+			want: []ImportedMessage{
+				{Key: "slack:C1:1704067200.000100", Author: "alice", SentAt: time.Unix(1704067200, 100000).UTC(), EditedAt: &edited, Content: "hello <all>"},
+				{Key: "slack:C1:1704067300.000000", Author: "alice", SentAt: time.Unix(1704067300, 0).UTC(), Content: "waves", Action: true},
+				{Key: "slack:C1:1704067400.000000", Author: "deploybot", SentAt: time.Unix(1704067400, 0).UTC(), Content: "deployed"},
+				{Key: "slack:C1:1704153600.000200", Author: "bob", SentAt: time.Unix(1704153600, 200000).UTC(), Content: "reply to @alice", ThreadKey: "slack:C1:1704067200.000100"},
+			},
+			wantChannel: "general",
+		},
+		{
+			name:        "single channel needs no name",
+			files:       map[string]string{"ops/2024-01-01.json": `[]`},
+			wantChannel: "ops",
+		},
+		{
+			name:    "several channels need a name",
+			files:   map[string]string{"ops/2024-01-01.json": `[]`, "dev/2024-01-01.json": `[]`},
+			wantErr: ErrInvalidImport,
+		},
+		{
+			name:    "missing channel",
+			files:   map[string]string{"ops/2024-01-01.json": `[]`},
+			channel: "dev",
+			wantErr: ErrInvalidImport,
+		},
+		{
+			name:    "broken day file",
+			files:   map[string]string{"ops/2024-01-01.json": `{`},
+			wantErr: ErrInvalidImport,
+		},
+		{
+			name:    "not a zip",
+			data:    []byte("not a zip"),
+			wantErr: ErrInvalidImport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			if data == nil {
+				var buf bytes.Buffer
+				zw := zip.NewWriter(&buf)
+				for name, content := range tt.files {
+					w, err := zw.Create(name)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, err := w.Write([]byte(content)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := zw.Close(); err != nil {
+					t.Fatal(err)
+				}
+				data = buf.Bytes()
+			}
+			history, channel, err := ReadSlackExport(bytes.NewReader(data), int64(len(data)), tt.channel)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if channel != tt.wantChannel {
+				t.Errorf("channel = %q, want %q", channel, tt.wantChannel)
+			}
+			if !reflect.DeepEqual(history, tt.want) {
+				t.Errorf("got %+v, want %+v", history, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackTime(t *testing.T) {
+	tests := []struct {
+		ts   string
+		want time.Time
+	}{
+		{ts: "1700000000.123456", want: time.Unix(1700000000, 123456000).UTC()},
+		{ts: "1700000000", want: time.Unix(1700000000, 0).UTC()},
+		{ts: "", want: time.Time{}},
+		{ts: "soon", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := slackTime(tt.ts); !got.Equal(tt.want) {
+			t.Errorf("slackTime(%q) = %v, want %v", tt.ts, got, tt.want)
+		}
+	}
+}
+
+func TestSlackText(t *testing.T) {
+	users := map[string]string{"U1": "alice"}
+	channels := map[string]string{"C1": "general"}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "  hi  ", want: "hi"},
+		{name: "known user", text: "hey <@U1>", want: "hey @alice"},
+		{name: "unknown user with label", text: "<@U9|bob>", want: "@bob"},
+		{name: "unknown user", text: "<@U9>", want: "@U9"},
+		{name: "channel by id", text: "see <#C1>", want: "see #general"},
+		{name: "channel label wins", text: "<#C1|ops>", want: "#ops"},
+		{name: "special mention", text: "<!here> now", want: "@here now"},
+		{name: "special mention with label", text: "<!subteam^S1|@devs>", want: "@devs"},
+		{name: "link with label", text: "<https://example.com|docs>", want: "docs (https://example.com)"},
+		{name: "bare link", text: "<https://example.com>", want: "https://example.com"},
+		{name: "mail link", text: "<mailto:a@b.c|a@b.c>", want: "a@b.c"},
+		{name: "entities", text: "a &lt;b&gt; &amp;amp;", want: "a <b> &amp;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slackText(tt.text, users, channels); got != tt.want {
+				t.Errorf("slackText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMemberNotFound = errors.New("member not found")
+	ErrCannotModerate = errors.New("cannot moderate this member")
+	ErrInvalidMute    = errors.New("invalid mute")
+	ErrNotMuted       = errors.New("member is not muted")
+	ErrMuted          = errors.New("user is muted in this chatroom")
+	ErrCannotPost     = errors.New("user's role may not post in this chatroom")
+	ErrNoPermission   = errors.New("user's role lacks the permission")
+	ErrInvalidBan     = errors.New("invalid ban")
+	ErrAlreadyBanned  = errors.New("member is already banned")
+	ErrNotBanned      = errors.New("member is not banned")
+	ErrNotRoomOwner   = errors.New("user is not the owner of this chatroom")
+	ErrAlreadyAdmin   = errors.New("member is already an admin")
+	ErrNotAdmin       = errors.New("member is not an admin")
+	ErrNoOwnerOffer   = errors.New("no ownership offer")
+)
+
+// MaxMuteDuration and MaxBanDuration bound timed mutes and bans; longer ones should
+// simply not expire.
+const (
+	MaxMuteDuration = 365 * 24 * time.Hour
+	MaxBanDuration  = 365 * 24 * time.Hour
+)
+
+// maxReasonLength matches the width of the mute_reason and ban_reason columns.
+const maxReasonLength = 255
+
+// OwnerOfferTTL is how long a member has to accept ownership of a room.
+const OwnerOfferTTL = 24 * time.Hour
+
+// liftBatch is how many expired mutes, or bans, one cleanup run lifts.
+const liftBatch = 100
+
+// MemberService moderates a room's members. Every change notifies the member and is
+// broadcast to the room as "member_updated".
+type MemberService struct {
+	chatrooms repositories.ChatroomRepository
+}
+
+func NewMemberService(c repositories.ChatroomRepository) *MemberService {
+	return &MemberService{chatrooms: c}
+}
+
+// Kick removes a member from the room; they can join again, or be invited back to a
+// private room. It takes the kick permission and a role that outranks the member's.
+func (s *MemberService) Kick(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	_, target, err := s.moderate(actorID, chatroomID, targetID, models.PermKick)
+	if err != nil {
+		return nil, err
+	}
+	target.IsJoined = false
+	if err := s.chatrooms.SaveUserChatroom(target); err != nil {
+		return nil, err
+	}
+	s.announce(target, actorID, "kicked", "kick", fmt.Sprintf("You have been kicked from %s", s.roomTitle(chatroomID)))
+	return target, nil
+}
+
+// Mute stops a member posting in the room for duration, or until they are unmuted when
+// it is zero. Muting a muted member replaces the mute. It takes the mute permission and a
+// role that outranks the member's; nobody can mute the owner or themselves.
+func (s *MemberService) Mute(actorID, chatroomID, targetID uint, duration time.Duration, reason string) (*models.UserChatroom, error) {
+	reason = strings.TrimSpace(reason)
+	if !validPenalty(duration, MaxMuteDuration, reason) {
+		return nil, ErrInvalidMute
+	}
+	_, target, err := s.moderate(actorID, chatroomID, targetID, models.PermMute)
+	if err != nil {
+		return nil, err
+	}
+
+	target.IsMuted = true
+	target.MutedUntil = nil
+	target.MuteReason = reason
+	if duration > 0 {
+		until := time.Now().Add(duration).UTC()
+		target.MutedUntil = &until
+	}
+	if err := s.chatrooms.SaveMute(target); err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("You have been muted in %s", s.roomTitle(chatroomID))
+	if target.MutedUntil != nil {
+		content += " until " + target.MutedUntil.Format(untilLayout)
+	}
+	if reason != "" {
+		content += ": " + reason
+	}
+	s.announce(target, actorID, "muted", "mute", content)
+	return target, nil
+}
+
+// Unmute lifts a member's mute. The same members can be unmuted as muted.
+func (s *MemberService) Unmute(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	_, target, err := s.moderate(actorID, chatroomID, targetID, models.PermMute)
+	if err != nil {
+		return nil, err
+	}
+	if !target.MutedAt(time.Now()) {
+		return nil, ErrNotMuted
+	}
+	target.IsMuted, target.MutedUntil, target.MuteReason = false, nil, ""
+	if err := s.chatrooms.SaveMute(target); err != nil {
+		return nil, err
+	}
+	s.announce(target, actorID, "unmuted", "unmute", fmt.Sprintf("You can post in %s again", s.roomTitle(chatroomID)))
+	return target, nil
+}
+
+// LiftExpiredMutes clears the timed mutes that have run out and tells the members.
+// Sending is allowed again as soon as a mute runs out; this is what announces it.
+func (s *MemberService) LiftExpiredMutes(now time.Time) (int, error) {
+	expired, err := s.chatrooms.ExpiredMutes(now, liftBatch)
+	if err != nil {
+		return 0, err
+	}
+	lifted := 0
+	for i := range expired {
+		ok, err := s.chatrooms.LiftExpiredMute(expired[i].ID, now)
+		if err != nil {
+			return lifted, err
+		}
+		if !ok {
+			continue // unmuted or muted again meanwhile
+		}
+		lifted++
+		member := &expired[i]
+		member.IsMuted, member.MutedUntil, member.MuteReason = false, nil, ""
+		s.announce(member, 0, "mute_expired", "unmute", fmt.Sprintf("Your mute in %s has ended", s.roomTitle(member.ChatroomID)))
+	}
+	return lifted, nil
+}
+
+// Ban removes a member from the room and stops them rejoining for duration, or until
+// they are unbanned when it is zero. Members who were invited but never joined can be
+// banned too. It takes the ban permission and a role that outranks the member's, and a
+// banned member loses their role.
+func (s *MemberService) Ban(actorID, chatroomID, targetID uint, duration time.Duration, reason string) (*models.UserChatroom, error) {
+	reason = strings.TrimSpace(reason)
+	if !validPenalty(duration, MaxBanDuration, reason) {
+		return nil, ErrInvalidBan
+	}
+	actor, actorRole, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermBan)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.member(targetID, chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	if target.IsBanned {
+		return nil, ErrAlreadyBanned
+	}
+	if err := s.checkOutranks(actor, actorRole, target); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	target.IsBanned, target.IsJoined, target.IsInvited, target.IsAdmin = true, false, false, false
+	target.RoleID = nil
+	target.BannedUntil = nil
+	target.BanReason = reason
+	target.BannedBy = &actorID
+	target.BannedSince = &now
+	if duration > 0 {
+		until := now.Add(duration)
+		target.BannedUntil = &until
+	}
+	if err := s.chatrooms.SaveBan(target); err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("You have been banned from %s", s.roomTitle(chatroomID))
+	if target.BannedUntil != nil {
+		content += " until " + target.BannedUntil.Format(untilLayout)
+	}
+	if reason != "" {
+		content += ": " + reason
+	}
+	s.announce(target, actorID, "banned", "ban", content)
+	return target, nil
+}
+
+// Unban lifts a member's ban. They can then join again, or be invited to a private room.
+func (s *MemberService) Unban(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	if _, _, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermBan); err != nil {
+		return nil, err
+	}
+	target, err := s.member(targetID, chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	if !target.IsBanned {
+		return nil, ErrNotBanned
+	}
+	clearBan(target)
+	if err := s.chatrooms.SaveBan(target); err != nil {
+		return nil, err
+	}
+	s.announce(target, actorID, "unbanned", "unban", fmt.Sprintf("Your ban from %s has been lifted", s.roomTitle(chatroomID)))
+	return target, nil
+}
+
+// Bans lists the room's banned members for a member who may ban, most recent first.
+func (s *MemberService) Bans(actorID, chatroomID uint) ([]models.UserChatroom, error) {
+	if _, _, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermBan); err != nil {
+		return nil, err
+	}
+	return s.chatrooms.ListBanned(chatroomID)
+}
+
+// LiftExpiredBans clears the timed bans that have run out and tells the members. Unlike
+// a mute, a ban holds until this lifts it.
+func (s *MemberService) LiftExpiredBans(now time.Time) (int, error) {
+	expired, err := s.chatrooms.ExpiredBans(now, liftBatch)
+	if err != nil {
+		return 0, err
+	}
+	lifted := 0
+	for i := range expired {
+		ok, err := s.chatrooms.LiftExpiredBan(expired[i].ID, now)
+		if err != nil {
+			return lifted, err
+		}
+		if !ok {
+			continue // unbanned or banned again meanwhile
+		}
+		lifted++
+		member := &expired[i]
+		clearBan(member)
+		s.announce(member, 0, "ban_expired", "unban", fmt.Sprintf("Your ban from %s has ended", s.roomTitle(member.ChatroomID)))
+	}
+	return lifted, nil
+}
+
+// Promote gives a member the built-in admin role. It takes the manage_roles permission
+// and a role that outranks both the member's and the admin role.
+func (s *MemberService) Promote(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	actorRole, target, err := s.moderate(actorID, chatroomID, targetID, models.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	if target.BuiltInRoleName() == models.RoleAdmin {
+		return nil, ErrAlreadyAdmin
+	}
+	role, err := s.chatrooms.FindRoleByName(chatroomID, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.assign(actorID, actorRole, target, role, "promoted", "Promoted to Admin",
+		fmt.Sprintf("You have been promoted to admin in %s", s.roomTitle(chatroomID))); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Demote gives an admin the built-in member role, like Promote.
+func (s *MemberService) Demote(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	actorRole, target, err := s.moderate(actorID, chatroomID, targetID, models.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	if target.BuiltInRoleName() != models.RoleAdmin {
+		return nil, ErrNotAdmin
+	}
+	role, err := s.chatrooms.FindRoleByName(chatroomID, models.RoleMember)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.assign(actorID, actorRole, target, role, "demoted", "demote",
+		fmt.Sprintf("You are no longer an admin in %s", s.roomTitle(chatroomID))); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// AssignRole gives a member one of the room's roles other than owner, which passes
+// only through an ownership transfer. It takes the manage_roles permission and a role
+// that outranks both the member's and the one granted.
+func (s *MemberService) AssignRole(actorID, chatroomID, targetID, roleID uint) (*models.UserChatroom, error) {
+	actorRole, target, err := s.moderate(actorID, chatroomID, targetID, models.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.chatrooms.FindRole(chatroomID, roleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.assign(actorID, actorRole, target, role, "role_changed", "role",
+		fmt.Sprintf("Your role in %s is now %s", s.roomTitle(chatroomID), role.Name)); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// assign gives target the role once the actor's role is checked to outrank it. The
+// built-in admin and member roles are held through IsAdmin, custom ones through RoleID.
+func (s *MemberService) assign(actorID uint, actorRole models.Role, target *models.UserChatroom, role *models.Role, action, kind, content string) error {
+	if role.BuiltIn && role.Name == models.RoleOwner {
+		return ErrBuiltInRole
+	}
+	if role.Rank >= actorRole.Rank {
+		return ErrRoleTooHigh
+	}
+	var roleID *uint
+	if !role.BuiltIn {
+		roleID = &role.ID
+	}
+	if err := s.chatrooms.AssignRole(target, roleID, role.BuiltIn && role.Name == models.RoleAdmin); err != nil {
+		return err
+	}
+	utils.ForgetMembership(target.UserID, target.ChatroomID)
+	s.announce(target, actorID, action, kind, content)
+	return nil
+}
+
+// OfferOwnership offers the room to a member, who becomes its owner if they accept
+// within OwnerOfferTTL. A new offer replaces the previous one.
+func (s *MemberService) OfferOwnership(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	target, err := s.ownerActsOn(actorID, chatroomID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(OwnerOfferTTL).UTC()
+	ok, err := s.chatrooms.OfferOwnership(chatroomID, actorID, targetID, expires)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotRoomOwner
+	}
+	s.announce(target, actorID, "ownership_offered", "ownership_offer",
+		fmt.Sprintf("You have been offered ownership of %s until %s", s.roomTitle(chatroomID), expires.Format(untilLayout)))
+	return target, nil
+}
+
+// AcceptOwnership makes the user the room's owner if they hold an offer for it. The
+// previous owner stays an admin.
+func (s *MemberService) AcceptOwnership(userID, chatroomID uint) (*models.UserChatroom, error) {
+	previous, err := s.chatrooms.AcceptOwnership(chatroomID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if previous == 0 {
+		return nil, ErrNoOwnerOffer
+	}
+	utils.ForgetMembership(userID, chatroomID)
+	utils.ForgetMembership(previous, chatroomID)
+
+	owner, err := s.chatrooms.FindUserChatroom(userID, chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	n := newNotification(previous, chatroomID, userID, "ownership",
+		fmt.Sprintf("%s is now the owner of %s", owner.Name, s.roomTitle(chatroomID)))
+	if err := s.chatrooms.SaveNotification(&n); err != nil {
+		log.Printf("ownership notification failed: %v", err)
+	}
+	broadcast(chatroomID, "member_updated", models.MemberUpdatedPayload{Action: "owner_changed", Member: *owner})
+	return owner, nil
+}
+
+// DeclineOwnership drops an ownership offer. The member offered the room declines it;
+// the owner withdraws it by naming them.
+func (s *MemberService) DeclineOwnership(actorID, chatroomID, targetID uint) error {
+	if actorID != targetID {
+		if _, err := s.owner(actorID, chatroomID); err != nil {
+			return err
+		}
+	}
+	ok, err := s.chatrooms.WithdrawOwnershipOffer(chatroomID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoOwnerOffer
+	}
+	member, err := s.member(targetID, chatroomID)
+	if err != nil {
+		return err
+	}
+	if actorID == targetID {
+		if room, err := s.chatrooms.FindByID(chatroomID); err == nil {
+			n := newNotification(room.OwnerId, chatroomID, actorID, "ownership",
+				fmt.Sprintf("%s declined ownership of %s", member.Name, room.Title))
+			if err := s.chatrooms.SaveNotification(&n); err != nil {
+				log.Printf("ownership notification failed: %v", err)
+			}
+		}
+	}
+	broadcast(chatroomID, "member_updated", models.MemberUpdatedPayload{Action: "ownership_declined", Member: *member})
+	return nil
+}
+
+func clearBan(uc *models.UserChatroom) {
+	uc.IsBanned, uc.BannedUntil, uc.BanReason, uc.BannedBy, uc.BannedSince = false, nil, "", nil, nil
+}
+
+// validPenalty checks the duration and reason given for a mute or ban.
+func validPenalty(duration, limit time.Duration, reason string) bool {
+	return duration >= 0 && duration <= limit && !strings.ContainsAny(reason, "\r\n") && utf8.RuneCountInString(reason) <= maxReasonLength
+}
+
+// untilLayout formats mute and ban expiry times in notifications.
+const untilLayout = "2 Jan 15:04 UTC"
+
+// checkCanPost returns ErrMuted when the user's mute in the room is in force, and
+// ErrCannotPost when their role lacks the send permission. Membership itself is
+// checked by the callers.
+func checkCanPost(chatrooms repositories.ChatroomRepository, userID, chatroomID uint) error {
+	uc, err := chatrooms.FindUserChatroom(userID, chatroomID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if uc.MutedAt(time.Now()) {
+		return ErrMuted
+	}
+	role, err := chatrooms.MemberRole(uc)
+	if err != nil {
+		return err
+	}
+	if !role.Permissions.Has(models.PermSend) {
+		return ErrCannotPost
+	}
+	return nil
+}
+
+// moderate loads the acting member's role and the target's membership, and checks the
+// actor may act on the target with perm. The target must be in the room.
+func (s *MemberService) moderate(actorID, chatroomID, targetID uint, perm models.Permissions) (models.Role, *models.UserChatroom, error) {
+	actor, actorRole, err := actingMember(s.chatrooms, actorID, chatroomID, perm)
+	if err != nil {
+		return models.Role{}, nil, err
+	}
+	target, err := s.member(targetID, chatroomID)
+	if err != nil {
+		return models.Role{}, nil, err
+	}
+	if !target.IsJoined || target.IsBanned {
+		return models.Role{}, nil, ErrMemberNotFound
+	}
+	if err := s.checkOutranks(actor, actorRole, target); err != nil {
+		return models.Role{}, nil, err
+	}
+	return actorRole, target, nil
+}
+
+// actingMember loads the membership and role of a member acting in the room, and checks
+// the role grants perm.
+func actingMember(chatrooms repositories.ChatroomRepository, actorID, chatroomID uint, perm models.Permissions) (*models.UserChatroom, models.Role, error) {
+	actor, err := chatrooms.FindUserChatroom(actorID, chatroomID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.Role{}, err
+	}
+	if err != nil || !actor.IsJoined || actor.IsBanned {
+		return nil, models.Role{}, ErrNoPermission
+	}
+	role, err := chatrooms.MemberRole(actor)
+	if err != nil {
+		return nil, models.Role{}, err
+	}
+	if !role.Permissions.Has(perm) {
+		return nil, models.Role{}, ErrNoPermission
+	}
+	return actor, role, nil
+}
+
+// owner loads the membership of the room's owner acting in it.
+func (s *MemberService) owner(actorID, chatroomID uint) (*models.UserChatroom, error) {
+	actor, err := s.chatrooms.FindUserChatroom(actorID, chatroomID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err != nil || !actor.IsJoined || actor.IsBanned || !actor.IsOwner {
+		return nil, ErrNotRoomOwner
+	}
+	return actor, nil
+}
+
+func (s *MemberService) member(userID, chatroomID uint) (*models.UserChatroom, error) {
+	uc, err := s.chatrooms.FindUserChatroom(userID, chatroomID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	return uc, err
+}
+
+// ownerActsOn checks the actor owns the room and loads another member for them to act on.
+func (s *MemberService) ownerActsOn(actorID, chatroomID, targetID uint) (*models.UserChatroom, error) {
+	if _, err := s.owner(actorID, chatroomID); err != nil {
+		return nil, err
+	}
+	_, target, err := s.moderate(actorID, chatroomID, targetID, 0)
+	return target, err
+}
+
+// checkOutranks returns ErrCannotModerate unless actor, holding actorRole, may act on
+// target: another member whose role ranks below the actor's. Nobody outranks the owner.
+func (s *MemberService) checkOutranks(actor *models.UserChatroom, actorRole models.Role, target *models.UserChatroom) error {
+	if target.UserID == actor.UserID || target.IsOwner {
+		return ErrCannotModerate
+	}
+	targetRole, err := s.chatrooms.MemberRole(target)
+	if err != nil {
+		return err
+	}
+	if targetRole.Rank >= actorRole.Rank {
+		return ErrCannotModerate
+	}
+	return nil
+}
+
+// announce notifies the member of a change made by actorID (0 for the server itself)
+// and broadcasts it to the room.
+func (s *MemberService) announce(member *models.UserChatroom, actorID uint, action, kind, content string) {
+	n := newNotification(member.UserID, member.ChatroomID, actorID, kind, content)
+	if err := s.chatrooms.SaveNotification(&n); err != nil {
+		log.Printf("%s notification failed: %v", kind, err)
+	}
+	broadcast(member.ChatroomID, "member_updated", models.MemberUpdatedPayload{Action: action, Member: *member})
+}
+
+func (s *MemberService) roomTitle(chatroomID uint) string {
+	if room, err := s.chatrooms.FindByID(chatroomID); err == nil {
+		return room.Title
+	}
+	return fmt.Sprintf("chatroom %d", chatroomID)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// rankedChatrooms answers MemberRole from each member's built-in flags, with custom
+// roles looked up by id.
+type rankedChatrooms struct {
+	repositories.ChatroomRepository
+	custom map[uint]models.Role
+}
+
+func (r rankedChatrooms) MemberRole(uc *models.UserChatroom) (models.Role, error) {
+	if uc.RoleID != nil && !uc.IsOwner {
+		role, ok := r.custom[*uc.RoleID]
+		if !ok {
+			return models.Role{}, gorm.ErrRecordNotFound
+		}
+		return role, nil
+	}
+	for _, role := range models.BuiltInRoles(uc.ChatroomID) {
+		if role.Name == uc.BuiltInRoleName() {
+			return role, nil
+		}
+	}
+	return models.Role{}, gorm.ErrRecordNotFound
+}
+
+func TestCheckOutranks(t *testing.T) {
+	helper, senior, missing := uint(1), uint(2), uint(9)
+	chatrooms := rankedChatrooms{custom: map[uint]models.Role{
+		helper: {ID: helper, Name: "helper", Permissions: models.PermMute, Rank: 10},
+		senior: {ID: senior, Name: "senior", Permissions: models.PermKick, Rank: 70},
+	}}
+	s := NewMemberService(chatrooms)
+
+	owner := &models.UserChatroom{UserID: 1, IsOwner: true}
+	admin := &models.UserChatroom{UserID: 2, IsAdmin: true}
+	otherAdmin := &models.UserChatroom{UserID: 3, IsAdmin: true}
+	member := &models.UserChatroom{UserID: 4}
+	helperMember := &models.UserChatroom{UserID: 5, RoleID: &helper}
+	seniorMember := &models.UserChatroom{UserID: 6, RoleID: &senior}
+	orphan := &models.UserChatroom{UserID: 7, RoleID: &missing}
+
+	tests := []struct {
+		name   string
+		actor  *models.UserChatroom
+		target *models.UserChatroom
+		want   error
+	}{
+		{name: "owner over admin", actor: owner, target: admin},
+		{name: "admin over member", actor: admin, target: member},
+		{name: "admin over lower custom role", actor: admin, target: helperMember},
+		{name: "custom role over member", actor: helperMember, target: member},
+		{name: "nobody acts on themselves", actor: admin, target: admin, want: ErrCannotModerate},
+		{name: "nobody outranks the owner", actor: seniorMember, target: owner, want: ErrCannotModerate},
+		{name: "equal ranks", actor: admin, target: otherAdmin, want: ErrCannotModerate},
+		{name: "higher custom role", actor: admin, target: seniorMember, want: ErrCannotModerate},
+		{name: "member over admin", actor: member, target: admin, want: ErrCannotModerate},
+		{name: "target role lookup fails", actor: admin, target: orphan, want: gorm.ErrRecordNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actorRole, err := chatrooms.MemberRole(tt.actor)
+			if err != nil {
+				t.Fatalf("MemberRole: %v", err)
+			}
+			err = s.checkOutranks(tt.actor, actorRole, tt.target)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("checkOutranks = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	mentionHere   = "here"   // every member of the room; moderators only
+	mentionAdmins = "admins" // the room's moderators, whatever their role
+)
+
+// parseMentions returns the lower-cased names mentioned as @name in content, without
+// duplicates and in order of first appearance. Trailing punctuation is not part of a name.
+func parseMentions(content string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, field := range strings.Fields(content) {
+		if !strings.HasPrefix(field, "@") {
+			continue
+		}
+		name := strings.TrimRightFunc(field[1:], func(r rune) bool {
+			return unicode.IsPunct(r) && r != '_' && r != '-'
+		})
+		name = strings.ToLower(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -2,39 +2,645 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/Wal-20/cli-chat-app/internal/api/ws"
 	"github.com/Wal-20/cli-chat-app/internal/models"
 	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrMessageNotFound  = errors.New("message not found")
+	ErrNotMessageAuthor = errors.New("user is not the author of this message")
+	ErrInvalidEmoji     = errors.New("invalid emoji")
+	ErrNotPinned        = errors.New("message is not pinned")
+	ErrInvalidClientID  = errors.New("invalid client id")
+	ErrInvalidTTL       = errors.New("invalid message ttl")
+	ErrInvalidKind      = errors.New("invalid message kind")
+	ErrNotEditable      = errors.New("message cannot be edited")
+	ErrSlowMode         = errors.New("slow mode: too soon to post again")
+)
+
+// MaxMessageTTL bounds how long an ephemeral message can live, per message or as a
+// room default.
+const MaxMessageTTL = 7 * 24 * time.Hour
+
+// expiredPurgeBatch is how many expired messages one cleanup run deletes.
+const expiredPurgeBatch = 500
+
+// maxClientIDLength matches the width of the messages.client_id column.
+const maxClientIDLength = 64
+
+// maxEmojiLength matches the width of the reactions.emoji column.
+const maxEmojiLength = 32
+
+// previewLength caps how much of a message is quoted in notifications.
+const previewLength = 80
+
 type MessageService struct {
-	messages repositories.MessageRepository
-	users    repositories.UserRepository
+	messages      repositories.MessageRepository
+	users         repositories.UserRepository
+	notifications repositories.NotificationRepository
+	chatrooms     repositories.ChatroomRepository
+}
+
+func NewMessageService(m repositories.MessageRepository, u repositories.UserRepository, n repositories.NotificationRepository, c repositories.ChatroomRepository) *MessageService {
+	return &MessageService{messages: m, users: u, notifications: n, chatrooms: c}
 }
 
-func NewMessageService(m repositories.MessageRepository, u repositories.UserRepository) *MessageService {
-	return &MessageService{messages: m, users: u}
+// SendOptions are the optional parts of a new message.
+type SendOptions struct {
+	ClientID string        // idempotency key; see SendMessage
+	TTL      time.Duration // non-zero makes the message ephemeral; see expiryFor
+	Kind     string        // models.MessageKindText when empty
+	// Attachment is stored with the message; its blob must already be uploaded.
+	Attachment *models.Attachment
+	// Poll is stored with a models.MessageKindPoll message; see PollService.Create.
+	Poll *models.Poll
+}
+
+// SendMessage stores and broadcasts a message. A non-empty ClientID makes the call
+// idempotent: repeating it returns the message stored the first time without
+// broadcasting it again. Muted senders get ErrMuted, those whose role may not send
+// ErrCannotPost, and those posting again too soon in slow mode ErrSlowMode.
+func (s *MessageService) SendMessage(senderID, chatroomID uint, content string, opts SendOptions) (models.Message, string, error) {
+	kind, err := messageKind(opts)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	if err := checkCanPost(s.chatrooms, senderID, chatroomID); err != nil {
+		return models.Message{}, "", err
+	}
+	if err := s.checkSlowMode(senderID, chatroomID, opts.ClientID); err != nil {
+		return models.Message{}, "", err
+	}
+	expiresAt, err := s.expiryFor(chatroomID, opts.TTL)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	msg := models.Message{UserId: senderID, ChatroomID: chatroomID, Content: content, ExpiresAt: expiresAt, Kind: kind, Attachment: opts.Attachment, Poll: opts.Poll}
+	created, err := s.createOnce(&msg, opts.ClientID)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	user, err := s.users.FindByID(senderID)
+	if err != nil {
+		return msg, "", nil
+	}
+	if !created {
+		return msg, user.Name, nil
+	}
+	broadcast(chatroomID, "message", s.withDetails(msg, user.Name))
+	s.notifyMentions(msg, user.Name)
+	return msg, user.Name, nil
 }
 
-func (s *MessageService) SendMessage(senderID, chatroomID uint, content string) (models.Message, string, error) {
-	msg := models.Message{UserId: senderID, ChatroomID: chatroomID, Content: content}
-	if err := s.messages.Create(&msg); err != nil {
+// SendReply posts a reply in the thread of parentID. Replies to a reply are attached to
+// the thread root so threads stay one level deep. opts work as in SendMessage.
+func (s *MessageService) SendReply(senderID, chatroomID, parentID uint, content string, opts SendOptions) (models.Message, string, error) {
+	kind, err := messageKind(opts)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	if kind == models.MessageKindPoll {
+		return models.Message{}, "", ErrInvalidKind // polls belong in the main conversation
+	}
+	if err := checkCanPost(s.chatrooms, senderID, chatroomID); err != nil {
+		return models.Message{}, "", err
+	}
+	if err := s.checkSlowMode(senderID, chatroomID, opts.ClientID); err != nil {
+		return models.Message{}, "", err
+	}
+	parent, err := s.findInChatroom(chatroomID, parentID)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	expiresAt, err := s.expiryFor(chatroomID, opts.TTL)
+	if err != nil {
+		return models.Message{}, "", err
+	}
+	// A reply cannot outlive its thread.
+	if parent.ExpiresAt != nil && (expiresAt == nil || expiresAt.After(*parent.ExpiresAt)) {
+		expiresAt = parent.ExpiresAt
+	}
+	rootID := parent.ID
+	if parent.ParentID != nil {
+		rootID = *parent.ParentID
+	}
+
+	msg := models.Message{UserId: senderID, ChatroomID: chatroomID, ParentID: &rootID, Content: content, ExpiresAt: expiresAt, Kind: kind, Attachment: opts.Attachment}
+	created, err := s.createOnce(&msg, opts.ClientID)
+	if err != nil {
 		return models.Message{}, "", err
 	}
 	user, err := s.users.FindByID(senderID)
+	if err != nil {
+		return msg, "", nil
+	}
+	if !created {
+		return msg, user.Name, nil
+	}
+
+	replies, err := s.messages.CountReplies(rootID)
 	if err == nil {
-		payload := models.MessageWithUser{
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-			Username:  user.Name,
+		broadcast(chatroomID, "thread_reply", models.ThreadReplyPayload{
+			RootID:     rootID,
+			ReplyCount: int(replies),
+			Message:    s.withUser(msg, user.Name),
+		})
+	}
+	mentioned := s.notifyMentions(msg, user.Name)
+	s.notifyThreadParticipants(msg, rootID, user.Name, mentioned)
+	return msg, user.Name, nil
+}
+
+// expiryFor works out when a new message in the room expires, nil when it is kept.
+// The room default applies when ttl is zero and also caps longer requests, so members
+// cannot opt out of a room's retention policy.
+func (s *MessageService) expiryFor(chatroomID uint, ttl time.Duration) (*time.Time, error) {
+	if ttl < 0 || ttl > MaxMessageTTL {
+		return nil, ErrInvalidTTL
+	}
+	room, err := s.chatrooms.FindByID(chatroomID)
+	if err != nil {
+		return nil, err
+	}
+	if roomTTL := time.Duration(room.MessageTTL) * time.Second; roomTTL > 0 && (ttl == 0 || ttl > roomTTL) {
+		ttl = roomTTL
+	}
+	if ttl == 0 {
+		return nil, nil
+	}
+	expiresAt := time.Now().Add(ttl)
+	return &expiresAt, nil
+}
+
+// checkSlowMode returns ErrSlowMode when the room is in slow mode and the sender posted
+// less than its interval ago. Members whose role may manage the room are exempt, and a
+// retried send is let through so createOnce can hand back the stored message.
+func (s *MessageService) checkSlowMode(senderID, chatroomID uint, clientID string) error {
+	room, err := s.chatrooms.FindByID(chatroomID)
+	if err != nil || room.SlowMode == 0 {
+		return err
+	}
+	if clientID != "" {
+		if _, err := s.messages.FindByClientID(senderID, chatroomID, clientID); err == nil {
+			return nil
 		}
-		if data, err := json.Marshal(payload); err == nil {
-			ws.BroadcastMessage(chatroomID, ws.WsEvent{
-				Type: "message",
-				Data: json.RawMessage(data),
-			})
+	}
+	if uc, err := s.chatrooms.FindUserChatroom(senderID, chatroomID); err == nil {
+		if role, err := s.chatrooms.MemberRole(uc); err == nil && role.Permissions.Has(models.PermManageRoom) {
+			return nil
 		}
 	}
-	return msg, user.Name, nil
+	last, err := s.messages.LastSentAt(senderID, chatroomID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if time.Since(last) < time.Duration(room.SlowMode)*time.Second {
+		return ErrSlowMode
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes ephemeral messages that have expired and tells the
+// rooms they were in. It returns the removed messages with their attachments, whose
+// blobs are left for the caller to delete.
+func (s *MessageService) PurgeExpired(now time.Time) ([]models.Message, error) {
+	purged, err := s.messages.PurgeExpired(now, expiredPurgeBatch)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range purged {
+		broadcast(msg.ChatroomID, "message_expired", models.MessageExpiredPayload{MessageID: msg.ID, ParentID: msg.ParentID})
+	}
+	return purged, nil
+}
+
+// createOnce stores msg unless its sender already stored a message in the room under
+// clientID, in which case msg is replaced by the stored one and created is false.
+func (s *MessageService) createOnce(msg *models.Message, clientID string) (created bool, err error) {
+	if clientID == "" {
+		return true, s.messages.Create(msg)
+	}
+	if len(clientID) > maxClientIDLength {
+		return false, ErrInvalidClientID
+	}
+	if existing, err := s.messages.FindByClientID(msg.UserId, msg.ChatroomID, clientID); err == nil {
+		*msg = *existing
+		return false, nil
+	}
+	msg.ClientID = &clientID
+	if err := s.messages.Create(msg); err != nil {
+		// A concurrent retry may have won the unique index; hand back its message.
+		if existing, findErr := s.messages.FindByClientID(msg.UserId, msg.ChatroomID, clientID); findErr == nil {
+			*msg = *existing
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetThread returns a root message and its replies.
+func (s *MessageService) GetThread(chatroomID, rootID uint) (models.Thread, error) {
+	if _, err := s.findInChatroom(chatroomID, rootID); err != nil {
+		return models.Thread{}, err
+	}
+	root, err := s.messages.FindWithUser(rootID)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if root.ParentID != nil {
+		// Asked for a reply; hand back the whole thread it belongs to.
+		return s.GetThread(chatroomID, *root.ParentID)
+	}
+	replies, err := s.messages.ListReplies(rootID)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if replies == nil {
+		replies = []models.MessageWithUser{}
+	}
+	return models.Thread{Root: *root, Replies: replies}, nil
+}
+
+// notifyThreadParticipants lets everyone who wrote in the thread, apart from the sender
+// and those already notified of a mention, know that a new reply arrived. Failures are
+// logged and never block the reply.
+func (s *MessageService) notifyThreadParticipants(reply models.Message, rootID uint, senderName string, skip map[uint]bool) {
+	participants, err := s.messages.ThreadParticipantIDs(rootID)
+	if err != nil {
+		log.Printf("thread participants lookup failed: %v", err)
+		return
+	}
+
+	content := fmt.Sprintf("%s replied in a thread you're part of: %s", senderName, preview(reply.Content))
+
+	var notifications []models.Notification
+	for _, userID := range participants {
+		if userID == reply.UserId || skip[userID] {
+			continue
+		}
+		n := newNotification(userID, reply.ChatroomID, reply.UserId, "thread_reply", content)
+		n.MessageId = &reply.ID
+		notifications = append(notifications, n)
+	}
+	if err := s.notifications.CreateMany(notifications); err != nil {
+		log.Printf("thread reply notifications failed: %v", err)
+	}
+}
+
+// notifyMentions creates a "mention" notification for every room member named in the
+// message. @admins reaches the members whose role holds a moderator permission; @here
+// reaches everyone but only when such a member sends it. It returns the ids of the
+// users that were notified.
+func (s *MessageService) notifyMentions(msg models.Message, senderName string) map[uint]bool {
+	notified := map[uint]bool{}
+	names := parseMentions(msg.Content)
+	if len(names) == 0 {
+		return notified
+	}
+
+	members, err := s.chatrooms.ListJoinedMembers(msg.ChatroomID)
+	if err != nil {
+		log.Printf("mention member lookup failed: %v", err)
+		return notified
+	}
+	// Roles are only looked up for group mentions, once per role.
+	roles := map[string]models.Role{}
+	moderates := func(member models.UserChatroom) bool {
+		key := member.BuiltInRoleName()
+		if key == "" {
+			key = fmt.Sprint(*member.RoleID)
+		}
+		role, ok := roles[key]
+		if !ok {
+			if role, err = s.chatrooms.MemberRole(&member); err != nil {
+				log.Printf("mention role lookup failed: %v", err)
+			}
+			roles[key] = role
+		}
+		return role.Permissions.Moderates()
+	}
+	var sender *models.UserChatroom
+	for i := range members {
+		if members[i].UserID == msg.UserId {
+			sender = &members[i]
+		}
+	}
+
+	for _, name := range names {
+		for _, member := range members {
+			if member.UserID == msg.UserId {
+				continue
+			}
+			var matches bool
+			switch name {
+			case mentionHere:
+				matches = sender != nil && moderates(*sender)
+			case mentionAdmins:
+				matches = moderates(member)
+			default:
+				matches = strings.EqualFold(member.Name, name)
+			}
+			if matches {
+				notified[member.UserID] = true
+			}
+		}
+	}
+
+	content := fmt.Sprintf("%s mentioned you: %s", senderName, preview(msg.Content))
+	notifications := make([]models.Notification, 0, len(notified))
+	for userID := range notified {
+		n := newNotification(userID, msg.ChatroomID, msg.UserId, "mention", content)
+		n.MessageId = &msg.ID
+		notifications = append(notifications, n)
+	}
+	if err := s.notifications.CreateMany(notifications); err != nil {
+		log.Printf("mention notifications failed: %v", err)
+	}
+	return notified
+}
+
+// preview shortens message content for use in notification text.
+func preview(content string) string {
+	if runes := []rune(content); len(runes) > previewLength {
+		return string(runes[:previewLength]) + "..."
+	}
+	return content
+}
+
+// GetMessages returns a page of a chatroom's history; see repositories.MessageQuery.
+func (s *MessageService) GetMessages(chatroomID uint, query repositories.MessageQuery) (models.MessagePage, error) {
+	return s.messages.ListByChatroom(chatroomID, query)
+}
+
+// EditMessage replaces the content of a message written by editorID, keeping the
+// previous version in the edit history, and notifies the room.
+func (s *MessageService) EditMessage(editorID, chatroomID, messageID uint, content string) (models.MessageWithUser, error) {
+	msg, err := s.findInChatroom(chatroomID, messageID)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	if msg.UserId != editorID {
+		return models.MessageWithUser{}, ErrNotMessageAuthor
+	}
+	if msg.Kind == models.MessageKindPoll {
+		// Votes were cast on the question as asked.
+		return models.MessageWithUser{}, ErrNotEditable
+	}
+	// A muted member may not change what the room sees through their old messages either.
+	if err := checkCanPost(s.chatrooms, editorID, chatroomID); err != nil {
+		return models.MessageWithUser{}, err
+	}
+
+	changed := msg.Content != content
+	if changed {
+		edit := models.MessageEdit{MessageID: msg.ID, EditorID: editorID, Content: msg.Content}
+		now := time.Now()
+		msg.Content = content
+		msg.EditedAt = &now
+		if err := s.messages.SaveEdit(msg, &edit); err != nil {
+			return models.MessageWithUser{}, err
+		}
+	}
+
+	// Reload the message so the payload carries its attachment and poll like a listed one.
+	payload, err := s.messages.FindWithUser(msg.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.MessageWithUser{}, ErrMessageNotFound
+	}
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	if changed {
+		broadcast(chatroomID, "message_edited", *payload)
+	}
+	return *payload, nil
+}
+
+// DeleteMessage soft deletes a message. Authors can remove their own messages and
+// members whose role may delete messages can remove anyone's; the room is told so
+// clients can show a tombstone.
+func (s *MessageService) DeleteMessage(actorID, chatroomID, messageID uint, canDeleteAny bool) (models.MessageWithUser, error) {
+	msg, err := s.findInChatroom(chatroomID, messageID)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	if msg.UserId != actorID && !canDeleteAny {
+		return models.MessageWithUser{}, ErrNotMessageAuthor
+	}
+
+	actor, err := s.users.FindByID(actorID)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	author := actor
+	if msg.UserId != actorID {
+		if author, err = s.users.FindByID(msg.UserId); err != nil {
+			return models.MessageWithUser{}, err
+		}
+	}
+
+	now := time.Now()
+	msg.DeletedAt = &now
+	msg.DeletedBy = &actorID
+	if err := s.messages.SoftDelete(msg); err != nil {
+		return models.MessageWithUser{}, err
+	}
+
+	payload := s.withUser(*msg, author.Name)
+	payload.DeletedBy = actor.Name
+	broadcast(chatroomID, "message_deleted", payload)
+	return payload, nil
+}
+
+// PinMessage pins a message for the whole room. Only roles with the pin permission may
+// pin; pinning an already pinned message returns the existing pin state without a
+// broadcast.
+func (s *MessageService) PinMessage(actorID, chatroomID, messageID uint, canPin bool) (models.PinnedMessage, error) {
+	if !canPin {
+		return models.PinnedMessage{}, ErrNoPermission
+	}
+	if _, err := s.findInChatroom(chatroomID, messageID); err != nil {
+		return models.PinnedMessage{}, err
+	}
+
+	pin := models.Pin{ChatroomID: chatroomID, MessageID: messageID, PinnedBy: actorID}
+	created, err := s.messages.AddPin(&pin)
+	if err != nil {
+		return models.PinnedMessage{}, err
+	}
+	if !created {
+		stored, err := s.messages.FindPin(messageID)
+		if err != nil {
+			return models.PinnedMessage{}, err
+		}
+		pin = *stored
+	}
+	pinner, err := s.users.FindByID(pin.PinnedBy)
+	if err != nil {
+		return models.PinnedMessage{}, err
+	}
+	message, err := s.messages.FindWithUser(messageID)
+	if err != nil {
+		return models.PinnedMessage{}, err
+	}
+
+	payload := models.PinnedMessage{Message: *message, PinnedBy: pinner.Name, PinnedAt: pin.CreatedAt}
+	if created {
+		broadcast(chatroomID, "message_pinned", payload)
+	}
+	return payload, nil
+}
+
+// UnpinMessage removes a message from the room's pins. The same roles may unpin as pin.
+func (s *MessageService) UnpinMessage(chatroomID, messageID uint, canPin bool) error {
+	if !canPin {
+		return ErrNoPermission
+	}
+	removed, err := s.messages.RemovePin(chatroomID, messageID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotPinned
+	}
+	broadcast(chatroomID, "message_unpinned", models.PinRemovedPayload{MessageID: messageID})
+	return nil
+}
+
+// GetPins lists the room's pinned messages, newest pin first.
+func (s *MessageService) GetPins(chatroomID uint) ([]models.PinnedMessage, error) {
+	pins, err := s.messages.ListPins(chatroomID)
+	if pins == nil {
+		pins = []models.PinnedMessage{}
+	}
+	return pins, err
+}
+
+// AddReaction records userID's emoji reaction on a message and broadcasts the new counts.
+func (s *MessageService) AddReaction(userID, chatroomID, messageID uint, emoji string) ([]models.ReactionCount, error) {
+	return s.updateReaction(userID, chatroomID, messageID, emoji, true)
+}
+
+// RemoveReaction withdraws userID's emoji reaction and broadcasts the new counts.
+func (s *MessageService) RemoveReaction(userID, chatroomID, messageID uint, emoji string) ([]models.ReactionCount, error) {
+	return s.updateReaction(userID, chatroomID, messageID, emoji, false)
+}
+
+func (s *MessageService) updateReaction(userID, chatroomID, messageID uint, emoji string, add bool) ([]models.ReactionCount, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" || len(emoji) > maxEmojiLength || strings.ContainsAny(emoji, " \t\n") {
+		return nil, ErrInvalidEmoji
+	}
+	if _, err := s.findInChatroom(chatroomID, messageID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if add {
+		err = s.messages.AddReaction(&models.Reaction{MessageID: messageID, UserID: userID, Emoji: emoji})
+	} else {
+		err = s.messages.RemoveReaction(messageID, userID, emoji)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.messages.ReactionCounts([]uint{messageID})
+	if err != nil {
+		return nil, err
+	}
+	reactions := counts[messageID]
+	if reactions == nil {
+		reactions = []models.ReactionCount{}
+	}
+	broadcast(chatroomID, "reaction_updated", models.ReactionUpdatePayload{MessageID: messageID, Reactions: reactions})
+	return reactions, nil
+}
+
+// findInChatroom loads a message and makes sure it belongs to the given chatroom
+// and has not been deleted.
+func (s *MessageService) findInChatroom(chatroomID, messageID uint) (*models.Message, error) {
+	msg, err := s.messages.FindByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if msg.ChatroomID != chatroomID || msg.DeletedAt != nil {
+		return nil, ErrMessageNotFound
+	}
+	return msg, nil
+}
+
+func (s *MessageService) withUser(msg models.Message, username string) models.MessageWithUser {
+	payload := models.MessageWithUser{
+		ID:         msg.ID,
+		Content:    msg.Content,
+		CreatedAt:  msg.CreatedAt,
+		EditedAt:   msg.EditedAt,
+		DeletedAt:  msg.DeletedAt,
+		ParentID:   msg.ParentID,
+		ExpiresAt:  msg.ExpiresAt,
+		Kind:       msg.Kind,
+		Attachment: msg.Attachment,
+		Username:   username,
+	}
+	if msg.ClientID != nil {
+		payload.ClientID = *msg.ClientID
+	}
+	if msg.DeletedAt != nil {
+		payload.Content = ""
+		payload.Attachment = nil
+	}
+	return payload
+}
+
+// withDetails is withUser plus the message's poll results, for a freshly stored message.
+func (s *MessageService) withDetails(msg models.Message, username string) models.MessageWithUser {
+	payload := s.withUser(msg, username)
+	if msg.Kind == models.MessageKindPoll && msg.DeletedAt == nil {
+		if polls, err := s.messages.Polls([]uint{msg.ID}); err == nil {
+			payload.Poll = polls[msg.ID]
+		}
+	}
+	return payload
+}
+
+// messageKind validates a requested message kind, defaulting to plain text. Poll
+// messages must come with their poll.
+func messageKind(opts SendOptions) (string, error) {
+	switch kind := opts.Kind; {
+	case kind == "" && opts.Poll == nil:
+		return models.MessageKindText, nil
+	case (kind == models.MessageKindText || kind == models.MessageKindAction) && opts.Poll == nil:
+		return kind, nil
+	case kind == models.MessageKindPoll && opts.Poll != nil:
+		return kind, nil
+	}
+	return "", ErrInvalidKind
+}
+
+// broadcast marshals the payload and fans it out to everyone connected to the room.
+func broadcast(chatroomID uint, eventType string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	ws.BroadcastMessage(chatroomID, ws.WsEvent{
+		Type: eventType,
+		Data: json.RawMessage(data),
+	})
 }
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// editStore holds one message with an attachment; only what EditMessage reads is served.
+type editStore struct {
+	repositories.MessageRepository
+	message *models.Message
+	saved   int
+}
+
+func (r *editStore) FindByID(id uint) (*models.Message, error) {
+	if id != r.message.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *r.message
+	return &copied, nil
+}
+
+func (r *editStore) SaveEdit(message *models.Message, edit *models.MessageEdit) error {
+	r.saved++
+	r.message.Content, r.message.EditedAt = message.Content, message.EditedAt
+	return nil
+}
+
+func (r *editStore) FindWithUser(id uint) (*models.MessageWithUser, error) {
+	return &models.MessageWithUser{
+		ID:         r.message.ID,
+		Content:    r.message.Content,
+		EditedAt:   r.message.EditedAt,
+		Username:   "alice",
+		Attachment: &models.Attachment{MessageID: r.message.ID, Name: "plan.pdf"},
+	}, nil
+}
+
+// noMembership lets everyone post, as for a member without a mute or a role.
+type noMembership struct {
+	repositories.ChatroomRepository
+}
+
+func (noMembership) FindUserChatroom(userID, chatroomID any) (*models.UserChatroom, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestEditMessageKeepsAttachment(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantSaved int
+	}{
+		{name: "new caption", content: "the plan, v2", wantSaved: 1},
+		{name: "unchanged caption", content: "the plan", wantSaved: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &editStore{message: &models.Message{ID: 7, ChatroomID: 3, UserId: 1, Content: "the plan"}}
+			s := NewMessageService(store, nil, nil, noMembership{})
+			got, err := s.EditMessage(1, 3, 7, tt.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if store.saved != tt.wantSaved {
+				t.Errorf("saved %d edits, want %d", store.saved, tt.wantSaved)
+			}
+			if got.Content != tt.content || got.Attachment == nil || got.Attachment.Name != "plan.pdf" {
+				t.Errorf("EditMessage = %+v, want content %q with its attachment", got, tt.content)
+			}
+		})
+	}
+}
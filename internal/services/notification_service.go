@@ -23,8 +23,26 @@ func (s *NotificationService) GetUserNotifications(userID uint) (models.Notifica
 	return resp, nil
 }
 
+// notificationRetention is how long notifications are kept before the cleanup job removes them.
+const notificationRetention = 21 * 24 * time.Hour // 3 weeks
+
+// newNotification builds a notification that expires together with the cleanup window.
+func newNotification(userID, chatroomID, senderID uint, kind, content string) models.Notification {
+	now := time.Now()
+	return models.Notification{
+		UserId:     userID,
+		ChatroomId: chatroomID,
+		Type:       kind,
+		SenderId:   senderID,
+		Content:    content,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ExpiresAt:  now.Add(notificationRetention),
+	}
+}
+
 func RemoveOldNotifications() (int64, error) {
-	threshold := time.Now().AddDate(0, 0, -21) // older than 3 weeks
+	threshold := time.Now().Add(-notificationRetention)
 
 	result := config.DB.
 		Where("created_at < ?", threshold).
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPoll       = errors.New("invalid poll")
+	ErrPollNotFound      = errors.New("poll not found")
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrInvalidPollOption = errors.New("invalid poll option")
+)
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 10
+	// MaxPollDuration bounds how far ahead a poll's close time can be.
+	MaxPollDuration = 30 * 24 * time.Hour
+)
+
+// maxPollQuestionLength and maxPollOptionLength are in characters; the latter matches
+// the width of the poll_options.text column.
+const (
+	maxPollQuestionLength = 300
+	maxPollOptionLength   = 100
+)
+
+// pollCloseBatch is how many due polls one cleanup run closes.
+const pollCloseBatch = 100
+
+type PollService struct {
+	polls    repositories.PollRepository
+	messages *MessageService
+}
+
+func NewPollService(p repositories.PollRepository, messages *MessageService) *PollService {
+	return &PollService{polls: p, messages: messages}
+}
+
+// Create posts a poll to the room as a message asking the question. req.ClientID makes
+// the call idempotent like SendMessage.
+func (s *PollService) Create(senderID, chatroomID uint, req models.CreatePollRequest) (models.MessageWithUser, error) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" || utf8.RuneCountInString(question) > maxPollQuestionLength {
+		return models.MessageWithUser{}, ErrInvalidPoll
+	}
+	if len(req.Options) < MinPollOptions || len(req.Options) > MaxPollOptions {
+		return models.MessageWithUser{}, ErrInvalidPoll
+	}
+	poll := &models.Poll{ChatroomID: chatroomID, MultiChoice: req.MultiChoice, Anonymous: req.Anonymous}
+	seen := make(map[string]bool)
+	for i, text := range req.Options {
+		text = strings.TrimSpace(text)
+		key := strings.ToLower(text)
+		if text == "" || utf8.RuneCountInString(text) > maxPollOptionLength || seen[key] {
+			return models.MessageWithUser{}, ErrInvalidPoll
+		}
+		seen[key] = true
+		poll.Options = append(poll.Options, models.PollOption{Position: i, Text: text})
+	}
+	if req.ClosesAt != nil {
+		if until := time.Until(*req.ClosesAt); until <= 0 || until > MaxPollDuration {
+			return models.MessageWithUser{}, ErrInvalidPoll
+		}
+		closesAt := req.ClosesAt.UTC()
+		poll.ClosesAt = &closesAt
+	}
+
+	msg, sender, err := s.messages.SendMessage(senderID, chatroomID, question, SendOptions{
+		ClientID: req.ClientID,
+		Kind:     models.MessageKindPoll,
+		Poll:     poll,
+	})
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	// A repeated request hands back the stored message, without its poll loaded.
+	return s.messages.withDetails(msg, sender), nil
+}
+
+// Vote replaces the user's votes in the poll of a message with optionIDs; none
+// withdraws them. Single-choice polls take at most one option. The room gets the new
+// tallies as "poll_updated", and the caller gets them with MyVotes set.
+func (s *PollService) Vote(userID, chatroomID, messageID uint, optionIDs []uint) (models.PollResults, error) {
+	poll, err := s.find(chatroomID, messageID)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	if isClosed(poll, time.Now()) {
+		return models.PollResults{}, ErrPollClosed
+	}
+	picked := slices.Clone(optionIDs)
+	slices.Sort(picked)
+	picked = slices.Compact(picked)
+	if !poll.MultiChoice && len(picked) > 1 {
+		return models.PollResults{}, ErrInvalidPollOption
+	}
+	for _, id := range picked {
+		if !slices.ContainsFunc(poll.Options, func(o models.PollOption) bool { return o.ID == id }) {
+			return models.PollResults{}, ErrInvalidPollOption
+		}
+	}
+
+	if err := s.polls.SetVotes(poll.ID, userID, picked); err != nil {
+		return models.PollResults{}, err
+	}
+	results, err := s.broadcastResults(poll)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	results.MyVotes = picked
+	return results, nil
+}
+
+// Close stops a poll taking votes. Its author and members whose role may delete messages
+// may close it; closing a closed poll just returns its results.
+func (s *PollService) Close(actorID, chatroomID, messageID uint, canCloseAny bool) (models.PollResults, error) {
+	msg, err := s.messages.findInChatroom(chatroomID, messageID)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	if msg.UserId != actorID && !canCloseAny {
+		return models.PollResults{}, ErrNotMessageAuthor
+	}
+	poll, err := s.find(chatroomID, messageID)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+
+	at := time.Now()
+	if poll.ClosesAt != nil && poll.ClosesAt.Before(at) {
+		at = *poll.ClosesAt
+	}
+	closed, err := s.polls.Close(poll.ID, at)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	if closed {
+		return s.broadcastResults(poll)
+	}
+	return s.results(poll)
+}
+
+// CloseDue closes the polls whose close time has passed and tells their rooms. Reads
+// already treat such polls as closed; this is what pushes the final tallies out.
+func (s *PollService) CloseDue(now time.Time) (int, error) {
+	due, err := s.polls.DueToClose(now, pollCloseBatch)
+	if err != nil {
+		return 0, err
+	}
+	closed := 0
+	for i := range due {
+		ok, err := s.polls.Close(due[i].ID, *due[i].ClosesAt)
+		if err != nil {
+			return closed, err
+		}
+		if !ok {
+			continue // closed by hand meanwhile
+		}
+		closed++
+		if _, err := s.broadcastResults(&due[i]); err != nil {
+			log.Printf("poll %d closed but not announced: %v", due[i].ID, err)
+		}
+	}
+	return closed, nil
+}
+
+// MarkVoted fills in MyVotes on the polls among messages, as seen by userID.
+func (s *PollService) MarkVoted(userID uint, messages []models.MessageWithUser) error {
+	var ids []uint
+	for _, message := range messages {
+		if message.Poll != nil {
+			ids = append(ids, message.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	voted, err := s.polls.VotedOptions(userID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		if messages[i].Poll != nil {
+			messages[i].Poll.MyVotes = voted[messages[i].ID]
+		}
+	}
+	return nil
+}
+
+// find loads the poll of a live message in the room.
+func (s *PollService) find(chatroomID, messageID uint) (*models.Poll, error) {
+	if _, err := s.messages.findInChatroom(chatroomID, messageID); err != nil {
+		return nil, err
+	}
+	poll, err := s.polls.FindByMessageID(messageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPollNotFound
+	}
+	return poll, err
+}
+
+func (s *PollService) results(poll *models.Poll) (models.PollResults, error) {
+	results, err := s.polls.Results([]uint{poll.MessageID})
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	if results[poll.MessageID] == nil {
+		return models.PollResults{}, ErrPollNotFound
+	}
+	return *results[poll.MessageID], nil
+}
+
+func (s *PollService) broadcastResults(poll *models.Poll) (models.PollResults, error) {
+	results, err := s.results(poll)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	broadcast(poll.ChatroomID, "poll_updated", results)
+	return results, nil
+}
+
+func isClosed(poll *models.Poll, now time.Time) bool {
+	return poll.ClosedAt != nil || (poll.ClosesAt != nil && !now.Before(*poll.ClosesAt))
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+	"github.com/Wal-20/cli-chat-app/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrBuiltInRole  = errors.New("built-in role cannot be changed that way")
+	ErrRoleTooHigh  = errors.New("role ranks too high")
+)
+
+// roleNamePattern is what a custom role may be called; names are stored lower-cased.
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// RoleInput holds the fields of a role to create or change; nil fields are left alone,
+// or take their defaults on create.
+type RoleInput struct {
+	Name        *string             `json:"name"`
+	Permissions *models.Permissions `json:"permissions"`
+	Rank        *uint               `json:"rank"`
+}
+
+// RoleService manages a room's roles. Changing roles takes the manage_roles permission,
+// and a member can only create, change or delete roles ranked below their own, with
+// permissions they hold themselves. Every change is broadcast as "roles_updated".
+type RoleService struct {
+	chatrooms repositories.ChatroomRepository
+}
+
+func NewRoleService(c repositories.ChatroomRepository) *RoleService {
+	return &RoleService{chatrooms: c}
+}
+
+// List returns the room's roles, highest rank first.
+func (s *RoleService) List(chatroomID uint) ([]models.Role, error) {
+	return s.chatrooms.ListRoles(chatroomID)
+}
+
+// Create adds a custom role. It can send by default and ranks just above members.
+func (s *RoleService) Create(actorID, chatroomID uint, in RoleInput) (*models.Role, error) {
+	_, actorRole, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	if in.Name == nil {
+		return nil, ErrInvalidRole
+	}
+	role := models.Role{ChatroomID: chatroomID, Permissions: models.PermSend, Rank: models.MemberRank + 1}
+	if err := s.apply(&role, actorRole, in); err != nil {
+		return nil, err
+	}
+	if err := s.chatrooms.CreateRole(&role); err != nil {
+		return nil, err
+	}
+	s.changed(chatroomID)
+	return &role, nil
+}
+
+// Update changes a role. The built-in admin and member roles keep their name and rank,
+// and the owner role cannot be changed at all.
+func (s *RoleService) Update(actorID, chatroomID, roleID uint, in RoleInput) (*models.Role, error) {
+	_, actorRole, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.find(chatroomID, roleID, actorRole)
+	if err != nil {
+		return nil, err
+	}
+	if role.BuiltIn && (role.Name == models.RoleOwner ||
+		in.Name != nil && *in.Name != role.Name || in.Rank != nil && *in.Rank != role.Rank) {
+		return nil, ErrBuiltInRole
+	}
+	if err := s.apply(role, actorRole, in); err != nil {
+		return nil, err
+	}
+	if err := s.chatrooms.SaveRole(role); err != nil {
+		return nil, err
+	}
+	s.changed(chatroomID)
+	return role, nil
+}
+
+// Delete removes a custom role; the members holding it fall back to the member role.
+func (s *RoleService) Delete(actorID, chatroomID, roleID uint) error {
+	_, actorRole, err := actingMember(s.chatrooms, actorID, chatroomID, models.PermManageRoles)
+	if err != nil {
+		return err
+	}
+	role, err := s.find(chatroomID, roleID, actorRole)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return ErrBuiltInRole
+	}
+	if err := s.chatrooms.DeleteRole(role); err != nil {
+		return err
+	}
+	s.changed(chatroomID)
+	return nil
+}
+
+// find loads a role the actor, holding actorRole, ranks above.
+func (s *RoleService) find(chatroomID, roleID uint, actorRole models.Role) (*models.Role, error) {
+	role, err := s.chatrooms.FindRole(chatroomID, roleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if role.Rank >= actorRole.Rank {
+		return nil, ErrRoleTooHigh
+	}
+	return role, nil
+}
+
+// apply validates in against the actor's role and copies it onto role.
+func (s *RoleService) apply(role *models.Role, actorRole models.Role, in RoleInput) error {
+	if in.Name != nil && (!role.BuiltIn || *in.Name != role.Name) {
+		name := strings.ToLower(strings.TrimSpace(*in.Name))
+		if !roleNamePattern.MatchString(name) || name == models.RoleOwner || name == models.RoleAdmin || name == models.RoleMember {
+			return ErrInvalidRole
+		}
+		if name != role.Name {
+			_, err := s.chatrooms.FindRoleByName(role.ChatroomID, name)
+			if err == nil {
+				return ErrRoleExists
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		role.Name = name
+	}
+	if in.Rank != nil {
+		role.Rank = *in.Rank
+	}
+	if in.Permissions != nil {
+		role.Permissions = *in.Permissions
+	}
+	if role.Rank >= actorRole.Rank || !actorRole.Permissions.Has(role.Permissions) {
+		return ErrRoleTooHigh
+	}
+	return nil
+}
+
+// changed drops the room's cached permissions and broadcasts its roles.
+func (s *RoleService) changed(chatroomID uint) {
+	utils.ForgetRoomMemberships(chatroomID)
+	roles, err := s.chatrooms.ListRoles(chatroomID)
+	if err != nil {
+		return
+	}
+	broadcast(chatroomID, "roles_updated", models.RolesUpdatedPayload{ChatroomID: chatroomID, Roles: roles})
+}
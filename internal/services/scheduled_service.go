@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+)
+
+var (
+	ErrEmptyMessage        = errors.New("message content cannot be empty")
+	ErrInvalidSendTime     = errors.New("invalid send time")
+	ErrTooManyScheduled    = errors.New("too many scheduled messages")
+	ErrScheduledNotFound   = errors.New("scheduled message not found")
+	errSenderNotRoomMember = errors.New("sender is no longer a member of the chatroom")
+)
+
+const (
+	maxScheduleAhead       = 365 * 24 * time.Hour
+	maxPendingScheduled    = 50  // per user, across rooms
+	scheduledDeliveryBatch = 100 // messages delivered per run of the cron job
+	maxDeliveryAttempts    = 5   // failed deliveries before a message is marked failed
+	firstRetryDelay        = 30 * time.Second
+	maxHoldDelay           = time.Hour // longest a held message waits between checks
+	maxFailureReasonLength = 255       // width of the error column
+)
+
+// ScheduledMessageService stores messages to be posted later and delivers them once due.
+type ScheduledMessageService struct {
+	scheduled repositories.ScheduledMessageRepository
+	messages  *MessageService
+	chatrooms repositories.ChatroomRepository
+}
+
+func NewScheduledMessageService(s repositories.ScheduledMessageRepository, m *MessageService, c repositories.ChatroomRepository) *ScheduledMessageService {
+	return &ScheduledMessageService{scheduled: s, messages: m, chatrooms: c}
+}
+
+// Schedule stores content to be posted by the user in the room at sendAt.
+func (s *ScheduledMessageService) Schedule(userID, chatroomID uint, content string, sendAt time.Time) (models.ScheduledMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return models.ScheduledMessage{}, ErrEmptyMessage
+	}
+	now := time.Now()
+	if !sendAt.After(now) {
+		return models.ScheduledMessage{}, fmt.Errorf("%w: must be in the future", ErrInvalidSendTime)
+	}
+	if sendAt.After(now.Add(maxScheduleAhead)) {
+		return models.ScheduledMessage{}, fmt.Errorf("%w: must be within a year", ErrInvalidSendTime)
+	}
+	pending, err := s.scheduled.CountPending(userID)
+	if err != nil {
+		return models.ScheduledMessage{}, err
+	}
+	if pending >= maxPendingScheduled {
+		return models.ScheduledMessage{}, ErrTooManyScheduled
+	}
+
+	scheduled := models.ScheduledMessage{
+		ChatroomID: chatroomID,
+		UserID:     userID,
+		Content:    content,
+		SendAt:     sendAt.UTC(),
+		Status:     models.ScheduledPending,
+	}
+	if err := s.scheduled.Create(&scheduled); err != nil {
+		return models.ScheduledMessage{}, err
+	}
+	return scheduled, nil
+}
+
+// ListPending returns the user's scheduled messages in the room that have not been sent yet.
+func (s *ScheduledMessageService) ListPending(userID, chatroomID uint) ([]models.ScheduledMessage, error) {
+	return s.scheduled.ListPending(userID, chatroomID)
+}
+
+// Cancel removes one of the user's pending scheduled messages.
+func (s *ScheduledMessageService) Cancel(userID, chatroomID, id uint) error {
+	cancelled, err := s.scheduled.Cancel(id, userID, chatroomID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrScheduledNotFound
+	}
+	return nil
+}
+
+// DeliverDue posts every scheduled message whose time has come. Each is sent with an
+// idempotency key derived from its id, so a message that was posted but not marked as
+// sent is not posted twice on the next run. Messages from users who have left or been
+// banned are marked failed. Other errors put the message back with a doubling delay, so
+// it does not hold up the rest of the queue, until it fails maxDeliveryAttempts times.
+// A message its sender may not post yet, under a timed mute or slow mode, is held until
+// they may; one they may never post, under a mute without end or a role without send,
+// is marked failed.
+func (s *ScheduledMessageService) DeliverDue(now time.Time) (sent, failed int, err error) {
+	due, err := s.scheduled.Due(now, scheduledDeliveryBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, scheduled := range due {
+		uc, err := s.chatrooms.FindUserChatroom(scheduled.UserID, scheduled.ChatroomID)
+		if err != nil || !uc.IsJoined || uc.IsBanned {
+			if err := s.scheduled.MarkFailed(scheduled.ID, errSenderNotRoomMember.Error()); err != nil {
+				log.Printf("failed to mark scheduled message %d as failed: %v", scheduled.ID, err)
+			}
+			failed++
+			continue
+		}
+
+		clientID := fmt.Sprintf("scheduled-%d", scheduled.ID)
+		msg, _, err := s.messages.SendMessage(scheduled.UserID, scheduled.ChatroomID, scheduled.Content, SendOptions{ClientID: clientID})
+		if errors.Is(err, ErrMuted) || errors.Is(err, ErrCannotPost) || errors.Is(err, ErrSlowMode) {
+			if s.hold(scheduled, uc, now, err) {
+				failed++
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to deliver scheduled message %d: %v", scheduled.ID, err)
+			if s.retryLater(scheduled, now, err) {
+				failed++
+			}
+			continue
+		}
+		if err := s.scheduled.MarkSent(scheduled.ID, msg.ID, time.Now()); err != nil {
+			log.Printf("failed to mark scheduled message %d as sent: %v", scheduled.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, failed, nil
+}
+
+// retryLater records a failed delivery. It puts the message back until its next retry,
+// or marks it failed, and reports true, once it has used up its attempts.
+func (s *ScheduledMessageService) retryLater(scheduled models.ScheduledMessage, now time.Time, cause error) bool {
+	reason := failureReason(cause)
+	attempts := scheduled.Attempts + 1
+	if attempts >= maxDeliveryAttempts {
+		if err := s.scheduled.MarkFailed(scheduled.ID, reason); err != nil {
+			log.Printf("failed to mark scheduled message %d as failed: %v", scheduled.ID, err)
+		}
+		return true
+	}
+	retryAt := now.Add(firstRetryDelay << (attempts - 1))
+	if err := s.scheduled.Retry(scheduled.ID, attempts, retryAt, reason); err != nil {
+		log.Printf("failed to reschedule scheduled message %d: %v", scheduled.ID, err)
+	}
+	return false
+}
+
+// failureReason is the error as stored with the message, cut to the width of its
+// column without splitting a character.
+func failureReason(cause error) string {
+	reason := []rune(cause.Error())
+	if len(reason) > maxFailureReasonLength {
+		reason = reason[:maxFailureReasonLength]
+	}
+	return string(reason)
+}
+
+// hold puts back a message its sender may not post at the moment, until the mute or
+// slow mode in the way runs out; it is checked at least every maxHoldDelay in case the
+// mute is lifted early. It marks the message failed, and reports true, when the sender
+// cannot post until someone steps in.
+func (s *ScheduledMessageService) hold(scheduled models.ScheduledMessage, uc *models.UserChatroom, now time.Time, cause error) bool {
+	var retryAt time.Time
+	switch {
+	case errors.Is(cause, ErrMuted) && uc.MutedUntil != nil:
+		retryAt = *uc.MutedUntil
+	case errors.Is(cause, ErrSlowMode):
+		room, err := s.chatrooms.FindByID(scheduled.ChatroomID)
+		if err != nil {
+			retryAt = now.Add(firstRetryDelay)
+			break
+		}
+		retryAt = now.Add(time.Duration(room.SlowMode) * time.Second)
+	default:
+		if err := s.scheduled.MarkFailed(scheduled.ID, failureReason(cause)); err != nil {
+			log.Printf("failed to mark scheduled message %d as failed: %v", scheduled.ID, err)
+		}
+		return true
+	}
+	retryAt = minTime(retryAt, now.Add(maxHoldDelay))
+	if err := s.scheduled.Retry(scheduled.ID, scheduled.Attempts, retryAt, failureReason(cause)); err != nil {
+		log.Printf("failed to hold scheduled message %d: %v", scheduled.ID, err)
+	}
+	return false
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		cause error
+		want  string
+	}{
+		{name: "short", cause: errors.New("room deleted"), want: "room deleted"},
+		{name: "cut at the column width", cause: errors.New(strings.Repeat("x", 300)), want: strings.Repeat("x", maxFailureReasonLength)},
+		{name: "multi-byte characters stay whole", cause: errors.New(strings.Repeat("é", 300)), want: strings.Repeat("é", maxFailureReasonLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := failureReason(tt.cause)
+			if got != tt.want || !utf8.ValidString(got) {
+				t.Errorf("failureReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+)
+
+var (
+	ErrEmptySearch   = errors.New("search query is empty")
+	ErrInvalidSearch = errors.New("invalid search query")
+)
+
+const (
+	searchResultLimit    = 30
+	searchCandidatePage  = 100  // matches read from the store at a time
+	searchCandidateLimit = 1000 // newest matches considered for ranking
+	snippetRadius        = 60   // runes of context kept on each side of the best match
+	searchDateLayout     = "2006-01-02"
+)
+
+// parseSearchQuery turns a raw query such as
+//
+//	deploy "release notes" from:alice in:ops after:2026-01-01
+//
+// into a repository filter. Operator values may be quoted to include spaces.
+func parseSearchQuery(raw string) (repositories.SearchFilter, error) {
+	var filter repositories.SearchFilter
+	for _, token := range tokenizeSearch(raw) {
+		if key, value, ok := strings.Cut(token, ":"); ok && value != "" {
+			value = strings.Trim(value, `"`)
+			switch strings.ToLower(key) {
+			case "from":
+				filter.From = strings.TrimPrefix(value, "@")
+				continue
+			case "in":
+				filter.Room = value
+				continue
+			case "before", "after":
+				day, err := time.Parse(searchDateLayout, value)
+				if err != nil {
+					return filter, fmt.Errorf("%w: %s date %q, expected YYYY-MM-DD", ErrInvalidSearch, key, value)
+				}
+				if strings.EqualFold(key, "before") {
+					filter.Before = &day
+				} else {
+					// after: skips the whole day, like before: does
+					end := day.Add(24*time.Hour - time.Nanosecond)
+					filter.After = &end
+				}
+				continue
+			}
+		}
+		if strings.HasPrefix(token, `"`) {
+			if phrase := strings.TrimSpace(strings.Trim(token, `"`)); phrase != "" {
+				filter.Phrases = append(filter.Phrases, phrase)
+			}
+			continue
+		}
+		filter.Terms = append(filter.Terms, token)
+	}
+
+	if len(filter.Terms) == 0 && len(filter.Phrases) == 0 && filter.From == "" && filter.Room == "" &&
+		filter.Before == nil && filter.After == nil {
+		return filter, ErrEmptySearch
+	}
+	return filter, nil
+}
+
+// tokenizeSearch splits on whitespace outside of double quotes, keeping the quotes.
+func tokenizeSearch(raw string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuote := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// Search looks for messages across every room userID has joined and returns them best
+// match first, each with a highlighted snippet. Matches are read newest first, a page
+// at a time, and the newest searchCandidateLimit of them are ranked; a query matching
+// more than that should be narrowed with operators to reach older messages.
+func (s *MessageService) Search(userID uint, raw string) ([]models.SearchHit, error) {
+	filter, err := parseSearchQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = searchCandidatePage
+	var hits []models.SearchHit
+	for len(hits) < searchCandidateLimit {
+		page, err := s.messages.Search(userID, filter)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page {
+			page[i].Score = scoreMatch(page[i].Message.Content, filter)
+		}
+		hits = append(hits, page...)
+		if len(page) < filter.Limit {
+			break
+		}
+		filter.BeforeID = page[len(page)-1].Message.ID
+	}
+
+	// Candidates arrive newest first; a stable sort keeps that order among equal scores.
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > searchResultLimit {
+		hits = hits[:searchResultLimit]
+	}
+	needles := append(append([]string(nil), filter.Phrases...), filter.Terms...)
+	for i := range hits {
+		hits[i].Snippet, hits[i].Highlights = snippet(hits[i].Message.Content, needles)
+	}
+	return hits, nil
+}
+
+// scoreMatch favours exact phrases, repeated terms and terms matching whole words.
+func scoreMatch(content string, filter repositories.SearchFilter) float64 {
+	lower := strings.ToLower(content)
+	score := 0.0
+	for _, phrase := range filter.Phrases {
+		score += 3 * float64(strings.Count(lower, strings.ToLower(phrase)))
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+	for _, term := range filter.Terms {
+		term = strings.ToLower(term)
+		score += float64(strings.Count(lower, term))
+		for _, word := range words {
+			if word == term {
+				score += 0.5
+				break
+			}
+		}
+	}
+	return score
+}
+
+// snippet cuts content down to the text around the first match and reports where
+// every needle occurs in the excerpt.
+func snippet(content string, needles []string) (string, []models.TextSpan) {
+	first := -1
+	for _, needle := range needles {
+		if idx := indexFold(content, needle, 0); idx >= 0 && (first < 0 || idx < first) {
+			first = idx
+		}
+	}
+
+	excerpt := content
+	if first >= 0 {
+		runes := []rune(content)
+		at := len([]rune(content[:first]))
+		start, end := max(at-snippetRadius, 0), min(at+snippetRadius, len(runes))
+		excerpt = string(runes[start:end])
+		if start > 0 {
+			excerpt = "…" + excerpt
+		}
+		if end < len(runes) {
+			excerpt += "…"
+		}
+	} else if runes := []rune(content); len(runes) > 2*snippetRadius {
+		excerpt = string(runes[:2*snippetRadius]) + "…"
+	}
+
+	var spans []models.TextSpan
+	for _, needle := range needles {
+		for idx := indexFold(excerpt, needle, 0); idx >= 0; idx = indexFold(excerpt, needle, idx+len(needle)) {
+			spans = append(spans, models.TextSpan{Start: idx, End: idx + len(needle)})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return excerpt, mergeSpans(spans)
+}
+
+// indexFold is a case-insensitive strings.Index starting at byte offset from.
+func indexFold(s, substr string, from int) int {
+	if substr == "" {
+		return -1
+	}
+	for i := from; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeSpans joins overlapping spans; spans must be sorted by Start.
+func mergeSpans(spans []models.TextSpan) []models.TextSpan {
+	var merged []models.TextSpan
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, span.End)
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/repositories"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	before := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2026, 1, 2, 0, 0, 0, -1, time.UTC)
+	tests := []struct {
+		name    string
+		raw     string
+		want    repositories.SearchFilter
+		wantErr error
+	}{
+		{
+			name: "terms",
+			raw:  "deploy  staging",
+			want: repositories.SearchFilter{Terms: []string{"deploy", "staging"}},
+		},
+		{
+			name: "phrase and operators",
+			raw:  `deploy "release notes" from:@alice in:ops`,
+			want: repositories.SearchFilter{Terms: []string{"deploy"}, Phrases: []string{"release notes"}, From: "alice", Room: "ops"},
+		},
+		{
+			name: "quoted operator value",
+			raw:  `in:"general chat" hello`,
+			want: repositories.SearchFilter{Terms: []string{"hello"}, Room: "general chat"},
+		},
+		{
+			name: "dates",
+			raw:  "before:2026-03-01 after:2026-01-01",
+			want: repositories.SearchFilter{Before: &before, After: &after},
+		},
+		{
+			name: "operators are case-insensitive",
+			raw:  "FROM:bob",
+			want: repositories.SearchFilter{From: "bob"},
+		},
+		{
+			name: "unknown operator is a term",
+			raw:  "http://example.com",
+			want: repositories.SearchFilter{Terms: []string{"http://example.com"}},
+		},
+		{
+			name: "empty phrase is dropped",
+			raw:  `"" x`,
+			want: repositories.SearchFilter{Terms: []string{"x"}},
+		},
+		{name: "empty", raw: "   ", wantErr: ErrEmptySearch},
+		{name: "only empty phrase", raw: `""`, wantErr: ErrEmptySearch},
+		{name: "bad date", raw: "before:yesterday", wantErr: ErrInvalidSearch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSearchQuery(tt.raw)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		filter  repositories.SearchFilter
+		want    float64
+	}{
+		{name: "no match", content: "hello", filter: repositories.SearchFilter{Terms: []string{"bye"}}, want: 0},
+		{name: "whole word", content: "Deploy now", filter: repositories.SearchFilter{Terms: []string{"deploy"}}, want: 1.5},
+		{name: "inside a word", content: "redeployed", filter: repositories.SearchFilter{Terms: []string{"deploy"}}, want: 1},
+		{name: "repeated term", content: "go go go", filter: repositories.SearchFilter{Terms: []string{"go"}}, want: 3.5},
+		{name: "phrase", content: "the Release Notes are out", filter: repositories.SearchFilter{Phrases: []string{"release notes"}}, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scoreMatch(tt.content, tt.filter); got != tt.want {
+				t.Errorf("scoreMatch(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		needles   []string
+		want      string
+		wantSpans []models.TextSpan
+	}{
+		{
+			name:      "short content kept whole",
+			content:   "Deploy the deploy script",
+			needles:   []string{"deploy"},
+			want:      "Deploy the deploy script",
+			wantSpans: []models.TextSpan{{Start: 0, End: 6}, {Start: 11, End: 17}},
+		},
+		{
+			name:      "overlapping needles merge",
+			content:   "release notes",
+			needles:   []string{"release notes", "notes"},
+			want:      "release notes",
+			wantSpans: []models.TextSpan{{Start: 0, End: 13}},
+		},
+		{
+			name:    "cut around the match",
+			content: strings.Repeat("x", 100) + "needle" + strings.Repeat("x", 100),
+			needles: []string{"needle"},
+			want:    "…" + strings.Repeat("x", snippetRadius) + "needle" + strings.Repeat("x", snippetRadius-len("needle")) + "…",
+			wantSpans: []models.TextSpan{
+				{Start: len("…") + snippetRadius, End: len("…") + snippetRadius + len("needle")},
+			},
+		},
+		{
+			name:    "no match truncates",
+			content: strings.Repeat("x", 3*snippetRadius),
+			needles: []string{"absent"},
+			want:    strings.Repeat("x", 2*snippetRadius) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, spans := snippet(tt.content, tt.needles)
+			if got != tt.want {
+				t.Errorf("snippet = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(spans, tt.wantSpans) {
+				t.Errorf("spans = %v, want %v", spans, tt.wantSpans)
+			}
+		})
+	}
+}
+
+// pagedSearch serves matches newest first, honouring BeforeID and Limit.
+type pagedSearch struct {
+	repositories.MessageRepository
+	matches []models.SearchHit
+}
+
+func (r pagedSearch) Search(userID uint, filter repositories.SearchFilter) ([]models.SearchHit, error) {
+	var page []models.SearchHit
+	for _, hit := range r.matches {
+		if (filter.BeforeID == 0 || hit.Message.ID < filter.BeforeID) && len(page) < filter.Limit {
+			page = append(page, hit)
+		}
+	}
+	return page, nil
+}
+
+func TestSearchRanksBeyondFirstPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches int
+		bestID  uint // id of the one message matching the whole word
+		wantTop uint
+	}{
+		{name: "best match on the first page", matches: 50, bestID: 40, wantTop: 40},
+		{name: "best match on a later page", matches: 3 * searchCandidatePage, bestID: 5, wantTop: 5},
+		{name: "best match past the candidate limit", matches: searchCandidateLimit + 50, bestID: 5, wantTop: searchCandidateLimit + 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matches []models.SearchHit
+			for id := uint(tt.matches); id > 0; id-- {
+				content := "redeployed"
+				if id == tt.bestID {
+					content = "deploy"
+				}
+				matches = append(matches, models.SearchHit{Message: models.MessageWithUser{ID: id, Content: content}})
+			}
+			s := NewMessageService(pagedSearch{matches: matches}, nil, nil, nil)
+			hits, err := s.Search(1, "deploy")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(hits) != searchResultLimit || hits[0].Message.ID != tt.wantTop {
+				t.Errorf("got %d hits, first %d; want %d hits, first %d", len(hits), hits[0].Message.ID, searchResultLimit, tt.wantTop)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+// transcriptWriter renders an export. Messages arrive oldest first, replies interleaved
+// with the rest in the order they were sent.
+type transcriptWriter interface {
+	begin(room *models.Chatroom, opts ExportOptions) error
+	message(m models.ExportedMessage) error
+	end() error
+}
+
+func newTranscriptWriter(format ExportFormat, w io.Writer) (transcriptWriter, error) {
+	switch format {
+	case ExportJSONLines:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonLinesTranscript{enc: enc}, nil
+	case ExportMarkdown:
+		return &markdownTranscript{w: w}, nil
+	case ExportHTML:
+		return &htmlTranscript{w: w}, nil
+	}
+	return nil, ErrInvalidExportFormat
+}
+
+// Transcripts give times in UTC so archives read the same wherever they are opened.
+const (
+	transcriptDayLayout  = "Monday, 2 January 2006"
+	transcriptTimeLayout = "15:04 UTC"
+	transcriptFullLayout = "2006-01-02 15:04 UTC"
+)
+
+// exportRange describes the date range of an export for its heading.
+func exportRange(opts ExportOptions) string {
+	switch {
+	case opts.From != nil && opts.To != nil:
+		return fmt.Sprintf("Messages from %s to %s", opts.From.UTC().Format(transcriptFullLayout), opts.To.UTC().Format(transcriptFullLayout))
+	case opts.From != nil:
+		return "Messages since " + opts.From.UTC().Format(transcriptFullLayout)
+	case opts.To != nil:
+		return "Messages before " + opts.To.UTC().Format(transcriptFullLayout)
+	}
+	return "Complete history"
+}
+
+func humanSize(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+}
+
+func pollSummary(poll *models.PollResults) string {
+	parts := []string{fmt.Sprintf("%d voters", poll.Voters)}
+	if poll.MultiChoice {
+		parts = append(parts, "multiple choice")
+	}
+	if poll.Anonymous {
+		parts = append(parts, "anonymous")
+	}
+	if poll.Closed {
+		parts = append(parts, "closed")
+	} else if poll.ClosesAt != nil {
+		parts = append(parts, "closes "+poll.ClosesAt.UTC().Format(transcriptFullLayout))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func reactionSummary(reactions []models.ReactionCount) string {
+	parts := make([]string, len(reactions))
+	for i, reaction := range reactions {
+		parts[i] = fmt.Sprintf("%s %d", reaction.Emoji, reaction.Count)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// jsonLinesTranscript writes one models.ExportedMessage per line.
+type jsonLinesTranscript struct{ enc *json.Encoder }
+
+func (t *jsonLinesTranscript) begin(*models.Chatroom, ExportOptions) error { return nil }
+func (t *jsonLinesTranscript) message(m models.ExportedMessage) error      { return t.enc.Encode(m) }
+func (t *jsonLinesTranscript) end() error                                  { return nil }
+
+// markdownTranscript writes a heading per day and a block per message. Content is
+// quoted, so the markup people typed renders without breaking the transcript's layout.
+type markdownTranscript struct {
+	w   io.Writer
+	day string
+}
+
+func (t *markdownTranscript) begin(room *models.Chatroom, opts ExportOptions) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", room.Title)
+	if room.Topic != "" {
+		fmt.Fprintf(&b, "_%s_\n\n", room.Topic)
+	}
+	fmt.Fprintf(&b, "%s · exported %s\n", exportRange(opts), time.Now().UTC().Format(transcriptFullLayout))
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *markdownTranscript) message(m models.ExportedMessage) error {
+	var b strings.Builder
+	created := m.CreatedAt.UTC()
+	if day := created.Format(transcriptDayLayout); day != t.day {
+		t.day = day
+		fmt.Fprintf(&b, "\n## %s\n", day)
+	}
+
+	fmt.Fprintf(&b, "\n**%s** · %s · #%d", m.Username, created.Format(transcriptTimeLayout), m.ID)
+	if m.ParentID != nil {
+		fmt.Fprintf(&b, " · reply to #%d", *m.ParentID)
+	}
+	if m.EditedAt != nil && m.DeletedAt == nil {
+		fmt.Fprintf(&b, " · edited %s", m.EditedAt.UTC().Format(transcriptFullLayout))
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case m.DeletedAt != nil:
+		fmt.Fprintf(&b, "> _Deleted by %s at %s_\n", m.DeletedBy, m.DeletedAt.UTC().Format(transcriptFullLayout))
+	case m.Kind == models.MessageKindAction:
+		b.WriteString(quoteMarkdown("\\* " + m.Username + " " + m.Content))
+	case m.Content != "":
+		b.WriteString(quoteMarkdown(m.Content))
+	}
+	if m.Attachment != nil {
+		fmt.Fprintf(&b, "\n📎 [%s](%s) (%s, %s)\n", m.Attachment.Name, m.AttachmentURL, humanSize(m.Attachment.Size), m.Attachment.MimeType)
+	}
+	if m.Poll != nil {
+		b.WriteString("\n")
+		for _, option := range m.Poll.Options {
+			fmt.Fprintf(&b, "- %s: %d", option.Text, option.Votes)
+			if len(option.Voters) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(option.Voters, ", "))
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "\n_Poll: %s_\n", pollSummary(m.Poll))
+	}
+	if len(m.Reactions) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", reactionSummary(m.Reactions))
+	}
+	if len(m.Edits) > 0 {
+		b.WriteString("\nEarlier versions:\n\n")
+		for _, edit := range m.Edits {
+			fmt.Fprintf(&b, "- replaced %s: %s\n", edit.CreatedAt.UTC().Format(transcriptFullLayout), strings.ReplaceAll(edit.Content, "\n", " ⏎ "))
+		}
+	}
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *markdownTranscript) end() error { return nil }
+
+func quoteMarkdown(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// htmlTranscript writes a standalone page: styles are inline and nothing is loaded from
+// elsewhere, apart from attachments, which are linked.
+type htmlTranscript struct {
+	w   io.Writer
+	day string
+}
+
+const transcriptCSS = `body{font:15px/1.5 system-ui,sans-serif;max-width:48rem;margin:2rem auto;padding:0 1rem;color:#1f2937}
+header.room p{color:#6b7280;margin:.25rem 0}
+h2{font-size:1rem;color:#6b7280;border-bottom:1px solid #e5e7eb;padding-bottom:.25rem;margin-top:2rem}
+article{margin:1rem 0}article.reply{margin-left:1.5rem;padding-left:.75rem;border-left:2px solid #e5e7eb}
+.meta{color:#6b7280;font-size:.85rem}.meta a{color:inherit}
+.content{white-space:pre-wrap;overflow-wrap:anywhere}.deleted{color:#9ca3af;font-style:italic}
+.attachment,.reactions,.poll{font-size:.9rem}.poll td{padding:0 .5rem 0 0}
+details{font-size:.85rem;color:#6b7280}`
+
+func (t *htmlTranscript) begin(room *models.Chatroom, opts ExportOptions) error {
+	var b strings.Builder
+	title := html.EscapeString(room.Title)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", title, transcriptCSS)
+	fmt.Fprintf(&b, "<header class=\"room\">\n<h1>%s</h1>\n", title)
+	if room.Topic != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(room.Topic))
+	}
+	fmt.Fprintf(&b, "<p>%s · exported %s</p>\n</header>\n<main>\n", html.EscapeString(exportRange(opts)), time.Now().UTC().Format(transcriptFullLayout))
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *htmlTranscript) message(m models.ExportedMessage) error {
+	var b strings.Builder
+	created := m.CreatedAt.UTC()
+	if day := created.Format(transcriptDayLayout); day != t.day {
+		t.day = day
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", day)
+	}
+
+	class := "message"
+	if m.ParentID != nil {
+		class += " reply"
+	}
+	fmt.Fprintf(&b, "<article id=\"m%d\" class=\"%s\">\n<div class=\"meta\"><strong>%s</strong> · <time datetime=\"%s\">%s</time> · <a href=\"#m%d\">#%d</a>",
+		m.ID, class, html.EscapeString(m.Username), created.Format(time.RFC3339), created.Format(transcriptTimeLayout), m.ID, m.ID)
+	if m.ParentID != nil {
+		fmt.Fprintf(&b, " · reply to <a href=\"#m%d\">#%d</a>", *m.ParentID, *m.ParentID)
+	}
+	if m.EditedAt != nil && m.DeletedAt == nil {
+		fmt.Fprintf(&b, " · edited %s", m.EditedAt.UTC().Format(transcriptFullLayout))
+	}
+	b.WriteString("</div>\n")
+
+	switch {
+	case m.DeletedAt != nil:
+		fmt.Fprintf(&b, "<div class=\"content deleted\">Deleted by %s at %s</div>\n", html.EscapeString(m.DeletedBy), m.DeletedAt.UTC().Format(transcriptFullLayout))
+	case m.Kind == models.MessageKindAction:
+		fmt.Fprintf(&b, "<div class=\"content\"><em>* %s %s</em></div>\n", html.EscapeString(m.Username), html.EscapeString(m.Content))
+	case m.Content != "":
+		fmt.Fprintf(&b, "<div class=\"content\">%s</div>\n", html.EscapeString(m.Content))
+	}
+	if a := m.Attachment; a != nil {
+		fmt.Fprintf(&b, "<p class=\"attachment\">📎 <a href=\"%s\">%s</a> · %s · %s</p>\n",
+			html.EscapeString(m.AttachmentURL), html.EscapeString(a.Name), humanSize(a.Size), html.EscapeString(a.MimeType))
+	}
+	if m.Poll != nil {
+		b.WriteString("<table class=\"poll\">\n")
+		for _, option := range m.Poll.Options {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%d</td><td>%s</td></tr>\n",
+				html.EscapeString(option.Text), option.Votes, html.EscapeString(strings.Join(option.Voters, ", ")))
+		}
+		fmt.Fprintf(&b, "</table>\n<p class=\"meta\">Poll: %s</p>\n", html.EscapeString(pollSummary(m.Poll)))
+	}
+	if len(m.Reactions) > 0 {
+		fmt.Fprintf(&b, "<p class=\"reactions\">%s</p>\n", html.EscapeString(reactionSummary(m.Reactions)))
+	}
+	if len(m.Edits) > 0 {
+		summary := "1 earlier version"
+		if len(m.Edits) > 1 {
+			summary = fmt.Sprintf("%d earlier versions", len(m.Edits))
+		}
+		fmt.Fprintf(&b, "<details><summary>%s</summary>\n<ol>\n", summary)
+		for _, edit := range m.Edits {
+			fmt.Fprintf(&b, "<li>replaced <time datetime=\"%s\">%s</time><div class=\"content\">%s</div></li>\n",
+				edit.CreatedAt.UTC().Format(time.RFC3339), edit.CreatedAt.UTC().Format(transcriptFullLayout), html.EscapeString(edit.Content))
+		}
+		b.WriteString("</ol>\n</details>\n")
+	}
+	b.WriteString("</article>\n")
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *htmlTranscript) end() error {
+	_, err := io.WriteString(t.w, "</main>\n</body>\n</html>\n")
+	return err
+}
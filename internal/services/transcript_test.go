@@ -0,0 +1,93 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+func TestHTMLTranscriptEscapes(t *testing.T) {
+	sent := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message models.ExportedMessage
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "content and author",
+			message: models.ExportedMessage{MessageWithUser: models.MessageWithUser{
+				ID: 1, CreatedAt: sent, Username: `<b>eve</b>`, Content: `<script>alert("hi")</script> & more`,
+			}},
+			want:    []string{"<strong>&lt;b&gt;eve&lt;/b&gt;</strong>", "&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt; &amp; more"},
+			notWant: []string{"<script>", "<b>eve"},
+		},
+		{
+			name: "action",
+			message: models.ExportedMessage{MessageWithUser: models.MessageWithUser{
+				ID: 2, CreatedAt: sent, Username: "eve", Kind: models.MessageKindAction, Content: "waves <img src=x>",
+			}},
+			want:    []string{"<em>* eve waves &lt;img src=x&gt;</em>"},
+			notWant: []string{"<img"},
+		},
+		{
+			name: "attachment link",
+			message: models.ExportedMessage{
+				MessageWithUser: models.MessageWithUser{
+					ID: 3, CreatedAt: sent, Username: "eve",
+					Attachment: &models.Attachment{Name: `"><i>x.png`, MimeType: "image/png", Size: 10},
+				},
+				AttachmentURL: `https://example.com/a?x=1&y="2"`,
+			},
+			want:    []string{`href="https://example.com/a?x=1&amp;y=&#34;2&#34;"`, "&#34;&gt;&lt;i&gt;x.png"},
+			notWant: []string{"<i>"},
+		},
+		{
+			name: "earlier versions",
+			message: models.ExportedMessage{
+				MessageWithUser: models.MessageWithUser{ID: 4, CreatedAt: sent, Username: "eve", Content: "fixed"},
+				Edits:           []models.MessageEdit{{Content: "<u>typo</u>", CreatedAt: sent}},
+			},
+			want:    []string{"&lt;u&gt;typo&lt;/u&gt;"},
+			notWant: []string{"<u>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			tw := &htmlTranscript{w: &b}
+			if err := tw.message(tt.message); err != nil {
+				t.Fatalf("message: %v", err)
+			}
+			out := b.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("transcript is missing %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("transcript contains unescaped %q:\n%s", notWant, out)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLTranscriptEscapesRoomHeading(t *testing.T) {
+	var b strings.Builder
+	tw := &htmlTranscript{w: &b}
+	room := &models.Chatroom{Title: "</title><script>x</script>", Topic: "a & b"}
+	if err := tw.begin(room, ExportOptions{Format: ExportHTML}); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	out := b.String()
+	if strings.Contains(out, "<script>") {
+		t.Errorf("room title was not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, "<title>&lt;/title&gt;&lt;script&gt;x&lt;/script&gt;</title>") || !strings.Contains(out, "<p>a &amp; b</p>") {
+		t.Errorf("heading not escaped as expected:\n%s", out)
+	}
+}
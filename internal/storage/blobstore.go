@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+)
+
+// BlobStore keeps opaque file contents under slash-separated keys such as
+// "chatrooms/12/3f9a…". Metadata (names, sizes, types) lives in the database.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns the blob's contents; callers close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// FromEnv builds the store selected by BLOB_STORE: "local" (the default) keeps files
+// under BLOB_DIR, "s3" talks to an S3-compatible service configured by the S3_*
+// variables.
+func FromEnv() (BlobStore, error) {
+	switch strings.ToLower(os.Getenv("BLOB_STORE")) {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "data/blobs"
+		}
+		return NewLocalBlobStore(dir)
+	case "s3":
+		return NewS3BlobStore(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", os.Getenv("BLOB_STORE"))
+	}
+}
+
+// validKey rejects keys that could escape the store's namespace.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
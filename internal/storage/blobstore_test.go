@@ -0,0 +1,27 @@
+package storage
+
+import "testing"
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "attachments/12/ab34", want: true},
+		{key: "file.png", want: true},
+		{key: "a/.hidden", want: true},
+		{key: "", want: false},
+		{key: "/etc/passwd", want: false},
+		{key: "../outside", want: false},
+		{key: "a/../../b", want: false},
+		{key: "a/./b", want: false},
+		{key: "a//b", want: false},
+		{key: "a/", want: false},
+		{key: `a\..\b`, want: false},
+	}
+	for _, tt := range tests {
+		if got := validKey(tt.key); got != tt.want {
+			t.Errorf("validKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3BlobStore(t *testing.T) {
+	const credential = "Credential=minio/20260301/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, "
+	tests := []struct {
+		name     string
+		status   int
+		respBody string
+		call     func(*S3BlobStore) (string, error)
+		wantReq  string // method and path the server must see
+		wantAuth string
+		wantBody string // body the server must receive
+		wantGot  string // body the call must return
+		wantErr  error
+		wantFail bool // some other error
+	}{
+		{
+			name:   "put signs an unsigned payload",
+			status: http.StatusOK,
+			call: func(s *S3BlobStore) (string, error) {
+				return "", s.Put(context.Background(), "attachments/12/ab34", strings.NewReader("hello"), 5, "text/plain")
+			},
+			wantReq:  "PUT /chat/attachments/12/ab34",
+			wantAuth: "AWS4-HMAC-SHA256 " + credential + "Signature=9bbdc0434a1b89e86cc1185d8c8d86ebb0911d85b3bc4831bbf12bce6f8b19af",
+			wantBody: "hello",
+		},
+		{
+			name:     "get returns the object",
+			status:   http.StatusOK,
+			respBody: "hello",
+			call: func(s *S3BlobStore) (string, error) {
+				rc, err := s.Get(context.Background(), "attachments/12/ab34")
+				if err != nil {
+					return "", err
+				}
+				defer rc.Close()
+				b, err := io.ReadAll(rc)
+				return string(b), err
+			},
+			wantReq:  "GET /chat/attachments/12/ab34",
+			wantAuth: "AWS4-HMAC-SHA256 " + credential + "Signature=ec8a3e33d5a842056250339f112fc62669487025f38e6a6e9cfbbe7c399374c8",
+			wantGot:  "hello",
+		},
+		{
+			name:   "get of a missing object",
+			status: http.StatusNotFound,
+			call: func(s *S3BlobStore) (string, error) {
+				_, err := s.Get(context.Background(), "attachments/12/ab34")
+				return "", err
+			},
+			wantReq: "GET /chat/attachments/12/ab34",
+			wantErr: ErrBlobNotFound,
+		},
+		{
+			name:   "delete of a missing object",
+			status: http.StatusNotFound,
+			call: func(s *S3BlobStore) (string, error) {
+				return "", s.Delete(context.Background(), "attachments/12/ab34")
+			},
+			wantReq: "DELETE /chat/attachments/12/ab34",
+		},
+		{
+			name:     "server error",
+			status:   http.StatusInternalServerError,
+			respBody: "<Error>InternalError</Error>",
+			call: func(s *S3BlobStore) (string, error) {
+				_, err := s.Get(context.Background(), "attachments/12/ab34")
+				return "", err
+			},
+			wantReq:  "GET /chat/attachments/12/ab34",
+			wantFail: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq, gotAuth, gotBody, gotType, gotHost string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotReq = r.Method + " " + r.URL.EscapedPath()
+				gotAuth = r.Header.Get("Authorization")
+				gotType = r.Header.Get("Content-Type")
+				gotHost = r.Host
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.respBody)
+			}))
+			defer srv.Close()
+
+			// Dial the test server whatever the URL says, so the signed host stays fixed.
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+				},
+			}}
+			store, err := NewS3BlobStore(S3Config{
+				Endpoint:        "http://minio.local:9000",
+				Bucket:          "chat",
+				AccessKeyID:     "minio",
+				SecretAccessKey: "secret",
+				PathStyle:       true,
+				HTTPClient:      client,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			store.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+			got, err := tt.call(store)
+			switch {
+			case tt.wantFail:
+				if err == nil || errors.Is(err, ErrBlobNotFound) {
+					t.Fatalf("err = %v, want a server error", err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if gotReq != tt.wantReq {
+				t.Errorf("request = %q, want %q", gotReq, tt.wantReq)
+			}
+			if gotHost != "minio.local:9000" {
+				t.Errorf("host = %q, want minio.local:9000", gotHost)
+			}
+			if tt.wantAuth != "" && gotAuth != tt.wantAuth {
+				t.Errorf("authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("server got body %q, want %q", gotBody, tt.wantBody)
+			}
+			if tt.wantBody != "" && gotType != "text/plain" {
+				t.Errorf("content type = %q, want text/plain", gotType)
+			}
+			if got != tt.wantGot {
+				t.Errorf("got %q, want %q", got, tt.wantGot)
+			}
+		})
+	}
+}
+
+func TestS3VirtualHostURL(t *testing.T) {
+	store, err := NewS3BlobStore(S3Config{Region: "eu-west-1", Bucket: "chat", AccessKeyID: "a", SecretAccessKey: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := store.newRequest(context.Background(), http.MethodGet, "attachments/12/a b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://chat.s3.eu-west-1.amazonaws.com/attachments/12/a%20b"; req.URL.String() != want {
+		t.Errorf("url = %q, want %q", req.URL.String(), want)
+	}
+	if _, err := store.newRequest(context.Background(), http.MethodGet, "../outside", nil); !errors.Is(err, ErrInvalidBlobKey) {
+		t.Errorf("err = %v, want ErrInvalidBlobKey", err)
+	}
+}
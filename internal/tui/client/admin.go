@@ -1,6 +1,12 @@
 package client
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
 
 // Admin actions
 func (c *APIClient) InviteUser(chatroomID, userID string) error {
@@ -13,12 +19,126 @@ func (c *APIClient) KickUser(chatroomID, userID string) error {
 	return err
 }
 
-func (c *APIClient) BanUser(chatroomID, userID string) error {
-	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/ban/%s", chatroomID, userID), nil)
+// BanUser bans a member for duration, or until unbanned when it is zero.
+func (c *APIClient) BanUser(chatroomID, userID string, duration time.Duration, reason string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/ban/%s", chatroomID, userID), map[string]any{
+		"duration": uint(duration / time.Second),
+		"reason":   reason,
+	})
+	return err
+}
+
+func (c *APIClient) UnbanUser(chatroomID, userID string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/unban/%s", chatroomID, userID), nil)
 	return err
 }
 
+// GetBans lists the room's banned members, most recently banned first (admins only).
+func (c *APIClient) GetBans(chatroomID uint) ([]models.UserChatroom, error) {
+	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/bans", chatroomID))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Bans []models.UserChatroom `json:"Bans"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Bans, nil
+}
+
 func (c *APIClient) MakeAdmin(chatroomID, userID string) error {
 	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/promote/%s", chatroomID, userID), nil)
 	return err
 }
+
+func (c *APIClient) DemoteUser(chatroomID, userID string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/demote/%s", chatroomID, userID), nil)
+	return err
+}
+
+// OfferOwnership offers the room to a member; it is theirs once they accept.
+func (c *APIClient) OfferOwnership(chatroomID, userID string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/transfer/%s", chatroomID, userID), nil)
+	return err
+}
+
+func (c *APIClient) WithdrawOwnership(chatroomID, userID string) error {
+	_, err := c.delete(fmt.Sprintf("/users/chatrooms/%s/transfer/%s", chatroomID, userID), nil)
+	return err
+}
+
+// AcceptOwnership makes the user the owner of a room they were offered.
+func (c *APIClient) AcceptOwnership(chatroomID uint) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/ownership/accept", chatroomID), nil)
+	return err
+}
+
+func (c *APIClient) DeclineOwnership(chatroomID uint) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/ownership/decline", chatroomID), nil)
+	return err
+}
+
+// MuteUser stops a member posting for duration, or until unmuted when it is zero.
+func (c *APIClient) MuteUser(chatroomID, userID string, duration time.Duration, reason string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/mute/%s", chatroomID, userID), map[string]any{
+		"duration": uint(duration / time.Second),
+		"reason":   reason,
+	})
+	return err
+}
+
+func (c *APIClient) UnmuteUser(chatroomID, userID string) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/unmute/%s", chatroomID, userID), nil)
+	return err
+}
+
+// AssignRole gives a member one of the room's roles (manage_roles only).
+func (c *APIClient) AssignRole(chatroomID, userID string, roleID uint) error {
+	_, err := c.post(fmt.Sprintf("/users/chatrooms/%s/role/%s", chatroomID, userID), map[string]any{"role_id": roleID})
+	return err
+}
+
+// GetRoles lists the room's roles, highest rank first.
+func (c *APIClient) GetRoles(chatroomID uint) ([]models.Role, error) {
+	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/roles", chatroomID))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Roles []models.Role `json:"Roles"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Roles, nil
+}
+
+// CreateRole adds a custom role; nil permissions leave it the server's default.
+func (c *APIClient) CreateRole(chatroomID uint, name string, rank uint, perms *models.Permissions) error {
+	body := map[string]any{"name": name, "rank": rank}
+	if perms != nil {
+		body["permissions"] = *perms
+	}
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/roles", chatroomID), body)
+	return err
+}
+
+// UpdateRole changes a role's rank and permissions; nil leaves either as it is.
+func (c *APIClient) UpdateRole(chatroomID, roleID uint, rank *uint, perms *models.Permissions) error {
+	body := map[string]any{}
+	if rank != nil {
+		body["rank"] = *rank
+	}
+	if perms != nil {
+		body["permissions"] = *perms
+	}
+	_, err := c.patch(fmt.Sprintf("/chatrooms/%v/roles/%v", chatroomID, roleID), body)
+	return err
+}
+
+func (c *APIClient) DeleteRole(chatroomID, roleID uint) error {
+	_, err := c.delete(fmt.Sprintf("/chatrooms/%v/roles/%v", chatroomID, roleID), nil)
+	return err
+}
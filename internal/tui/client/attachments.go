@@ -0,0 +1,108 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+// UploadAttachment posts the file at path to the room as a message, with an optional
+// caption. clientID works as in SendMessage.
+func (c *APIClient) UploadAttachment(chatroomID uint, path, caption, clientID string) (models.MessageWithUser, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return models.MessageWithUser{}, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	res, err := c.postMultipart(fmt.Sprintf("/chatrooms/%v/attachments", chatroomID), func(mw *multipart.Writer) error {
+		if caption != "" {
+			if err := mw.WriteField("content", caption); err != nil {
+				return err
+			}
+		}
+		if clientID != "" {
+			if err := mw.WriteField("client_id", clientID); err != nil {
+				return err
+			}
+		}
+		part, err := mw.CreateFormFile("file", filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(part, f)
+		return err
+	})
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+
+	var message models.MessageWithUser
+	raw, _ := json.Marshal(res["Message"])
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return models.MessageWithUser{}, err
+	}
+	if sender, ok := res["Sender"].(string); ok && message.Username == "" {
+		message.Username = sender
+	}
+	return message, nil
+}
+
+// DownloadAttachment saves an attachment to dest, which must not exist yet, and checks
+// it against the checksum the server reports. A partial or corrupt file is removed.
+func (c *APIClient) DownloadAttachment(chatroomID, attachmentID uint, dest string) (err error) {
+	resp, err := c.getStream(fmt.Sprintf("/chatrooms/%v/attachments/%v", chatroomID, attachmentID))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		return err
+	}
+	if want := resp.Header.Get("X-Content-SHA256"); want != "" && want != hex.EncodeToString(hash.Sum(nil)) {
+		return fmt.Errorf("checksum mismatch for %s", filepath.Base(dest))
+	}
+	return nil
+}
+
+// maxThumbnailSize bounds how much of a thumbnail response is read.
+const maxThumbnailSize = 8 << 20
+
+// Thumbnail fetches the PNG thumbnail of an image attachment.
+func (c *APIClient) Thumbnail(chatroomID, attachmentID uint) ([]byte, error) {
+	resp, err := c.getStream(fmt.Sprintf("/chatrooms/%v/attachments/%v/thumbnail", chatroomID, attachmentID))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, maxThumbnailSize))
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/patrickmn/go-cache"
 
@@ -82,6 +83,65 @@ func (c *APIClient) GetUsersByChatroom(chatroomID uint, active bool) ([]models.U
 	return result.UserChatroom, nil
 }
 
+// GetUnreadCounts returns the unread and mention counts of the user's rooms keyed by chatroom id.
+func (c *APIClient) GetUnreadCounts() (map[uint]models.UnreadCount, error) {
+	resp, err := c.get("/users/chatrooms/unread")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Unread []models.UnreadCount `json:"Unread"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]models.UnreadCount, len(result.Unread))
+	for _, count := range result.Unread {
+		counts[count.ChatroomID] = count
+	}
+	return counts, nil
+}
+
+// MarkChatroomRead moves the user's read marker in the room forward to messageID.
+func (c *APIClient) MarkChatroomRead(chatroomID, messageID uint) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/read", chatroomID), map[string]any{"message_id": messageID})
+	return err
+}
+
+// SetMessageTTL changes how long new messages in the room live for; zero keeps them.
+func (c *APIClient) SetMessageTTL(chatroomID uint, ttl time.Duration) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/message-ttl", chatroomID), map[string]any{"ttl": uint(ttl / time.Second)})
+	if err == nil {
+		c.InvalidateUserChatrooms()
+	}
+	return err
+}
+
+// SetTopic changes the room's topic line; an empty topic clears it.
+func (c *APIClient) SetTopic(chatroomID uint, topic string) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/topic", chatroomID), map[string]any{"topic": topic})
+	if err == nil {
+		c.InvalidateUserChatrooms()
+	}
+	return err
+}
+
+// UpdateChatroom changes the room's settings and returns the room as saved. Keys are
+// those of the API: title, topic, is_public, maxUserCount, slow_mode and message_ttl.
+func (c *APIClient) UpdateChatroom(chatroomID uint, settings map[string]any) (models.Chatroom, error) {
+	res, err := c.patch(fmt.Sprintf("/chatrooms/%v", chatroomID), settings)
+	if err != nil {
+		return models.Chatroom{}, err
+	}
+	c.InvalidateUserChatrooms()
+	b, _ := json.Marshal(res["Chatroom"])
+	var room models.Chatroom
+	if err := json.Unmarshal(b, &room); err != nil || room.Id == 0 {
+		return models.Chatroom{}, fmt.Errorf("unexpected response updating chatroom")
+	}
+	return room, nil
+}
+
 func (c *APIClient) DeleteChatroom(id uint) error {
 	_, err := c.delete(fmt.Sprintf("/chatrooms/%v", id), nil)
 	if err == nil && c.cache != nil {
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+// GetDirectMessages lists the user's direct-message conversations, most recent first.
+func (c *APIClient) GetDirectMessages() ([]models.DirectConversation, error) {
+	body, err := c.get("/dms")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Conversations []models.DirectConversation `json:"Conversations"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return result.Conversations, nil
+}
+
+// OpenDirectMessage returns the conversation with a user, by name or id, creating it
+// if needed.
+func (c *APIClient) OpenDirectMessage(user string) (models.DirectConversation, error) {
+	res, err := c.post(fmt.Sprintf("/dms/%s", url.PathEscape(user)), nil)
+	if err != nil {
+		return models.DirectConversation{}, err
+	}
+	var conv models.DirectConversation
+	raw, _ := json.Marshal(res["Conversation"])
+	if err := json.Unmarshal(raw, &conv); err != nil || conv.Chatroom.Id == 0 {
+		return models.DirectConversation{}, fmt.Errorf("unexpected response opening conversation")
+	}
+	return conv, nil
+}
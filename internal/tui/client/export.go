@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ExportChatroom saves a transcript of the room to dest, which must not exist yet.
+// format is jsonl, md or html; from and to, when not zero, bound the messages it
+// covers (from inclusive, to exclusive). A partial file is removed.
+func (c *APIClient) ExportChatroom(chatroomID uint, format string, from, to time.Time, dest string) (written int64, err error) {
+	query := url.Values{"format": {format}}
+	if !from.IsZero() {
+		query.Set("from", from.UTC().Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.UTC().Format(time.RFC3339))
+	}
+	resp, err := c.getStream(fmt.Sprintf("/chatrooms/%v/export?%s", chatroomID, query.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+	return io.Copy(f, resp.Body)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 )
 
@@ -51,6 +52,33 @@ func (c *APIClient) post(path string, data any) (map[string]any, error) {
 	return result, err
 }
 
+func (c *APIClient) patch(path string, data any) (map[string]any, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PATCH", c.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil && isUnauthorized(err) && c.refreshToken != "" {
+		if rerr := c.refreshTokens(); rerr == nil {
+			req2, _ := http.NewRequest("PATCH", c.baseURL+path, bytes.NewBuffer(jsonData))
+			resp, err = c.doRequest(req2)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	err = json.Unmarshal(resp, &result)
+	return result, err
+}
+
 func (c *APIClient) delete(path string, data any) (map[string]any, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -78,11 +106,82 @@ func (c *APIClient) delete(path string, data any) (map[string]any, error) {
 	return result, err
 }
 
+// postMultipart sends a multipart/form-data body produced by write. The body is streamed,
+// so write is called again when the request is retried after a token refresh.
+func (c *APIClient) postMultipart(path string, write func(*multipart.Writer) error) (map[string]any, error) {
+	send := func() ([]byte, error) {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		mw := multipart.NewWriter(pw)
+		go func() {
+			err := write(mw)
+			if err == nil {
+				err = mw.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+		req, err := http.NewRequest("POST", c.baseURL+path, pr)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return c.doRequest(req)
+	}
+
+	resp, err := send()
+	if err != nil && isUnauthorized(err) && c.refreshToken != "" {
+		if rerr := c.refreshTokens(); rerr == nil {
+			resp, err = send()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	err = json.Unmarshal(resp, &result)
+	return result, err
+}
+
+// getStream performs a GET and hands back the response for the caller to read and
+// close, for bodies too large to buffer.
+func (c *APIClient) getStream(path string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest("GET", c.baseURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.accessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return nil, fmt.Errorf("HTTP error: %s, Response: %s", resp.Status, string(body))
+		}
+		return resp, nil
+	}
+
+	resp, err := send()
+	if err != nil && isUnauthorized(err) && c.refreshToken != "" {
+		if rerr := c.refreshTokens(); rerr == nil {
+			return send()
+		}
+	}
+	return resp, err
+}
+
 func (c *APIClient) doRequest(req *http.Request) ([]byte, error) {
 	if c.accessToken != "" {
 		req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -4,52 +4,196 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Wal-20/cli-chat-app/internal/models"
 )
 
 // Message endpoints
 func (c *APIClient) GetMessages(chatroomID uint) ([]models.MessageWithUser, error) {
-	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/messages", chatroomID))
+	page, err := c.GetMessagesPage(chatroomID, "", 0, 0)
+	return page.Messages, err
+}
+
+// GetMessagesPage fetches the page of messages older than the given cursor (the newest
+// page when before is 0), optionally filtered by a search query. A limit of 0 uses the server default.
+func (c *APIClient) GetMessagesPage(chatroomID uint, search string, before uint, limit int) (models.MessagePage, error) {
+	params := url.Values{}
+	if strings.TrimSpace(search) != "" {
+		params.Set("search", search)
+	}
+	if before != 0 {
+		params.Set("before", strconv.FormatUint(uint64(before), 10))
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := fmt.Sprintf("/chatrooms/%v/messages", chatroomID)
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.get(path)
+	if err != nil {
+		return models.MessagePage{}, err
+	}
+	var page models.MessagePage
+	if err := json.Unmarshal(resp, &page); err != nil {
+		return models.MessagePage{}, err
+	}
+	return page, nil
+}
+
+// SendMessage posts a message. req.ClientID is an optional idempotency key: resending
+// with the same key returns the already stored message instead of posting it again.
+// A non-zero req.TTL (seconds) makes the message expire after that long.
+func (c *APIClient) SendMessage(chatroomID string, req models.SendMessageRequest) (map[string]any, error) {
+	res, err := c.post(fmt.Sprintf("/chatrooms/%s/messages", chatroomID), req)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// EditMessage replaces the content of one of the current user's messages.
+func (c *APIClient) EditMessage(chatroomID, messageID uint, content string) (models.MessageWithUser, error) {
+	data := map[string]any{
+		"content": content,
+	}
+	res, err := c.post(fmt.Sprintf("/chatrooms/%v/messages/%v", chatroomID, messageID), data)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	var message models.MessageWithUser
+	raw, _ := json.Marshal(res["Message"])
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return models.MessageWithUser{}, err
+	}
+	return message, nil
+}
+
+// DeleteMessage removes a message; authors can delete their own and admins anyone's.
+func (c *APIClient) DeleteMessage(chatroomID, messageID uint) error {
+	_, err := c.delete(fmt.Sprintf("/chatrooms/%v/messages/%v", chatroomID, messageID), nil)
+	return err
+}
+
+// GetThread fetches a root message and all of its replies.
+func (c *APIClient) GetThread(chatroomID, messageID uint) (models.Thread, error) {
+	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/messages/%v/thread", chatroomID, messageID))
+	if err != nil {
+		return models.Thread{}, err
+	}
+	var thread models.Thread
+	if err := json.Unmarshal(resp, &thread); err != nil {
+		return models.Thread{}, err
+	}
+	return thread, nil
+}
+
+// SendReply posts a reply in the thread started by parentID.
+func (c *APIClient) SendReply(chatroomID, parentID uint, content string) (map[string]any, error) {
+	data := map[string]any{
+		"content":   content,
+		"parent_id": parentID,
+	}
+	return c.post(fmt.Sprintf("/chatrooms/%v/messages", chatroomID), data)
+}
+
+// AddReaction reacts to a message with the given emoji.
+func (c *APIClient) AddReaction(chatroomID, messageID uint, emoji string) error {
+	data := map[string]any{
+		"emoji": emoji,
+	}
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/messages/%v/reactions", chatroomID, messageID), data)
+	return err
+}
+
+// RemoveReaction withdraws the current user's emoji reaction from a message.
+func (c *APIClient) RemoveReaction(chatroomID, messageID uint, emoji string) error {
+	_, err := c.delete(fmt.Sprintf("/chatrooms/%v/messages/%v/reactions/%s", chatroomID, messageID, url.PathEscape(emoji)), nil)
+	return err
+}
+
+// GetPins lists the room's pinned messages, newest pin first.
+func (c *APIClient) GetPins(chatroomID uint) ([]models.PinnedMessage, error) {
+	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/pins", chatroomID))
 	if err != nil {
 		return nil, err
 	}
 	var result struct {
-		Messages []models.MessageWithUser `json:"Messages"`
+		Pins []models.PinnedMessage `json:"Pins"`
 	}
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, err
 	}
-	return result.Messages, nil
+	return result.Pins, nil
 }
 
-// GetMessagesWithSearch fetches messages with an optional search query.
-func (c *APIClient) GetMessagesWithSearch(chatroomID uint, search string) ([]models.MessageWithUser, error) {
-	path := fmt.Sprintf("/chatrooms/%v/messages", chatroomID)
-	if strings.TrimSpace(search) != "" {
-		path = path + "?search=" + url.QueryEscape(search)
-	}
-	resp, err := c.get(path)
+// PinMessage pins a message for everyone in the room (admins only).
+func (c *APIClient) PinMessage(chatroomID, messageID uint) error {
+	_, err := c.post(fmt.Sprintf("/chatrooms/%v/messages/%v/pin", chatroomID, messageID), nil)
+	return err
+}
+
+// UnpinMessage removes a message from the room's pins (admins only).
+func (c *APIClient) UnpinMessage(chatroomID, messageID uint) error {
+	_, err := c.delete(fmt.Sprintf("/chatrooms/%v/messages/%v/pin", chatroomID, messageID), nil)
+	return err
+}
+
+// SearchAll searches every room the user has joined. The query understands plain
+// terms, "exact phrases" and the from:, in:, before: and after: operators.
+func (c *APIClient) SearchAll(query string) ([]models.SearchHit, error) {
+	resp, err := c.get("/search?" + url.Values{"q": {query}}.Encode())
 	if err != nil {
 		return nil, err
 	}
 	var result struct {
-		Messages []models.MessageWithUser `json:"Messages"`
+		Results []models.SearchHit `json:"Results"`
 	}
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, err
 	}
-	return result.Messages, nil
+	return result.Results, nil
 }
 
-func (c *APIClient) SendMessage(chatroomID, content string) (map[string]any, error) {
+// ScheduleMessage stores a message to be posted in the room at sendAt.
+func (c *APIClient) ScheduleMessage(chatroomID uint, content string, sendAt time.Time) (models.ScheduledMessage, error) {
 	data := map[string]any{
 		"content": content,
+		"send_at": sendAt.Format(time.RFC3339),
+	}
+	res, err := c.post(fmt.Sprintf("/chatrooms/%v/scheduled", chatroomID), data)
+	if err != nil {
+		return models.ScheduledMessage{}, err
+	}
+	var scheduled models.ScheduledMessage
+	raw, _ := json.Marshal(res["Scheduled"])
+	if err := json.Unmarshal(raw, &scheduled); err != nil {
+		return models.ScheduledMessage{}, err
 	}
-	res, err := c.post(fmt.Sprintf("/chatrooms/%s/messages", chatroomID), data)
+	return scheduled, nil
+}
+
+// GetScheduledMessages lists the current user's pending scheduled messages in a room.
+func (c *APIClient) GetScheduledMessages(chatroomID uint) ([]models.ScheduledMessage, error) {
+	resp, err := c.get(fmt.Sprintf("/chatrooms/%v/scheduled", chatroomID))
 	if err != nil {
 		return nil, err
 	}
-	return res, nil
+	var result struct {
+		Scheduled []models.ScheduledMessage `json:"Scheduled"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Scheduled, nil
+}
+
+// CancelScheduledMessage deletes a scheduled message before it is sent.
+func (c *APIClient) CancelScheduledMessage(chatroomID, scheduledID uint) error {
+	_, err := c.delete(fmt.Sprintf("/chatrooms/%v/scheduled/%v", chatroomID, scheduledID), nil)
+	return err
 }
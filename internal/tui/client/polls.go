@@ -0,0 +1,56 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+// CreatePoll posts a poll to the room and returns its message.
+func (c *APIClient) CreatePoll(chatroomID uint, req models.CreatePollRequest) (models.MessageWithUser, error) {
+	res, err := c.post(fmt.Sprintf("/chatrooms/%v/polls", chatroomID), req)
+	if err != nil {
+		return models.MessageWithUser{}, err
+	}
+	var message models.MessageWithUser
+	raw, _ := json.Marshal(res["Message"])
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return models.MessageWithUser{}, err
+	}
+	return message, nil
+}
+
+// VotePoll replaces the current user's votes in the poll of a message; no options
+// withdraws them. The results come back with MyVotes set.
+func (c *APIClient) VotePoll(chatroomID, messageID uint, optionIDs []uint) (models.PollResults, error) {
+	if optionIDs == nil {
+		optionIDs = []uint{}
+	}
+	data := map[string]any{
+		"option_ids": optionIDs,
+	}
+	res, err := c.post(fmt.Sprintf("/chatrooms/%v/messages/%v/poll/votes", chatroomID, messageID), data)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	return parsePollResults(res)
+}
+
+// ClosePoll stops a poll taking votes (its author and admins only).
+func (c *APIClient) ClosePoll(chatroomID, messageID uint) (models.PollResults, error) {
+	res, err := c.post(fmt.Sprintf("/chatrooms/%v/messages/%v/poll/close", chatroomID, messageID), nil)
+	if err != nil {
+		return models.PollResults{}, err
+	}
+	return parsePollResults(res)
+}
+
+func parsePollResults(res map[string]any) (models.PollResults, error) {
+	var results models.PollResults
+	raw, _ := json.Marshal(res["Poll"])
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return models.PollResults{}, err
+	}
+	return results, nil
+}
@@ -0,0 +1,86 @@
+// Package graphics draws real images in terminals that support an inline graphics
+// protocol.
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	_ "image/png"
+	"os"
+	"strings"
+)
+
+type Protocol string
+
+const (
+	None  Protocol = ""
+	Kitty Protocol = "kitty"
+	Sixel Protocol = "sixel"
+)
+
+// Detect picks the protocol of the terminal we run in. CHAT_GRAPHICS=kitty|sixel|none
+// overrides the guess, which is conservative: multiplexers such as tmux swallow the
+// escape sequences unless configured to pass them through.
+func Detect() Protocol {
+	switch strings.ToLower(os.Getenv("CHAT_GRAPHICS")) {
+	case "kitty":
+		return Kitty
+	case "sixel":
+		return Sixel
+	case "none", "off":
+		return None
+	}
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		return None
+	}
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty",
+		program == "ghostty", program == "WezTerm":
+		return Kitty
+	case term == "foot", strings.HasPrefix(term, "foot-"), term == "mlterm", strings.Contains(term, "sixel"):
+		return Sixel
+	}
+	return None
+}
+
+// Encode returns the escape sequence that draws a PNG image at the cursor.
+func Encode(protocol Protocol, pngData []byte) (string, error) {
+	if protocol == Kitty {
+		return kitty(pngData), nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		return "", err
+	}
+	return sixel(img), nil
+}
+
+// ClearKitty removes every image kitty has placed on screen.
+const ClearKitty = "\x1b_Ga=d,q=2\x1b\\"
+
+// kittyChunkSize is the largest base64 payload kitty accepts in one escape sequence.
+const kittyChunkSize = 4096
+
+// kitty transmits and displays a PNG with the kitty graphics protocol. q=2 silences the
+// terminal's replies, which would otherwise arrive as keyboard input.
+func kitty(pngData []byte) string {
+	payload := base64.StdEncoding.EncodeToString(pngData)
+	var b strings.Builder
+	for i := 0; i < len(payload); i += kittyChunkSize {
+		chunk := payload[i:min(i+kittyChunkSize, len(payload))]
+		more := "1"
+		if i+kittyChunkSize >= len(payload) {
+			more = "0"
+		}
+		if i == 0 {
+			b.WriteString("\x1b_Ga=T,f=100,q=2,m=" + more + ";")
+		} else {
+			b.WriteString("\x1b_Gm=" + more + ";")
+		}
+		b.WriteString(chunk)
+		b.WriteString("\x1b\\")
+	}
+	return b.String()
+}
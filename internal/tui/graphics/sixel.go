@@ -0,0 +1,79 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"strings"
+)
+
+// sixel draws img as DEC sixel graphics, dithered to the 216-colour web-safe palette.
+// Transparent pixels are left unpainted.
+func sixel(img image.Image) string {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, w, h), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	var b strings.Builder
+	// P2=1: pixels not painted keep the background.
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i, c := range palette.WebSafe {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	row := make([]byte, w)
+	for y0 := 0; y0 < h; y0 += 6 {
+		var used [256]bool
+		for y := y0; y < min(y0+6, h); y++ {
+			for x := 0; x < w; x++ {
+				if opaque(img, bounds, x, y) {
+					used[paletted.ColorIndexAt(x, y)] = true
+				}
+			}
+		}
+		for c, ok := range used {
+			if !ok {
+				continue
+			}
+			for x := 0; x < w; x++ {
+				bits := byte(0)
+				for k := 0; k < 6 && y0+k < h; k++ {
+					if int(paletted.ColorIndexAt(x, y0+k)) == c && opaque(img, bounds, x, y0+k) {
+						bits |= 1 << k
+					}
+				}
+				row[x] = 63 + bits
+			}
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRow(&b, row)
+			b.WriteByte('$') // back to the start of the band for the next colour
+		}
+		b.WriteByte('-') // next band
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+func opaque(img image.Image, bounds image.Rectangle, x, y int) bool {
+	_, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+	return a >= 0x8000
+}
+
+// writeSixelRow writes one colour's row, run-length encoding repeats as "!<n><char>".
+func writeSixelRow(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, row[i])
+		} else {
+			b.WriteString(strings.Repeat(string(row[i]), n))
+		}
+		i = j
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Wal-20/cli-chat-app/internal/tui/client"
 	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
@@ -12,13 +13,15 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// BanUserModal prompts for a user id or name to ban from the current chatroom.
+// BanUserModal prompts for a user id or name to ban from the current chatroom, for how
+// long and why. An empty duration bans them until an admin lifts the ban.
 type BanUserModal struct {
 	apiClient  *client.APIClient
 	chatroomID uint
 	returnTo   tea.Model
 
-	input      textinput.Model
+	inputs     []textinput.Model // user, duration, reason
+	focused    int
 	submitting bool
 	width      int
 	height     int
@@ -27,15 +30,26 @@ type BanUserModal struct {
 }
 
 func NewBanUserModal(api *client.APIClient, chatroomID uint, returnTo tea.Model) BanUserModal {
-	in := textinput.New()
-	in.Prompt = "> "
-	in.Placeholder = "user id or name (e.g., 42 or alice)"
-	in.PromptStyle = styles.InputPromptFocusedStyle
-	in.TextStyle = styles.InputTextFocusedStyle
-	in.PlaceholderStyle = styles.InputPlaceholderStyle
-	in.Cursor.Style = styles.KeyStyle
-	in.Focus()
-	return BanUserModal{apiClient: api, chatroomID: chatroomID, returnTo: returnTo, input: in}
+	placeholders := []string{
+		"user id or name (e.g., 42 or alice)",
+		"duration, e.g. 1h or 7d (empty: until unbanned)",
+		"reason (optional)",
+	}
+	inputs := make([]textinput.Model, len(placeholders))
+	for i, placeholder := range placeholders {
+		in := textinput.New()
+		in.Prompt = "> "
+		in.Placeholder = placeholder
+		in.PromptStyle = styles.InputPromptStyle
+		in.TextStyle = styles.InputTextStyle
+		in.PlaceholderStyle = styles.InputPlaceholderStyle
+		in.Cursor.Style = styles.KeyStyle
+		inputs[i] = in
+	}
+	inputs[2].CharLimit = 255
+	m := BanUserModal{apiClient: api, chatroomID: chatroomID, returnTo: returnTo, inputs: inputs}
+	m.focus(0)
+	return m
 }
 
 func (m BanUserModal) Init() tea.Cmd { return textinput.Blink }
@@ -48,35 +62,55 @@ func (m BanUserModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		w := m.width - 20
-		if w > 48 {
-			w = 48
+		if w > 56 {
+			w = 56
 		}
 		if w < 28 {
 			w = 28
 		}
-		m.input.Width = w
+		for i := range m.inputs {
+			m.inputs[i].Width = w
+		}
 		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "esc", "q":
+		case "esc":
 			if m.submitting {
 				return m, nil
 			}
 			return m.returnTo, nil
+		case "tab", "down":
+			m.focus((m.focused + 1) % len(m.inputs))
+			return m, nil
+		case "shift+tab", "up":
+			m.focus((m.focused + len(m.inputs) - 1) % len(m.inputs))
+			return m, nil
 		case "enter":
 			if m.submitting {
 				return m, nil
 			}
-			ident := strings.TrimSpace(m.input.Value())
+			ident := strings.TrimSpace(m.inputs[0].Value())
 			if ident == "" {
 				m.status = "Enter a user id or name"
 				m.statusOK = false
+				m.focus(0)
 				return m, nil
 			}
+			var duration time.Duration
+			if value := strings.TrimSpace(m.inputs[1].Value()); value != "" {
+				d, err := parseTTL(value)
+				if err != nil {
+					m.status = "Duration must look like 1h or 7d"
+					m.statusOK = false
+					m.focus(1)
+					return m, nil
+				}
+				duration = d
+			}
 			m.submitting = true
 			m.status = "Banning..."
 			m.statusOK = true
-			return m, banUserCmd(m.apiClient, m.chatroomID, ident)
+			return m, banUserCmd(m.apiClient, m.chatroomID, ident, duration, strings.TrimSpace(m.inputs[2].Value()))
 		}
 	case banDoneMsg:
 		m.submitting = false
@@ -88,14 +122,36 @@ func (m BanUserModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.returnTo, nil
 	}
 	var cmd tea.Cmd
-	m.input, cmd = m.input.Update(msg)
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
 	return m, cmd
 }
 
+func (m *BanUserModal) focus(i int) {
+	for j := range m.inputs {
+		if j == i {
+			m.inputs[j].Focus()
+			m.inputs[j].PromptStyle = styles.InputPromptFocusedStyle
+			m.inputs[j].TextStyle = styles.InputTextFocusedStyle
+		} else {
+			m.inputs[j].Blur()
+			m.inputs[j].PromptStyle = styles.InputPromptStyle
+			m.inputs[j].TextStyle = styles.InputTextStyle
+		}
+	}
+	m.focused = i
+}
+
 func (m BanUserModal) View() string {
 	title := styles.CardTitleStyle.Render("Ban User")
 	subtitle := styles.CardSubtitleStyle.Render("Ban a user from this chatroom")
-	field := styles.InputFieldFocusedStyle.Render(m.input.View())
+	fields := make([]string, len(m.inputs))
+	for i, in := range m.inputs {
+		if i == m.focused {
+			fields[i] = styles.InputFieldFocusedStyle.Render(in.View())
+		} else {
+			fields[i] = styles.InputFieldStyle.Render(in.View())
+		}
+	}
 
 	statusView := ""
 	if m.status != "" {
@@ -106,11 +162,12 @@ func (m BanUserModal) View() string {
 		}
 	}
 	help := styles.HelpStyle.Render(strings.Join([]string{
+		styles.RenderKeyBinding("Tab", "Next field"),
 		styles.RenderKeyBinding("Enter", "Ban user"),
 		styles.RenderKeyBinding("Esc", "Cancel"),
 	}, styles.HelpStyle.Render("  ")))
 
-	content := strings.Join([]string{title, subtitle, field, statusView, help}, "\n\n")
+	content := strings.Join([]string{title, subtitle, strings.Join(fields, "\n"), statusView, help}, "\n\n")
 	card := styles.CardStyle.Render(content)
 	if m.width > 0 && m.height > 0 {
 		centered := lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, card)
@@ -119,12 +176,13 @@ func (m BanUserModal) View() string {
 	return styles.AppStyle.Render(card)
 }
 
-func banUserCmd(api *client.APIClient, chatroomID uint, ident string) tea.Cmd {
+func banUserCmd(api *client.APIClient, chatroomID uint, ident string, duration time.Duration, reason string) tea.Cmd {
 	return func() tea.Msg {
+		// Resolve ident to id if it's a name
 		if _, err := strconv.ParseUint(ident, 10, 64); err != nil {
 			users, uerr := api.GetUsersByChatroom(chatroomID, false)
 			if uerr == nil {
-				lowered := strings.ToLower(ident)
+				lowered := strings.ToLower(strings.TrimPrefix(ident, "@"))
 				for _, u := range users {
 					if strings.ToLower(u.Name) == lowered {
 						ident = fmt.Sprintf("%d", u.UserID)
@@ -133,7 +191,7 @@ func banUserCmd(api *client.APIClient, chatroomID uint, ident string) tea.Cmd {
 				}
 			}
 		}
-		err := api.BanUser(fmt.Sprintf("%d", chatroomID), ident)
+		err := api.BanUser(fmt.Sprintf("%d", chatroomID), ident, duration, reason)
 		return banDoneMsg{err: err}
 	}
 }
@@ -0,0 +1,267 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxUploadSize mirrors the server's limit so oversized files fail before uploading.
+const maxUploadSize = 25 << 20
+
+// attachmentUploadedMsg reports the outcome of /upload.
+type attachmentUploadedMsg struct {
+	name    string
+	message models.MessageWithUser
+	err     error
+}
+
+// attachmentDownloadedMsg reports the outcome of /download.
+type attachmentDownloadedMsg struct {
+	name string
+	path string
+	err  error
+}
+
+func (m ChatroomModel) runUpload(args string) (tea.Model, tea.Cmd) {
+	if args == "" {
+		m.flashUsage("upload")
+		return m, nil
+	}
+	// Paths may contain spaces: prefer the whole argument when it names a file.
+	path, caption := expandHome(args), ""
+	if _, err := os.Stat(path); err != nil {
+		first, rest, _ := strings.Cut(args, " ")
+		path, caption = expandHome(first), strings.TrimSpace(rest)
+	}
+	info, err := os.Stat(path)
+	var problem string
+	switch {
+	case err != nil:
+		problem = fmt.Sprintf("Cannot read %s", path)
+	case !info.Mode().IsRegular():
+		problem = fmt.Sprintf("%s is not a file", path)
+	case info.Size() == 0:
+		problem = fmt.Sprintf("%s is empty", path)
+	case info.Size() > maxUploadSize:
+		problem = fmt.Sprintf("%s is larger than %s", info.Name(), formatSize(maxUploadSize))
+	}
+	if problem != "" {
+		m.flashMessage = problem
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Uploading %s (%s)...", info.Name(), formatSize(info.Size()))
+	m.flashStyle = styles.StatusInfoStyle
+	return m, uploadAttachment(m.apiClient, m.chatroom.Id, path, caption)
+}
+
+func uploadAttachment(api *client.APIClient, chatroomID uint, path, caption string) tea.Cmd {
+	return func() tea.Msg {
+		message, err := api.UploadAttachment(chatroomID, path, caption, newClientID())
+		return attachmentUploadedMsg{name: filepath.Base(path), message: message, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleAttachmentUploaded(msg attachmentUploadedMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to upload %s: %s", msg.name, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	// The websocket broadcast may have delivered the message already.
+	if m.messageIndex(msg.message.ID) < 0 {
+		m.messages = append(m.messages, msg.message)
+		m.refreshViewportContent(false)
+	}
+	m.flashMessage = "Uploaded " + msg.name
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+func (m ChatroomModel) runDownload(args string) (tea.Model, tea.Cmd) {
+	ref, dest, _ := strings.Cut(args, " ")
+	id, err := strconv.ParseUint(strings.TrimPrefix(ref, "#"), 10, 64)
+	if err != nil || id == 0 {
+		m.flashUsage("download")
+		return m, nil
+	}
+	attachment := m.findAttachment(uint(id))
+	if attachment == nil {
+		m.flashMessage = fmt.Sprintf("No attachment #%d in this room", id)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+
+	dest = expandHome(strings.TrimSpace(dest))
+	if dest == "" {
+		dest = downloadDir()
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = availablePath(filepath.Join(dest, filepath.Base(attachment.Name)))
+	}
+
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Downloading %s...", attachment.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	return m, downloadAttachment(m.apiClient, m.chatroom.Id, *attachment, dest)
+}
+
+func downloadAttachment(api *client.APIClient, chatroomID uint, attachment models.Attachment, dest string) tea.Cmd {
+	return func() tea.Msg {
+		err := api.DownloadAttachment(chatroomID, attachment.ID, dest)
+		return attachmentDownloadedMsg{name: attachment.Name, path: dest, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleAttachmentDownloaded(msg attachmentDownloadedMsg) {
+	if msg.err != nil {
+		if errors.Is(msg.err, os.ErrExist) {
+			m.flashMessage = fmt.Sprintf("%s already exists", msg.path)
+		} else {
+			m.flashMessage = fmt.Sprintf("Failed to download %s: %s", msg.name, msg.err.Error())
+		}
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.flashMessage = "Saved " + msg.path
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+// findAttachment looks an attachment up among the messages loaded in the view.
+func (m ChatroomModel) findAttachment(id uint) *models.Attachment {
+	for _, message := range m.loadedMessages() {
+		if message.Attachment != nil && message.Attachment.ID == id && message.DeletedAt == nil {
+			return message.Attachment
+		}
+	}
+	return nil
+}
+
+func (m ChatroomModel) loadedMessages() []models.MessageWithUser {
+	loaded := append([]models.MessageWithUser(nil), m.messages...)
+	if m.thread != nil {
+		loaded = append(loaded, m.thread.Root)
+		loaded = append(loaded, m.thread.Replies...)
+	}
+	for _, pin := range m.pins {
+		loaded = append(loaded, pin.Message)
+	}
+	return loaded
+}
+
+// renderAttachment renders the attachment line and its preview below a message: an
+// image rendition at the viewport width, or the stored text preview. Text lines are cut
+// rather than wrapped so file contents keep their shape.
+func renderAttachment(attachment *models.Attachment, width int) string {
+	header := fmt.Sprintf("📎 %s · %s · %s", attachment.Name, formatSize(attachment.Size), attachment.MimeType)
+	out := styles.AttachmentStyle.Render(ansi.Truncate(header, max(width-6, 10), "…")) +
+		styles.MutedTextStyle.Render(fmt.Sprintf(" [#%d]", attachment.ID))
+	if image := imagePreviewFor(attachment, width); image != nil {
+		return out + "\n" + image.Art
+	}
+	if attachment.Preview == "" {
+		return out
+	}
+	lines := strings.Split(attachment.Preview, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, max(width-2, 10), "…")
+	}
+	return out + "\n" + styles.AttachmentPreviewStyle.Render(strings.Join(lines, "\n"))
+}
+
+// attachmentCandidates completes /download with the attachments on screen, newest first.
+func attachmentCandidates(m ChatroomModel, _ string) []string {
+	var ids []string
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if a := m.messages[i].Attachment; a != nil && m.messages[i].DeletedAt == nil {
+			ids = append(ids, strconv.FormatUint(uint64(a.ID), 10))
+		}
+	}
+	return ids
+}
+
+// pathCandidates completes /upload with the entries of the directory being typed.
+// Directories end in a slash; dotfiles are listed only once a dot is typed.
+func pathCandidates(_ ChatroomModel, word string) []string {
+	dir, base := filepath.Split(word)
+	entries, err := os.ReadDir(expandHome(dir))
+	if dir == "" {
+		entries, err = os.ReadDir(".")
+	}
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		paths = append(paths, dir+name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// downloadDir is ~/Downloads when it exists, else the working directory.
+func downloadDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		dir := filepath.Join(home, "Downloads")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return "."
+}
+
+// availablePath adds " (1)", " (2)", … before the extension until the path is free.
+func availablePath(path string) string {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// "/bans" opens the room's bans in the sidebar slot, where Delete lifts one. The list is
+// loaded when the pane opens and kept in sync through member_updated events.
+
+type bansLoadedMsg struct {
+	bans []models.UserChatroom
+	err  error
+}
+
+type unbanResultMsg struct {
+	userID uint
+	name   string
+	err    error
+}
+
+func loadBans(api *client.APIClient, chatroomID uint) tea.Cmd {
+	return func() tea.Msg {
+		bans, err := api.GetBans(chatroomID)
+		return bansLoadedMsg{bans: bans, err: err}
+	}
+}
+
+func unbanMember(api *client.APIClient, chatroomID uint, ban models.UserChatroom) tea.Cmd {
+	return func() tea.Msg {
+		room := strconv.FormatUint(uint64(chatroomID), 10)
+		user := strconv.FormatUint(uint64(ban.UserID), 10)
+		return unbanResultMsg{userID: ban.UserID, name: ban.Name, err: api.UnbanUser(room, user)}
+	}
+}
+
+func (m ChatroomModel) runBans(args string) (tea.Model, tea.Cmd) {
+	if args != "" {
+		m.flashUsage("bans")
+		return m, nil
+	}
+	m.input.Reset()
+	m.openBans()
+	return m, loadBans(m.apiClient, m.chatroom.Id)
+}
+
+func (m *ChatroomModel) openBans() {
+	m.thread = nil
+	m.showPins = false
+	m.showScheduled = false
+	m.showBans = true
+	m.banCursor = 0
+	m.flashMessage = "↑/↓ to browse, Delete to lift a ban, Esc to close"
+	m.flashStyle = styles.StatusInfoStyle
+}
+
+// updateBans handles keys while the bans pane has focus.
+func (m ChatroomModel) updateBans(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		m.banCursor = max(m.banCursor-1, 0)
+	case "down":
+		m.banCursor = min(m.banCursor+1, max(len(m.bans)-1, 0))
+	case "delete":
+		if m.banCursor >= len(m.bans) {
+			return m, nil
+		}
+		ban := m.bans[m.banCursor]
+		m.flashMessage = fmt.Sprintf("Lifting the ban on %s...", ban.Name)
+		m.flashStyle = styles.StatusInfoStyle
+		return m, unbanMember(m.apiClient, m.chatroom.Id, ban)
+	case "esc":
+		m.showBans = false
+		m.flashMessage = ""
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *ChatroomModel) handleBansLoaded(msg bansLoadedMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to load bans: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.bans = msg.bans
+	m.banCursor = min(m.banCursor, max(len(m.bans)-1, 0))
+	if len(m.bans) == 0 && m.showBans {
+		m.flashMessage = "Nobody is banned"
+		m.flashStyle = styles.StatusInfoStyle
+	}
+}
+
+func (m *ChatroomModel) handleUnbanResult(msg unbanResultMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to lift the ban on %s: %s", msg.name, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.removeBan(msg.userID)
+	m.flashMessage = "Lifted the ban on " + msg.name
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+// addBan puts a new ban at the top of the list, replacing any older entry for the member.
+func (m *ChatroomModel) addBan(ban models.UserChatroom) {
+	m.removeBan(ban.UserID)
+	m.bans = append([]models.UserChatroom{ban}, m.bans...)
+}
+
+func (m *ChatroomModel) removeBan(userID uint) {
+	for i, ban := range m.bans {
+		if ban.UserID == userID {
+			m.bans = append(m.bans[:i], m.bans[i+1:]...)
+			break
+		}
+	}
+	m.banCursor = min(m.banCursor, max(len(m.bans)-1, 0))
+}
+
+// memberName names a user of the room, falling back to their id once they have left.
+func (m ChatroomModel) memberName(userID uint) string {
+	for _, u := range m.users {
+		if u.UserID == userID {
+			return u.Name
+		}
+	}
+	return fmt.Sprintf("user #%d", userID)
+}
+
+func (m ChatroomModel) renderBansPane(width int) string {
+	if width <= 0 {
+		width = styles.SidebarStyle.GetWidth()
+	}
+	innerWidth := max(width-4, 12)
+
+	lines := []string{
+		styles.SidebarTitleStyle.Render(fmt.Sprintf("Bans (%d)", len(m.bans))),
+		styles.MutedTextStyle.Render("Delete to lift, Esc to close"),
+		"",
+	}
+	if len(m.bans) == 0 {
+		lines = append(lines, styles.MutedTextStyle.Render("Nobody is banned."))
+	}
+	cursorLine := 0
+	for i, ban := range m.bans {
+		pointer := "  "
+		nameStyle := styles.MessageAuthorStyle
+		if i == m.banCursor {
+			pointer = styles.KeyStyle.Render("> ")
+			nameStyle = styles.ListItemTitleSelectedStyle
+			cursorLine = len(lines)
+		}
+		until := "until lifted"
+		if ban.BannedUntil != nil {
+			until = "until " + ban.BannedUntil.Local().Format("Jan 2 15:04")
+		}
+		lines = append(lines, pointer+nameStyle.Render(ban.Name)+" "+styles.MessageTimestampStyle.Render(until))
+		if ban.BanReason != "" {
+			reason := strings.Split(wrapText(ban.BanReason, innerWidth), "\n")
+			if len(reason) > 2 {
+				reason = append(reason[:2], "…")
+			}
+			for _, line := range reason {
+				lines = append(lines, "  "+line)
+			}
+		}
+		if ban.BannedBy != nil {
+			lines = append(lines, "  "+styles.MutedTextStyle.Render("banned by "+m.memberName(*ban.BannedBy)))
+		}
+		lines = append(lines, "")
+	}
+
+	if height := m.viewport.Height; height > 0 && len(lines) > height {
+		start := min(cursorLine, len(lines)-height)
+		lines = lines[start : start+height]
+	}
+	return styles.ThreadPaneStyle.Copy().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,659 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Lines typed into the chatroom input that start with "/" are commands. Each command
+// lives in the registry below with its usage, so parsing, /help, the inline hint and
+// tab completion all come from one place. "//text" sends "/text" as a plain message.
+
+// slashCommand is one entry of the registry. perm is what the user's role must grant
+// to see and run it (0 for everyone); complete lists candidates for the first argument
+// given what has been typed of it (nil when it has none); run receives the arguments
+// with surrounding spaces trimmed.
+type slashCommand struct {
+	name     string
+	args     string
+	summary  string
+	perm     models.Permissions
+	complete func(m ChatroomModel, word string) []string
+	run      func(m ChatroomModel, args string) (tea.Model, tea.Cmd)
+}
+
+// slashCommands is filled in init because /help reads the registry it belongs to.
+var slashCommands []slashCommand
+
+func init() {
+	slashCommands = []slashCommand{
+		{name: "help", args: "[command]", summary: "List commands or show how to use one", complete: commandNameCandidates, run: ChatroomModel.runHelp},
+		{name: "me", args: "<action>", summary: "Send an action, e.g. /me waves", run: ChatroomModel.runMe},
+		{name: "search", args: "[query]", summary: "Search this room's messages", run: ChatroomModel.runSearch},
+		{name: "topic", args: "[text | -]", summary: "Show the topic; admins can set it or clear it with -", run: ChatroomModel.runTopic},
+		{name: "schedule", args: "[time message]", summary: "Schedule a message, or list scheduled ones", run: ChatroomModel.runSchedule},
+		{name: "poll", args: `[-multi] [-anon] [-closes <ttl>] "question" "option" "option"...`, summary: "Ask the room a question; vote with Alt+V", run: ChatroomModel.runPoll},
+		{name: "ephemeral", args: "<ttl> <message>", summary: "Send a message that expires, e.g. /ephemeral 10m hi", run: ChatroomModel.runEphemeral},
+		{name: "upload", args: "<path> [caption]", summary: "Share a file with the room", complete: pathCandidates, run: ChatroomModel.runUpload},
+		{name: "download", args: "<n> [path]", summary: "Save attachment #n, by default to your Downloads folder", complete: attachmentCandidates, run: ChatroomModel.runDownload},
+		{name: "export", args: "<md | html | jsonl> [path] [from] [to]", summary: "Save the room's history to a file; dates are YYYY-MM-DD", complete: exportFormatCandidates, run: ChatroomModel.runExport},
+		{name: "view", args: "<n>", summary: "Show image #n full size, in terminals with kitty or sixel graphics", complete: imageCandidates, run: ChatroomModel.runView},
+		{name: "leave", summary: "Leave this chatroom", run: ChatroomModel.runLeave},
+		{name: "invite", args: "<user>", summary: "Invite a user to the room", perm: models.PermInvite, run: ChatroomModel.runInvite},
+		{name: "kick", args: "<user>", summary: "Remove a member from the room", perm: models.PermKick, complete: otherMemberCandidates, run: ChatroomModel.runKick},
+		{name: "ban", args: "<user> [duration] [reason]", summary: "Remove a member and stop them rejoining, e.g. /ban bob 7d", perm: models.PermBan, complete: otherMemberCandidates, run: ChatroomModel.runBan},
+		{name: "bans", summary: "List the room's bans; Delete lifts one", perm: models.PermBan, run: ChatroomModel.runBans},
+		{name: "mute", args: "<user> [duration] [reason]", summary: "Stop a member posting, e.g. /mute bob 1h spamming", perm: models.PermMute, complete: otherMemberCandidates, run: ChatroomModel.runMute},
+		{name: "unmute", args: "<user>", summary: "Let a muted member post again", perm: models.PermMute, complete: mutedMemberCandidates, run: ChatroomModel.runUnmute},
+		{name: "promote", args: "<user>", summary: "Give a member the admin role", perm: models.PermManageRoles, complete: promotableCandidates, run: ChatroomModel.runPromote},
+		{name: "demote", args: "<user>", summary: "Give an admin the member role", perm: models.PermManageRoles, complete: demotableCandidates, run: ChatroomModel.runDemote},
+		{name: "transfer", args: "<user>", summary: "Offer the room to a member; they must accept (owner only)", perm: models.PermManageRoles, complete: otherMemberCandidates, run: ChatroomModel.runTransfer},
+		{name: "ownership", args: "<accept | decline | withdraw>", summary: "Answer an offer of the room, or withdraw yours", complete: ownershipCandidates, run: ChatroomModel.runOwnership},
+		{name: "roles", args: "[role]", summary: "List the room's roles, or one role's permissions", complete: roleCandidates, run: ChatroomModel.runRoles},
+		{name: "role", args: "<user> <role>", summary: "Give a member one of the room's roles", perm: models.PermManageRoles, complete: otherMemberCandidates, run: ChatroomModel.runRole},
+		{name: "newrole", args: "<name> <rank> [perm,perm...]", summary: "Create a role, e.g. /newrole mod 20 send,kick,mute,pin", perm: models.PermManageRoles, run: ChatroomModel.runNewRole},
+		{name: "editrole", args: "<role> [rank] [perm,perm...]", summary: "Change a role's rank or replace its permissions", perm: models.PermManageRoles, complete: roleCandidates, run: ChatroomModel.runEditRole},
+		{name: "delrole", args: "<role>", summary: "Delete a role; its members become plain members", perm: models.PermManageRoles, complete: roleCandidates, run: ChatroomModel.runDelRole},
+		{name: "roomttl", args: "<ttl | off>", summary: "Set how long new messages live", perm: models.PermManageRoom, run: ChatroomModel.runRoomTTL},
+		{name: "settings", summary: "Change the room's title, topic, visibility, capacity, slow mode and message ttl", perm: models.PermManageRoom, run: ChatroomModel.runSettings},
+	}
+}
+
+// memberActionMsg reports the outcome of a command run on a member, such as /kick.
+type memberActionMsg struct {
+	action string
+	name   string
+	userID uint
+	err    error
+}
+
+type topicResultMsg struct {
+	topic string
+	err   error
+}
+
+type wsTopicUpdatedMsg struct{ payload models.TopicUpdatedPayload }
+
+type wsRoomUpdatedMsg struct{ payload models.RoomUpdatedPayload }
+
+type wsMemberUpdatedMsg struct{ payload models.MemberUpdatedPayload }
+
+func lookupCommand(name string) (slashCommand, bool) {
+	name = strings.ToLower(name)
+	for _, cmd := range slashCommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return slashCommand{}, false
+}
+
+func (c slashCommand) usage() string {
+	if c.args == "" {
+		return "/" + c.name
+	}
+	return "/" + c.name + " " + c.args
+}
+
+// availableCommands lists the commands the current user may run.
+func (m ChatroomModel) availableCommands() []slashCommand {
+	var cmds []slashCommand
+	for _, cmd := range slashCommands {
+		if m.can(cmd.perm) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// runCommand parses and runs a "/name args" line typed into the input.
+func (m ChatroomModel) runCommand(line string) (tea.Model, tea.Cmd) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		m.flashMessage = fmt.Sprintf("Unknown command /%s (try /help)", name)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if !m.can(cmd.perm) {
+		m.flashMessage = fmt.Sprintf("Your role cannot use /%s", cmd.name)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	return cmd.run(m, strings.TrimSpace(args))
+}
+
+func (m *ChatroomModel) flashUsage(name string) {
+	cmd, _ := lookupCommand(name)
+	m.flashMessage = "Usage: " + cmd.usage()
+	m.flashStyle = styles.StatusErrorStyle
+}
+
+// commandHint is the inline help shown while a command is being typed: the usage of
+// the command named so far, or the commands its name could still become.
+func (m ChatroomModel) commandHint() string {
+	value := m.input.Value()
+	if !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//") {
+		return ""
+	}
+	name, _, hasArgs := strings.Cut(value[1:], " ")
+	if cmd, ok := lookupCommand(name); ok && (hasArgs || m.matchCount(name) == 1) {
+		return cmd.usage() + " — " + cmd.summary
+	}
+	var usages []string
+	for _, cmd := range m.availableCommands() {
+		if strings.HasPrefix(cmd.name, strings.ToLower(name)) {
+			usages = append(usages, cmd.usage())
+		}
+	}
+	if len(usages) == 0 {
+		return fmt.Sprintf("Unknown command /%s (try /help)", name)
+	}
+	return strings.Join(usages, "   ")
+}
+
+func (m ChatroomModel) matchCount(prefix string) int {
+	n := 0
+	for _, cmd := range m.availableCommands() {
+		if strings.HasPrefix(cmd.name, strings.ToLower(prefix)) {
+			n++
+		}
+	}
+	return n
+}
+
+// completeCommand completes the command name, or its first argument, at the end of the
+// input. Repeated presses cycle through the candidates. It reports false when the input
+// is not a command so Tab can fall back to @mention completion.
+func (m *ChatroomModel) completeCommand() bool {
+	value := m.input.Value()
+	if !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//") {
+		return false
+	}
+	name, arg, hasArg := strings.Cut(value[1:], " ")
+	prefix, word := "/", name
+	candidates := commandNameCandidates
+	if hasArg {
+		cmd, ok := lookupCommand(name)
+		if !ok || cmd.complete == nil || strings.Contains(arg, " ") {
+			return false
+		}
+		prefix, word = "/"+cmd.name+" ", strings.TrimPrefix(arg, "@")
+		candidates = cmd.complete
+	}
+
+	if len(m.completions) > 0 && m.completions[m.completionIndex] == word {
+		m.completionIndex = (m.completionIndex + 1) % len(m.completions)
+	} else {
+		m.completions = nil
+		m.completionIndex = 0
+		for _, c := range candidates(*m, word) {
+			if strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+				m.completions = append(m.completions, c)
+			}
+		}
+	}
+	if len(m.completions) == 0 {
+		m.flashMessage = "No completions"
+		m.flashStyle = styles.StatusInfoStyle
+		return true
+	}
+
+	completed := prefix + m.completions[m.completionIndex]
+	// A unique match is final: the next Tab starts over from it, so a command name moves
+	// on to its arguments and a directory to its entries.
+	if len(m.completions) == 1 {
+		if !hasArg {
+			completed += " "
+		}
+		m.completions = nil
+	}
+	m.input.SetValue(completed)
+	m.input.CursorEnd()
+	m.flashMessage = ""
+	return true
+}
+
+func commandNameCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	for _, cmd := range m.availableCommands() {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+func otherMemberCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	for _, u := range m.users {
+		if u.UserID != m.userID && !strings.EqualFold(u.Name, m.username) {
+			names = append(names, u.Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	return names
+}
+
+func promotableCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	for _, name := range otherMemberCandidates(m, "") {
+		if member, ok := m.findMember(name); ok && member.BuiltInRoleName() != models.RoleAdmin && !member.IsOwner {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func mutedMemberCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	now := time.Now()
+	for _, name := range otherMemberCandidates(m, "") {
+		if member, ok := m.findMember(name); ok && member.MutedAt(now) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// findMember resolves a user argument, given as a name (optionally with @) or a user id.
+func (m ChatroomModel) findMember(ident string) (models.UserChatroom, bool) {
+	ident = strings.TrimPrefix(strings.TrimSpace(ident), "@")
+	id, idErr := strconv.ParseUint(ident, 10, 64)
+	for _, u := range m.users {
+		if strings.EqualFold(u.Name, ident) || (idErr == nil && u.UserID == uint(id)) {
+			return u, true
+		}
+	}
+	return models.UserChatroom{}, false
+}
+
+func (m ChatroomModel) currentUserIsOwner() bool {
+	for _, u := range m.users {
+		if u.UserID == m.userID && u.IsOwner {
+			return true
+		}
+	}
+	return false
+}
+
+func (m ChatroomModel) runHelp(args string) (tea.Model, tea.Cmd) {
+	m.input.Reset()
+	m.flashStyle = styles.StatusInfoStyle
+	if args != "" {
+		cmd, ok := lookupCommand(strings.TrimPrefix(args, "/"))
+		if !ok || !m.can(cmd.perm) {
+			m.flashMessage = fmt.Sprintf("No command /%s", strings.TrimPrefix(args, "/"))
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.flashMessage = cmd.usage() + " — " + cmd.summary
+		return m, nil
+	}
+	var names []string
+	for _, cmd := range m.availableCommands() {
+		names = append(names, "/"+cmd.name)
+	}
+	m.flashMessage = "Commands: " + strings.Join(names, " ") + "  (/help <command> for details, // to send a literal /)"
+	return m, nil
+}
+
+func (m ChatroomModel) runMe(args string) (tea.Model, tea.Cmd) {
+	if args == "" {
+		m.flashUsage("me")
+		return m, nil
+	}
+	m.input.Reset()
+	typingStoppedCmd := makeUserStatusCmd(m.wsSend, "stoppedTyping", m.username)
+	return m, tea.Batch(m.queueMessage(args, 0, models.MessageKindAction), typingStoppedCmd)
+}
+
+func (m ChatroomModel) runSearch(args string) (tea.Model, tea.Cmd) {
+	m.input.Reset()
+	m.searching = true
+	m.searchInput.SetValue(args)
+	m.searchInput.CursorEnd()
+	focus := m.searchInput.Focus()
+	if args == "" {
+		return m, focus
+	}
+	return m, tea.Batch(focus, searchMessages(m.apiClient, m.chatroom.Id, args))
+}
+
+// runSettings opens the room's settings form, which comes back here when closed.
+func (m ChatroomModel) runSettings(args string) (tea.Model, tea.Cmd) {
+	if args != "" {
+		m.flashUsage("settings")
+		return m, nil
+	}
+	if m.chatroom.IsDirect {
+		m.flashMessage = "Direct conversations have no settings"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = ""
+	sm := NewChatroomSettingsModel(m.username, m.userID, m.chatroom, m.apiClient, m)
+	return sm, sm.Init()
+}
+
+func (m ChatroomModel) runTopic(args string) (tea.Model, tea.Cmd) {
+	if args == "" {
+		m.input.Reset()
+		m.flashMessage = "No topic set"
+		if m.chatroom.Topic != "" {
+			m.flashMessage = "Topic: " + m.chatroom.Topic
+		}
+		m.flashStyle = styles.StatusInfoStyle
+		return m, nil
+	}
+	if !m.can(models.PermManageRoom) {
+		m.flashMessage = "Your role cannot change the topic"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if args == "-" {
+		args = ""
+	}
+	m.input.Reset()
+	m.flashMessage = "Updating topic..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, setTopic(m.apiClient, m.chatroom.Id, args)
+}
+
+func (m ChatroomModel) runLeave(args string) (tea.Model, tea.Cmd) {
+	return m.leaveChatroom(m.apiClient, m.chatroom.Id)
+}
+
+func (m ChatroomModel) runInvite(args string) (tea.Model, tea.Cmd) {
+	name := strings.TrimPrefix(args, "@")
+	if name == "" || strings.Contains(name, " ") {
+		m.flashUsage("invite")
+		return m, nil
+	}
+	if _, ok := m.findMember(name); ok {
+		m.flashMessage = fmt.Sprintf("%s is already in the room", name)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	return m.startMemberAction("invite", models.UserChatroom{Name: name})
+}
+
+func (m ChatroomModel) runKick(args string) (tea.Model, tea.Cmd) {
+	return m.runOnMember("kick", args)
+}
+
+func (m ChatroomModel) runBan(args string) (tea.Model, tea.Cmd) {
+	return m.runPenalty("ban", args)
+}
+
+func (m ChatroomModel) runMute(args string) (tea.Model, tea.Cmd) {
+	return m.runPenalty("mute", args)
+}
+
+// runPenalty bans or mutes a member. A second word that reads as a duration bounds the
+// penalty; everything after it, or after the name when there is none, is the reason.
+func (m ChatroomModel) runPenalty(action, args string) (tea.Model, tea.Cmd) {
+	ident, rest, _ := strings.Cut(args, " ")
+	if ident == "" {
+		m.flashUsage(action)
+		return m, nil
+	}
+	var duration time.Duration
+	reason := strings.TrimSpace(rest)
+	if first, after, _ := strings.Cut(reason, " "); first != "" {
+		if d, err := parseTTL(first); err == nil {
+			duration, reason = d, strings.TrimSpace(after)
+		}
+	}
+	member, ok := m.resolveMember(action, ident)
+	if !ok {
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Running /%s %s...", action, member.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	return m, penalize(m.apiClient, m.chatroom.Id, action, member, duration, reason)
+}
+
+func (m ChatroomModel) runUnmute(args string) (tea.Model, tea.Cmd) {
+	return m.runOnMember("unmute", args)
+}
+
+func (m ChatroomModel) runPromote(args string) (tea.Model, tea.Cmd) {
+	return m.runOnMember("promote", args)
+}
+
+// runOnMember validates the user argument of a command run on a member.
+func (m ChatroomModel) runOnMember(action, args string) (tea.Model, tea.Cmd) {
+	member, ok := m.resolveMember(action, args)
+	if !ok {
+		return m, nil
+	}
+	return m.startMemberAction(action, member)
+}
+
+// resolveMember finds the member a moderation command names, flashing why when it
+// names nobody the current user could act on.
+func (m *ChatroomModel) resolveMember(action, args string) (models.UserChatroom, bool) {
+	if args == "" || strings.Contains(args, " ") {
+		m.flashUsage(action)
+		return models.UserChatroom{}, false
+	}
+	member, ok := m.findMember(args)
+	if !ok {
+		m.flashMessage = fmt.Sprintf("No member named %s", strings.TrimPrefix(args, "@"))
+		m.flashStyle = styles.StatusErrorStyle
+		return models.UserChatroom{}, false
+	}
+	if member.UserID == m.userID {
+		m.flashMessage = fmt.Sprintf("You cannot %s yourself", action)
+		m.flashStyle = styles.StatusErrorStyle
+		return models.UserChatroom{}, false
+	}
+	return member, true
+}
+
+func (m ChatroomModel) startMemberAction(action string, member models.UserChatroom) (tea.Model, tea.Cmd) {
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Running /%s %s...", action, member.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	return m, memberAction(m.apiClient, m.chatroom.Id, action, member)
+}
+
+func memberAction(api *client.APIClient, chatroomID uint, action string, member models.UserChatroom) tea.Cmd {
+	return func() tea.Msg {
+		room := strconv.FormatUint(uint64(chatroomID), 10)
+		user := strconv.FormatUint(uint64(member.UserID), 10)
+		var err error
+		switch action {
+		case "invite":
+			// The server resolves names for invites.
+			err = api.InviteUser(room, member.Name)
+		case "kick":
+			err = api.KickUser(room, user)
+		case "unmute":
+			err = api.UnmuteUser(room, user)
+		case "promote":
+			err = api.MakeAdmin(room, user)
+		case "demote":
+			err = api.DemoteUser(room, user)
+		case "transfer":
+			err = api.OfferOwnership(room, user)
+		}
+		return memberActionMsg{action: action, name: member.Name, userID: member.UserID, err: err}
+	}
+}
+
+func penalize(api *client.APIClient, chatroomID uint, action string, member models.UserChatroom, duration time.Duration, reason string) tea.Cmd {
+	return func() tea.Msg {
+		room := strconv.FormatUint(uint64(chatroomID), 10)
+		user := strconv.FormatUint(uint64(member.UserID), 10)
+		var err error
+		if action == "ban" {
+			err = api.BanUser(room, user, duration, reason)
+		} else {
+			err = api.MuteUser(room, user, duration, reason)
+		}
+		return memberActionMsg{action: action, name: member.Name, userID: member.UserID, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleMemberAction(msg memberActionMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("/%s %s failed: %s", msg.action, msg.name, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	switch msg.action {
+	case "invite":
+		m.flashMessage = "Invited " + msg.name
+	case "kick", "ban":
+		m.flashMessage = "Kicked " + msg.name
+		if msg.action == "ban" {
+			m.flashMessage = "Banned " + msg.name
+		}
+		var users []models.UserChatroom
+		for _, u := range m.users {
+			if u.UserID != msg.userID {
+				users = append(users, u)
+			}
+		}
+		m.users = users
+	case "mute":
+		// The member_updated event carries the mute itself.
+		m.flashMessage = "Muted " + msg.name
+	case "unmute":
+		m.flashMessage = "Unmuted " + msg.name
+	case "demote":
+		m.flashMessage = msg.name + " is no longer an admin"
+		for i := range m.users {
+			if m.users[i].UserID == msg.userID {
+				m.users[i].IsAdmin, m.users[i].RoleID = false, nil
+			}
+		}
+	case "transfer":
+		m.flashMessage = fmt.Sprintf("Offered the room to %s; they have %s to accept", msg.name, formatTTL(ownerOfferTTL))
+	case "promote":
+		m.flashMessage = msg.name + " is now an admin"
+		for i := range m.users {
+			if m.users[i].UserID == msg.userID {
+				m.users[i].IsAdmin, m.users[i].RoleID = true, nil
+			}
+		}
+	}
+	m.flashStyle = styles.StatusSuccessStyle
+	m.refreshViewportContent(true)
+}
+
+// handleMemberUpdated applies a change to a member's standing, telling the current
+// user when it is theirs.
+func (m *ChatroomModel) handleMemberUpdated(msg wsMemberUpdatedMsg) {
+	member := msg.payload.Member
+	switch msg.payload.Action {
+	case "banned", "kicked":
+		if msg.payload.Action == "banned" {
+			m.addBan(member)
+		}
+		var users []models.UserChatroom
+		for _, u := range m.users {
+			if u.UserID != member.UserID {
+				users = append(users, u)
+			}
+		}
+		m.users = users
+	case "unbanned", "ban_expired":
+		m.removeBan(member.UserID)
+	case "owner_changed":
+		for i := range m.users {
+			m.users[i].IsOwner = false
+		}
+		m.chatroom.OwnerId = member.UserID
+		m.chatroom.PendingOwnerID = nil
+	case "ownership_offered":
+		m.chatroom.PendingOwnerID = &member.UserID
+	case "ownership_declined":
+		m.chatroom.PendingOwnerID = nil
+		if member.UserID != m.userID && m.currentUserIsOwner() {
+			m.flashMessage = member.Name + " declined ownership of the room"
+			m.flashStyle = styles.StatusInfoStyle
+		}
+	}
+	for i := range m.users {
+		if m.users[i].UserID == member.UserID {
+			m.users[i].IsAdmin = member.IsAdmin
+			m.users[i].IsOwner = member.IsOwner
+			m.users[i].RoleID = member.RoleID
+			m.users[i].IsMuted = member.IsMuted
+			m.users[i].MutedUntil = member.MutedUntil
+			m.users[i].MuteReason = member.MuteReason
+		}
+	}
+	if member.UserID != m.userID {
+		return
+	}
+	switch msg.payload.Action {
+	case "muted":
+		m.flashMessage = "You have been muted"
+		if member.MutedUntil != nil {
+			m.flashMessage += " for " + formatTTL(time.Until(*member.MutedUntil))
+		}
+		if member.MuteReason != "" {
+			m.flashMessage += ": " + member.MuteReason
+		}
+		m.flashStyle = styles.StatusErrorStyle
+	case "unmuted", "mute_expired":
+		m.flashMessage = "You can post again"
+		m.flashStyle = styles.StatusSuccessStyle
+	case "banned":
+		m.flashMessage = "You have been banned from this chatroom"
+		if member.BanReason != "" {
+			m.flashMessage += ": " + member.BanReason
+		}
+		m.flashStyle = styles.StatusErrorStyle
+	case "kicked":
+		m.flashMessage = "You have been kicked from this chatroom"
+		m.flashStyle = styles.StatusErrorStyle
+	case "promoted":
+		m.flashMessage = "You are now an admin"
+		m.flashStyle = styles.StatusSuccessStyle
+	case "demoted":
+		m.flashMessage = "You are no longer an admin"
+		m.flashStyle = styles.StatusInfoStyle
+	case "role_changed":
+		m.flashMessage = "Your role is now " + m.roleOf(member).Name
+		m.flashStyle = styles.StatusInfoStyle
+	case "owner_changed":
+		m.flashMessage = "You are now the owner of this room"
+		m.flashStyle = styles.StatusSuccessStyle
+	case "ownership_offered":
+		m.flashMessage = "You have been offered ownership of this room: /ownership accept or /ownership decline"
+		m.flashStyle = styles.StatusInfoStyle
+	}
+}
+
+func setTopic(api *client.APIClient, chatroomID uint, topic string) tea.Cmd {
+	return func() tea.Msg {
+		return topicResultMsg{topic: topic, err: api.SetTopic(chatroomID, topic)}
+	}
+}
+
+func (m *ChatroomModel) handleTopicResult(msg topicResultMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to update topic: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.chatroom.Topic = msg.topic
+	m.flashMessage = "Topic cleared"
+	if msg.topic != "" {
+		m.flashMessage = "Topic updated"
+	}
+	m.flashStyle = styles.StatusSuccessStyle
+}
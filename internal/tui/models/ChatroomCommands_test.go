@@ -0,0 +1,148 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/charmbracelet/bubbles/textarea"
+)
+
+func TestSlashCommandRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, cmd := range slashCommands {
+		if cmd.name != strings.ToLower(cmd.name) || strings.ContainsAny(cmd.name, " /") {
+			t.Errorf("command %q: names are lower-case single words", cmd.name)
+		}
+		if seen[cmd.name] {
+			t.Errorf("command %q is registered twice", cmd.name)
+		}
+		seen[cmd.name] = true
+		if cmd.summary == "" || cmd.run == nil {
+			t.Errorf("command %q needs a summary and a run function", cmd.name)
+		}
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "help", want: "help", wantOK: true},
+		{name: "TOPIC", want: "topic", wantOK: true},
+		{name: "he", wantOK: false},
+		{name: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		cmd, ok := lookupCommand(tt.name)
+		if ok != tt.wantOK || cmd.name != tt.want {
+			t.Errorf("lookupCommand(%q) = %q, %v; want %q, %v", tt.name, cmd.name, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSlashCommandUsage(t *testing.T) {
+	tests := []struct {
+		cmd  slashCommand
+		want string
+	}{
+		{cmd: slashCommand{name: "leave"}, want: "/leave"},
+		{cmd: slashCommand{name: "kick", args: "<user>"}, want: "/kick <user>"},
+	}
+	for _, tt := range tests {
+		if got := tt.cmd.usage(); got != tt.want {
+			t.Errorf("usage() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRunCommandRejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		admin bool
+		line  string
+		want  string
+	}{
+		{name: "unknown", line: "/nosuch arg", want: "Unknown command /nosuch (try /help)"},
+		{name: "needs a permission", line: "/kick bob", want: "Your role cannot use /kick"},
+		{name: "usage on missing arguments", admin: true, line: "/mute", want: "Usage: /mute <user> [duration] [reason]"},
+		{name: "arguments are trimmed", admin: true, line: "/bans   ", want: "↑/↓ to browse, Delete to lift a ban, Esc to close"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ChatroomModel{
+				userID: 1,
+				users:  []models.UserChatroom{{UserID: 1, Name: "me", IsAdmin: tt.admin, IsJoined: true}},
+				input:  textarea.New(),
+			}
+			model, _ := m.runCommand(tt.line)
+			if got := model.(ChatroomModel).flashMessage; got != tt.want {
+				t.Errorf("runCommand(%q) flashed %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandHint(t *testing.T) {
+	tests := []struct {
+		name  string
+		admin bool
+		value string
+		want  string
+	}{
+		{name: "not a command", value: "hello", want: ""},
+		{name: "escaped slash", value: "//not a command", want: ""},
+		{name: "partial name", value: "/hel", want: "/help [command]"},
+		{name: "full name", value: "/help", want: "/help [command] — List commands or show how to use one"},
+		{name: "full name with arguments", value: "/topic new", want: "/topic [text | -] — Show the topic; admins can set it or clear it with -"},
+		{name: "ambiguous prefix", value: "/ro", admin: true, want: "/roles [role]   /roomttl <ttl | off>"},
+		{name: "commands the role lacks are hidden", value: "/ki", want: "Unknown command /ki (try /help)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ChatroomModel{
+				userID: 1,
+				users:  []models.UserChatroom{{UserID: 1, Name: "me", IsAdmin: tt.admin, IsJoined: true}},
+				input:  textarea.New(),
+			}
+			m.input.SetValue(tt.value)
+			if got := m.commandHint(); got != tt.want {
+				t.Errorf("commandHint(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantOK  bool
+		presses int
+	}{
+		{name: "not a command", value: "hi", want: "hi", presses: 1},
+		{name: "unique name gets a space", value: "/hel", want: "/help ", wantOK: true, presses: 1},
+		{name: "first argument", value: "/help to", want: "/help topic", wantOK: true, presses: 1},
+		{name: "repeated presses cycle", value: "/e", want: "/export", wantOK: true, presses: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ChatroomModel{
+				userID: 1,
+				users:  []models.UserChatroom{{UserID: 1, Name: "me", IsJoined: true}},
+				input:  textarea.New(),
+			}
+			m.input.SetValue(tt.value)
+			var ok bool
+			for i := 0; i < tt.presses; i++ {
+				ok = m.completeCommand()
+			}
+			if ok != tt.wantOK || m.input.Value() != tt.want {
+				t.Errorf("completeCommand(%q) = %q, %v; want %q, %v", tt.value, m.input.Value(), ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
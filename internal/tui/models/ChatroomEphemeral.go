@@ -0,0 +1,261 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Ephemeral messages carry an expiry. The server purges them and sends message_expired,
+// but the room also drops them locally on a one-second ticker so they disappear on time
+// and their countdown badges stay current.
+
+const expiryTickInterval = time.Second
+
+// expiryTickerSeq tells ticker chains apart: a room keeps only its latest chain alive,
+// so chains started by an earlier model or before a modal took over die out.
+var expiryTickerSeq int
+
+type expiryTickMsg struct{ seq int }
+
+type messageTTLResultMsg struct {
+	ttl time.Duration
+	err error
+}
+
+type wsMessageExpiredMsg struct{ payload models.MessageExpiredPayload }
+
+func expiryTick(seq int) tea.Cmd {
+	return tea.Tick(expiryTickInterval, func(time.Time) tea.Msg { return expiryTickMsg{seq: seq} })
+}
+
+func setMessageTTL(api *client.APIClient, chatroomID uint, ttl time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		return messageTTLResultMsg{ttl: ttl, err: api.SetMessageTTL(chatroomID, ttl)}
+	}
+}
+
+// parseTTL reads a duration such as 90s, 10m, 1h30m or 2d; "off" and "0" mean none.
+func parseTTL(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "off" || s == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d.Truncate(time.Second), nil
+}
+
+// formatTTL renders a duration in its largest whole unit, e.g. 4m or 2d.
+func formatTTL(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%ds", int(max(d, 0)/time.Second))
+	}
+}
+
+// expiryFor mirrors the server: the room default applies when ttl is zero and caps
+// anything longer. The server's answer replaces this guess once the message is stored.
+func (m ChatroomModel) expiryFor(ttl time.Duration) *time.Time {
+	if roomTTL := time.Duration(m.chatroom.MessageTTL) * time.Second; roomTTL > 0 && (ttl == 0 || ttl > roomTTL) {
+		ttl = roomTTL
+	}
+	if ttl == 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(ttl)
+	return &expiresAt
+}
+
+// runEphemeral handles "/ephemeral <ttl> <text>".
+func (m ChatroomModel) runEphemeral(args string) (tea.Model, tea.Cmd) {
+	ttlArg, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	text = strings.TrimSpace(text)
+	if text == "" {
+		m.flashMessage = "Usage: /ephemeral 10m message"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	ttl, err := parseTTL(ttlArg)
+	if err != nil || ttl == 0 {
+		m.flashMessage = "Give a lifetime such as 30s, 10m or 1h"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	return m, m.queueMessage(text, ttl, models.MessageKindText)
+}
+
+// runRoomTTL handles "/roomttl <ttl|off>", which sets the room default (manage_room only).
+func (m ChatroomModel) runRoomTTL(args string) (tea.Model, tea.Cmd) {
+	if !m.can(models.PermManageRoom) {
+		m.flashMessage = "Your role cannot change the room's message lifetime"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	ttl, err := parseTTL(args)
+	if err != nil {
+		m.flashMessage = "Usage: /roomttl 1h (or off)"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = "Updating message lifetime..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, setMessageTTL(m.apiClient, m.chatroom.Id, ttl)
+}
+
+func (m *ChatroomModel) handleMessageTTLResult(msg messageTTLResultMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to update message lifetime: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.chatroom.MessageTTL = uint(msg.ttl / time.Second)
+	m.flashMessage = "New messages are kept"
+	if msg.ttl > 0 {
+		m.flashMessage = "New messages now expire after " + formatTTL(msg.ttl)
+	}
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+func (m ChatroomModel) hasEphemeral() bool {
+	for _, message := range m.messages {
+		if message.ExpiresAt != nil {
+			return true
+		}
+	}
+	if m.thread == nil {
+		return false
+	}
+	for _, reply := range m.thread.Replies {
+		if reply.ExpiresAt != nil {
+			return true
+		}
+	}
+	return m.thread.Root.ExpiresAt != nil
+}
+
+// ensureExpiryTicker starts a ticker chain while ephemeral messages are on screen and
+// the current chain, if any, has stopped ticking.
+func (m *ChatroomModel) ensureExpiryTicker() tea.Cmd {
+	if time.Since(m.expiryTickAt) < 2*expiryTickInterval || !m.hasEphemeral() {
+		return nil
+	}
+	expiryTickerSeq++
+	m.expiryTickSeq = expiryTickerSeq
+	m.expiryTickAt = time.Now()
+	return expiryTick(m.expiryTickSeq)
+}
+
+func (m *ChatroomModel) handleExpiryTick(msg expiryTickMsg) tea.Cmd {
+	if msg.seq != m.expiryTickSeq {
+		return nil
+	}
+	m.dropExpired(time.Now())
+	if !m.hasEphemeral() {
+		m.expiryTickSeq = 0
+		m.expiryTickAt = time.Time{}
+		return nil
+	}
+	m.expiryTickAt = time.Now()
+	m.refreshViewportContent(true)
+	return expiryTick(msg.seq)
+}
+
+// dropExpired removes every message whose expiry has passed.
+func (m *ChatroomModel) dropExpired(now time.Time) {
+	var expired []models.MessageWithUser
+	collect := func(messages []models.MessageWithUser) {
+		for _, message := range messages {
+			if message.ExpiresAt != nil && !message.ExpiresAt.After(now) {
+				expired = append(expired, message)
+			}
+		}
+	}
+	collect(m.messages)
+	if m.thread != nil {
+		collect(m.thread.Replies)
+	}
+	for _, message := range expired {
+		m.removeExpired(models.MessageExpiredPayload{MessageID: message.ID, ParentID: message.ParentID}, message.ClientID)
+	}
+}
+
+// removeExpired forgets an expired message everywhere it is shown. Messages that were
+// never acknowledged have no id yet and are matched by clientID instead.
+func (m *ChatroomModel) removeExpired(expired models.MessageExpiredPayload, clientID string) {
+	idx := m.messageIndex(expired.MessageID)
+	if idx < 0 {
+		idx = m.clientMessageIndex(clientID)
+	}
+	if idx >= 0 {
+		m.messages = append(m.messages[:idx], m.messages[idx+1:]...)
+		delete(m.outbox, clientID)
+	}
+
+	if expired.ParentID != nil {
+		// A reply: keep the thread counts in step.
+		if root := m.messageIndex(*expired.ParentID); root >= 0 && m.messages[root].ReplyCount > 0 {
+			m.messages[root].ReplyCount--
+		}
+		if m.thread != nil && m.thread.Root.ID == *expired.ParentID {
+			for i, reply := range m.thread.Replies {
+				if reply.ID == expired.MessageID {
+					m.thread.Replies = append(m.thread.Replies[:i], m.thread.Replies[i+1:]...)
+					m.thread.Root.ReplyCount = max(m.thread.Root.ReplyCount-1, 0)
+					break
+				}
+			}
+		}
+	}
+
+	if expired.MessageID == 0 {
+		return
+	}
+	m.removePin(expired.MessageID)
+	if m.thread != nil && m.thread.Root.ID == expired.MessageID {
+		m.closeThread()
+		m.flashMessage = "The thread expired"
+		m.flashStyle = styles.StatusInfoStyle
+	}
+	if m.selectedID == expired.MessageID {
+		m.selectedID = 0
+	}
+	if m.editingID == expired.MessageID {
+		m.editingID = 0
+		m.input.Reset()
+		m.input.Placeholder = "Write a message..."
+		m.flashMessage = "The message you were editing expired"
+		m.flashStyle = styles.StatusErrorStyle
+	}
+}
+
+// expiryBadge is the countdown shown next to an ephemeral message.
+func expiryBadge(message models.MessageWithUser) string {
+	if message.ExpiresAt == nil || message.DeletedAt != nil {
+		return ""
+	}
+	return styles.ExpiryBadgeStyle.Render(" ⏳" + formatTTL(time.Until(*message.ExpiresAt)))
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportFormats maps the names /export accepts to the format the server expects,
+// which is also the file extension.
+var exportFormats = map[string]string{
+	"md": "md", "markdown": "md",
+	"html": "html", "htm": "html",
+	"jsonl": "jsonl", "json": "jsonl", "ndjson": "jsonl",
+}
+
+// exportDoneMsg reports the outcome of /export.
+type exportDoneMsg struct {
+	path string
+	size int64
+	err  error
+}
+
+// runExport saves a transcript of the room: /export <format> [path] [from] [to]. from
+// and to are local dates, both included. The path may be a directory, and defaults to
+// the Downloads folder.
+func (m ChatroomModel) runExport(args string) (tea.Model, tea.Cmd) {
+	words := strings.Fields(args)
+	if len(words) == 0 {
+		m.flashUsage("export")
+		return m, nil
+	}
+	format, ok := exportFormats[strings.ToLower(words[0])]
+	if !ok {
+		m.flashMessage = "Format must be md, html or jsonl"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+
+	// Trailing dates bound the export; whatever comes before them is the path, which
+	// may contain spaces.
+	rest := words[1:]
+	var dates []time.Time
+	for len(rest) > 0 && len(dates) < 2 {
+		day, err := time.ParseInLocation(time.DateOnly, rest[len(rest)-1], time.Local)
+		if err != nil {
+			break
+		}
+		dates = append([]time.Time{day}, dates...)
+		rest = rest[:len(rest)-1]
+	}
+	var from, to time.Time
+	switch len(dates) {
+	case 2:
+		from, to = dates[0], dates[1].AddDate(0, 0, 1)
+	case 1:
+		from = dates[0]
+	}
+	if !to.IsZero() && !from.Before(to) {
+		m.flashMessage = "The start date must not be after the end date"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+
+	dest := expandHome(strings.Join(rest, " "))
+	if dest == "" {
+		dest = downloadDir()
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = availablePath(filepath.Join(dest, exportFileName(m.chatroom.Title, format)))
+	}
+
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Exporting %s...", m.chatroom.Title)
+	m.flashStyle = styles.StatusInfoStyle
+	return m, exportChatroom(m.apiClient, m.chatroom.Id, format, from, to, dest)
+}
+
+func exportChatroom(api *client.APIClient, chatroomID uint, format string, from, to time.Time, dest string) tea.Cmd {
+	return func() tea.Msg {
+		size, err := api.ExportChatroom(chatroomID, format, from, to, dest)
+		return exportDoneMsg{path: dest, size: size, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleExportDone(msg exportDoneMsg) {
+	if msg.err != nil {
+		if errors.Is(msg.err, os.ErrExist) {
+			m.flashMessage = fmt.Sprintf("%s already exists", msg.path)
+		} else {
+			m.flashMessage = "Export failed: " + msg.err.Error()
+		}
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.flashMessage = fmt.Sprintf("Exported to %s (%s)", msg.path, formatSize(msg.size))
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+func exportFormatCandidates(ChatroomModel, string) []string {
+	return []string{"html", "jsonl", "md"}
+}
+
+// exportFileName names a transcript after the room and today's date.
+func exportFileName(title, format string) string {
+	stem := strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+	if stem == "" {
+		stem = "chatroom"
+	}
+	return fmt.Sprintf("%s-%s.%s", stem, time.Now().Format(time.DateOnly), format)
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Global search runs from the room's search bar (Tab switches between this room and
+// all rooms). Results replace the conversation until one is opened or Esc is pressed.
+
+type globalSearchResultMsg struct {
+	hits  []models.SearchHit
+	query string
+	err   error
+}
+
+func searchAllRooms(api *client.APIClient, query string) tea.Cmd {
+	return func() tea.Msg {
+		hits, err := api.SearchAll(query)
+		return globalSearchResultMsg{hits: hits, query: query, err: err}
+	}
+}
+
+func (m *ChatroomModel) toggleGlobalSearch() {
+	m.globalSearch = !m.globalSearch
+	if m.globalSearch {
+		m.searchInput.Prompt = "all rooms/ "
+		m.searchInput.Placeholder = `terms "phrase" from:user in:room before:YYYY-MM-DD after:YYYY-MM-DD`
+		return
+	}
+	m.searchInput.Prompt = "/ "
+	m.searchInput.Placeholder = "Search messages (Tab: all rooms)"
+}
+
+func (m ChatroomModel) runGlobalSearch(query string) (tea.Model, tea.Cmd) {
+	if query == "" {
+		m.flashMessage = "Type something to search for"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.searching = false
+	m.searchInput.Blur()
+	m.flashMessage = "Searching all rooms..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, searchAllRooms(m.apiClient, query)
+}
+
+// updateSearchResults handles keys while global search results are shown.
+func (m ChatroomModel) updateSearchResults(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		m.resultCursor = max(m.resultCursor-1, 0)
+	case "down":
+		m.resultCursor = min(m.resultCursor+1, max(len(m.searchResults)-1, 0))
+	case "enter":
+		return m.openSearchHit()
+	case "esc":
+		m.searchResults = nil
+		m.flashMessage = ""
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// openSearchHit scrolls to the selected result, switching rooms when it was found elsewhere.
+func (m ChatroomModel) openSearchHit() (tea.Model, tea.Cmd) {
+	if m.resultCursor >= len(m.searchResults) {
+		return m, nil
+	}
+	hit := m.searchResults[m.resultCursor]
+	m.searchResults = nil
+
+	if hit.ChatroomID == m.chatroom.Id {
+		cmd := m.jumpToMessage(hit.Message)
+		return m, cmd
+	}
+
+	room := models.Chatroom{Id: hit.ChatroomID, Title: hit.ChatroomTitle}
+	if rooms, err := m.apiClient.GetUserChatrooms(); err == nil {
+		for _, r := range rooms {
+			if r.Id == hit.ChatroomID {
+				room = r
+				break
+			}
+		}
+	}
+	m.disconnect()
+	next := NewChatroomModel(m.username, m.userID, room, m.apiClient)
+	next.applyWindowSize(m.width, m.height)
+	jumpCmd := next.jumpToMessage(hit.Message)
+	return next, tea.Batch(next.Init(), jumpCmd)
+}
+
+func (m ChatroomModel) renderSearchResults(width int) string {
+	if width <= 0 {
+		width = 60
+	}
+	lines := []string{
+		styles.SidebarTitleStyle.Render(fmt.Sprintf("Search results (%d)", len(m.searchResults))),
+		styles.MutedTextStyle.Render("↑/↓ to browse, Enter to open, Esc to close"),
+		"",
+	}
+	if len(m.searchResults) == 0 {
+		lines = append(lines, styles.MutedTextStyle.Render("No messages matched."))
+	}
+
+	cursorLine := 0
+	for i, hit := range m.searchResults {
+		pointer := "  "
+		roomStyle := styles.ListItemTitleStyle
+		if i == m.resultCursor {
+			pointer = styles.KeyStyle.Render("> ")
+			roomStyle = styles.ListItemTitleSelectedStyle
+			cursorLine = len(lines)
+		}
+		header := pointer + roomStyle.Render("#"+hit.ChatroomTitle) + " " +
+			styles.MessageAuthorStyle.Render(hit.Message.Username) + " " +
+			styles.MessageTimestampStyle.Render(hit.Message.CreatedAt.Format("Jan 2 15:04"))
+		lines = append(lines, header)
+		for _, line := range strings.Split(wrapText(highlightSpans(hit.Snippet, hit.Highlights), width-4), "\n") {
+			lines = append(lines, "    "+line)
+		}
+		lines = append(lines, "")
+	}
+
+	if height := m.viewport.Height; height > 0 && len(lines) > height {
+		start := min(cursorLine, len(lines)-height)
+		lines = lines[start : start+height]
+	}
+	return lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n"))
+}
+
+// highlightSpans styles the byte ranges of text reported as matches by the server.
+func highlightSpans(text string, spans []models.TextSpan) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span.Start < last || span.End > len(text) || span.Start >= span.End {
+			continue
+		}
+		b.WriteString(text[last:span.Start])
+		b.WriteString(styles.SearchHighlightStyle.Render(text[span.Start:span.End]))
+		last = span.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/graphics"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// thumbnailMsg carries an image attachment's thumbnail fetched for /view.
+type thumbnailMsg struct {
+	attachment models.Attachment
+	data       []byte
+	err        error
+}
+
+// imageViewedMsg is sent once the full-screen image view has been dismissed.
+type imageViewedMsg struct{ err error }
+
+// imagePreviewFor picks the widest rendition that fits in width cells, or nil when
+// none does or the terminal has no colours to draw it with.
+func imagePreviewFor(attachment *models.Attachment, width int) *models.ImagePreview {
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		return nil
+	}
+	var best *models.ImagePreview
+	for i := range attachment.ImagePreviews {
+		preview := &attachment.ImagePreviews[i]
+		if preview.Columns <= width && (best == nil || preview.Columns > best.Columns) {
+			best = preview
+		}
+	}
+	return best
+}
+
+func (m ChatroomModel) runView(args string) (tea.Model, tea.Cmd) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(args, "#"), 10, 64)
+	if err != nil || id == 0 {
+		m.flashUsage("view")
+		return m, nil
+	}
+	attachment := m.findAttachment(uint(id))
+	switch {
+	case attachment == nil:
+		m.flashMessage = fmt.Sprintf("No attachment #%d in this room", id)
+	case attachment.Width == 0:
+		m.flashMessage = fmt.Sprintf("#%d is not an image (try /download %d)", id, id)
+	case m.graphics == graphics.None:
+		m.flashMessage = "This terminal can't show images; set CHAT_GRAPHICS=kitty or sixel if it can"
+	default:
+		m.input.Reset()
+		m.flashMessage = fmt.Sprintf("Loading %s...", attachment.Name)
+		m.flashStyle = styles.StatusInfoStyle
+		return m, fetchThumbnail(m.apiClient, m.chatroom.Id, *attachment)
+	}
+	m.flashStyle = styles.StatusErrorStyle
+	return m, nil
+}
+
+func fetchThumbnail(api *client.APIClient, chatroomID uint, attachment models.Attachment) tea.Cmd {
+	return func() tea.Msg {
+		data, err := api.Thumbnail(chatroomID, attachment.ID)
+		return thumbnailMsg{attachment: attachment, data: data, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleThumbnail(msg thumbnailMsg) tea.Cmd {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to load %s: %s", msg.attachment.Name, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return nil
+	}
+	image, err := graphics.Encode(m.graphics, msg.data)
+	if err != nil {
+		m.flashMessage = fmt.Sprintf("Cannot show %s: %s", msg.attachment.Name, err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return nil
+	}
+	m.flashMessage = ""
+	viewer := &imageViewer{title: fmt.Sprintf("%s · %dx%d", msg.attachment.Name, msg.attachment.Width, msg.attachment.Height), image: image, protocol: m.graphics}
+	return tea.Exec(viewer, func(err error) tea.Msg { return imageViewedMsg{err: err} })
+}
+
+// imageViewer shows an image on the bare terminal while the TUI is suspended, since
+// graphics sequences can't pass through the renderer. It runs until Enter is pressed.
+type imageViewer struct {
+	title    string
+	image    string
+	protocol graphics.Protocol
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+func (v *imageViewer) Run() error {
+	if _, err := fmt.Fprintf(v.stdout, "\x1b[2J\x1b[H%s\r\n\r\n%s\r\n\r\nPress Enter to return to the chat", v.title, v.image); err != nil {
+		return err
+	}
+	_, err := bufio.NewReader(v.stdin).ReadString('\n')
+	if v.protocol == graphics.Kitty {
+		fmt.Fprint(v.stdout, graphics.ClearKitty)
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// imageCandidates completes /view with the images on screen, newest first.
+func imageCandidates(m ChatroomModel, _ string) []string {
+	var ids []string
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if a := m.messages[i].Attachment; a != nil && a.Width > 0 && m.messages[i].DeletedAt == nil {
+			ids = append(ids, strconv.FormatUint(uint64(a.ID), 10))
+		}
+	}
+	return ids
+}
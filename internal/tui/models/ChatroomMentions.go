@@ -0,0 +1,110 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+)
+
+// Group mentions understood by the server alongside @username.
+var groupMentions = []string{"here", "admins"}
+
+// mentionName extracts the name from an @name token, ignoring trailing punctuation
+// the same way the server does. ok is false when word is not a mention.
+func mentionName(word string) (name string, ok bool) {
+	if !strings.HasPrefix(word, "@") {
+		return "", false
+	}
+	name = strings.TrimRightFunc(word[1:], func(r rune) bool {
+		return unicode.IsPunct(r) && r != '_' && r != '-'
+	})
+	return name, name != ""
+}
+
+// mentionsMe reports whether a mention of name would notify the current user.
+func (m ChatroomModel) mentionsMe(name string) bool {
+	switch strings.ToLower(name) {
+	case "here":
+		return true
+	case "admins":
+		return m.moderates()
+	}
+	return strings.EqualFold(name, m.username)
+}
+
+// highlightMentions styles every @mention in message text, making
+// the ones aimed at the current user stand out.
+func (m ChatroomModel) highlightMentions(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		words := strings.Split(line, " ")
+		for j, word := range words {
+			name, ok := mentionName(word)
+			if !ok {
+				continue
+			}
+			token := "@" + name
+			style := styles.MentionStyle
+			if m.mentionsMe(name) {
+				style = styles.MentionSelfStyle
+			}
+			words[j] = style.Render(token) + word[len(token):]
+		}
+		lines[i] = strings.Join(words, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// completeMention completes the @name being typed at the end of the input from the
+// room's participants. Pressing Tab again cycles through the other matches.
+func (m *ChatroomModel) completeMention() {
+	value := m.input.Value()
+	start := strings.LastIndexAny(value, " \n") + 1
+	word := value[start:]
+	if !strings.HasPrefix(word, "@") {
+		m.mentionMatches = nil
+		return
+	}
+
+	current := word[1:]
+	if len(m.mentionMatches) > 0 && m.mentionMatches[m.mentionIndex] == current {
+		m.mentionIndex = (m.mentionIndex + 1) % len(m.mentionMatches)
+	} else {
+		m.mentionMatches = m.mentionCandidates(current)
+		m.mentionIndex = 0
+	}
+	if len(m.mentionMatches) == 0 {
+		m.flashMessage = "No matching participants"
+		m.flashStyle = styles.StatusInfoStyle
+		return
+	}
+
+	m.input.SetValue(value[:start] + "@" + m.mentionMatches[m.mentionIndex])
+	m.flashMessage = ""
+}
+
+// mentionCandidates lists participant names (and group mentions) starting with prefix.
+func (m ChatroomModel) mentionCandidates(prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var names []string
+	for _, u := range m.users {
+		if u.UserID == m.userID || strings.EqualFold(u.Name, m.username) {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(u.Name), prefix) {
+			names = append(names, u.Name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	for _, group := range groupMentions {
+		if group == "here" && !m.moderates() {
+			continue
+		}
+		if strings.HasPrefix(group, prefix) {
+			names = append(names, group)
+		}
+	}
+	return names
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -11,19 +12,37 @@ import (
 	"github.com/Wal-20/cli-chat-app/internal/api/ws"
 	"github.com/Wal-20/cli-chat-app/internal/models"
 	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/graphics"
 	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 type sendMessageResultMsg struct {
+	clientID string
+	message  models.MessageWithUser
+	err      error
+}
+
+type editMessageResultMsg struct {
 	message models.MessageWithUser
 	err     error
 }
 
+type deleteMessageResultMsg struct {
+	messageID uint
+	err       error
+}
+
+type reactionResultMsg struct{ err error }
+
+// reactionPalette holds the quick reactions offered by the Alt+A picker (keys 1-6).
+var reactionPalette = []string{"👍", "❤️", "😂", "🎉", "😮", "👀"}
+
 // invite flow result message
 // (obsolete) inviteResultMsg removed; modal handles invites now
 
@@ -42,6 +61,7 @@ type ChatroomModel struct {
 	sidebarWidth       int
 	showSidebar        bool
 	sending            bool
+	outbox             map[string]*outgoingMessage // sent messages awaiting an ack, by client id
 	flashMessage       string
 	wsStatusMessage    string
 	flashStyle         lipgloss.Style
@@ -54,8 +74,42 @@ type ChatroomModel struct {
 	lastTypingSent     time.Time
 	searchInput        textinput.Model
 	searchQuery        string
+	selectedID         uint  // message currently targeted by message actions (alt+up/down), 0 when none
+	editingID          uint  // message whose content is being edited in the input, 0 when composing
+	confirmingDeleteID uint  // message awaiting delete confirmation, 0 when none
+	olderCursor        *uint // cursor for the next page of older history, nil when fully loaded
+	loadingOlder       bool
+	thread             *models.Thread // open thread pane, nil when closed
+	reacting           bool           // reaction picker is open for the selected message
+	votingID           uint           // poll message the vote picker is open on, 0 when closed
+	mentionMatches     []string       // candidates cycled through by repeated Tab completion
+	mentionIndex       int
+	completions        []string // slash-command completion candidates, cycled like mentionMatches
+	completionIndex    int
+	pins               []models.PinnedMessage // newest pin first
+	showPins           bool                   // pins pane is open and has the arrow keys
+	pinCursor          int
+	scheduled          []models.ScheduledMessage // the user's pending scheduled messages, soonest first
+	showScheduled      bool                      // scheduled pane is open and has the arrow keys
+	scheduledCursor    int
+	roles              []models.Role         // the room's roles, highest rank first
+	bans               []models.UserChatroom // loaded when the bans pane opens, newest first
+	showBans           bool                  // bans pane is open and has the arrow keys
+	banCursor          int
+	globalSearch       bool               // search bar queries every joined room
+	searchResults      []models.SearchHit // global results on screen, nil when closed
+	resultCursor       int
+	expiryTickSeq      int               // ticker chain that expires ephemeral messages, 0 when stopped
+	expiryTickAt       time.Time         // last tick of that chain
+	rawMarkup          bool              // show message content as typed instead of rendering markup
+	graphics           graphics.Protocol // inline image protocol of the terminal, for /view
+	lastReadID         uint              // newest message the server has recorded as read
+	newSinceID         uint              // first message that was unread when the room opened, 0 when none
 }
 
+// messagePageSize is how many messages are requested per history page.
+const messagePageSize = 30
+
 // tea.Cmds to detect typing events. We track a sequence number so that
 // only the timers for the most recent typing activity are honored.
 type typingStoppedMsg struct{ seq int } // triggers clearing after inactivity
@@ -88,8 +142,9 @@ func NewChatroomModel(username string, userID uint, chatroom models.Chatroom, ap
 
 	input.Cursor.Style = styles.KeyStyle
 
-	messages, msgErr := apiClient.GetMessages(chatroom.Id)
-	if msgErr != nil {
+	page, msgErr := apiClient.GetMessagesPage(chatroom.Id, "", 0, messagePageSize)
+	messages := page.Messages
+	if msgErr != nil || messages == nil {
 		messages = []models.MessageWithUser{}
 	}
 
@@ -105,7 +160,7 @@ func NewChatroomModel(username string, userID uint, chatroom models.Chatroom, ap
 	// search input setup
 	s := textinput.New()
 	s.Prompt = "/ "
-	s.Placeholder = "Search messages"
+	s.Placeholder = "Search messages (Tab: all rooms)"
 	s.PromptStyle = styles.InputPromptFocusedStyle
 	s.TextStyle = styles.InputTextFocusedStyle
 	s.PlaceholderStyle = styles.InputPlaceholderStyle
@@ -124,6 +179,23 @@ func NewChatroomModel(username string, userID uint, chatroom models.Chatroom, ap
 		showSidebar:  true,
 		flashStyle:   styles.StatusInfoStyle,
 		searchInput:  s,
+		olderCursor:  page.NextCursor,
+		outbox:       map[string]*outgoingMessage{},
+		graphics:     graphics.Detect(),
+	}
+
+	// The "new messages" divider goes above the first message from someone else past
+	// the stored read marker. Unread counts are best-effort.
+	if counts, err := apiClient.GetUnreadCounts(); err == nil {
+		if count, ok := counts[chatroom.Id]; ok && count.LastReadID != nil {
+			model.lastReadID = *count.LastReadID
+			for _, message := range messages {
+				if message.ID > model.lastReadID && !strings.EqualFold(message.Username, username) {
+					model.newSinceID = message.ID
+					break
+				}
+			}
+		}
 	}
 
 	if msgErr != nil {
@@ -156,17 +228,28 @@ func NewChatroomModel(username string, userID uint, chatroom models.Chatroom, ap
 
 func (m ChatroomModel) Init() tea.Cmd {
 	if m.wsChan != nil {
-		cmds := []tea.Cmd{textarea.Blink, m.listenWS(m.wsChan)}
+		cmds := []tea.Cmd{textarea.Blink, m.listenWS(m.wsChan), m.markRead(), loadPins(m.apiClient, m.chatroom.Id), loadRoles(m.apiClient, m.chatroom.Id)}
 		if m.wsSend != nil {
 			// Announce that this user opened the chatroom.
 			cmds = append(cmds, makeUserStatusCmd(m.wsSend, "joined", m.username))
 		}
 		return tea.Batch(cmds...)
 	}
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, m.markRead(), loadPins(m.apiClient, m.chatroom.Id), loadRoles(m.apiClient, m.chatroom.Id))
 }
 
 func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	// Whatever brought ephemeral messages on screen, make sure they count down.
+	if room, ok := next.(ChatroomModel); ok {
+		if tick := room.ensureExpiryTicker(); tick != nil {
+			return room, tea.Batch(cmd, tick)
+		}
+	}
+	return next, cmd
+}
+
+func (m ChatroomModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var typingCmd tea.Cmd
 	// these two commands send updates to the ws channel when detecting user is typing / user left events
 
@@ -179,10 +262,84 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// Handle delete confirmation first
+		if m.confirmingDeleteID != 0 {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				id := m.confirmingDeleteID
+				m.confirmingDeleteID = 0
+				m.flashMessage = "Deleting message..."
+				m.flashStyle = styles.StatusInfoStyle
+				return m, deleteMessage(m.apiClient, m.chatroom.Id, id)
+			case "n", "esc":
+				m.confirmingDeleteID = 0
+				m.flashMessage = ""
+				return m, nil
+			case "ctrl+c":
+				return m, tea.Quit
+			default:
+				// ignore other keys while confirming
+				return m, nil
+			}
+		}
+
+		if m.reacting {
+			return m.pickReaction(msg.String())
+		}
+
+		if m.votingID != 0 {
+			return m.pickVote(msg.String())
+		}
+
+		if m.searchResults != nil {
+			switch msg.String() {
+			case "up", "down", "enter", "esc", "ctrl+c":
+				return m.updateSearchResults(msg.String())
+			}
+		}
+
+		if m.showScheduled {
+			switch msg.String() {
+			case "up", "down", "delete", "esc", "ctrl+c":
+				return m.updateScheduled(msg.String())
+			}
+		}
+
+		if m.showBans {
+			switch msg.String() {
+			case "up", "down", "delete", "esc", "ctrl+c":
+				return m.updateBans(msg.String())
+			}
+		}
+
+		if m.showPins {
+			switch msg.String() {
+			case "up", "down", "enter", "esc", "ctrl+t", "ctrl+c":
+				return m.updatePins(msg.String())
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
 		case "esc":
+			if m.editingID != 0 {
+				m.editingID = 0
+				m.input.Reset()
+				m.input.Placeholder = "Write a message..."
+				m.flashMessage = "Edit cancelled"
+				m.flashStyle = styles.StatusInfoStyle
+				return m, nil
+			}
+			if m.thread != nil {
+				m.closeThread()
+				return m, nil
+			}
+			if m.searching && m.globalSearch {
+				m.searching = false
+				m.searchInput.Blur()
+				return m, nil
+			}
 			if m.searching {
 				m.searching = false
 				m.searchInput.Blur()
@@ -191,18 +348,11 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, searchMessages(m.apiClient, m.chatroom.Id, "")
 			}
 
-			if m.wsSend != nil {
-				if cmd := makeUserStatusCmd(m.wsSend, "left", m.username); cmd != nil {
-					_ = cmd()
-				}
-			}
-			if m.wsCancel != nil {
-				m.wsCancel()
-			}
+			m.disconnect()
 			// Ensure next main screen pulls fresh memberships
 			m.apiClient.InvalidateUserChatrooms()
 			return NewMainChatModel(m.username, m.userID, m.apiClient), nil
-		case "/", "ctrl+f":
+		case "ctrl+f":
 			if !m.searching {
 				m.searching = true
 				m.searchInput.SetValue("")
@@ -213,53 +363,110 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.leaveChatroom(m.apiClient, m.chatroom.Id)
 		case "ctrl+u":
 			m.viewport.HalfViewUp()
-			return m, nil
+			cmd := m.loadOlderAtTop()
+			return m, cmd
 		case "ctrl+d":
 			m.viewport.HalfViewDown()
 			return m, nil
+		case "alt+up":
+			m.moveSelection(-1)
+			return m, nil
+		case "alt+down":
+			m.moveSelection(1)
+			return m, nil
+		case "alt+e":
+			return m.startEditing()
+		case "alt+d":
+			return m.confirmDelete()
+		case "alt+r":
+			return m.openThread()
+		case "alt+m":
+			m.rawMarkup = !m.rawMarkup
+			m.flashMessage = "Showing formatted text"
+			if m.rawMarkup {
+				m.flashMessage = "Showing raw text (Alt+M to format)"
+			}
+			m.flashStyle = styles.StatusInfoStyle
+			m.refreshViewportContent(true)
+			return m, nil
+		case "alt+p":
+			return m.togglePinSelected()
+		case "alt+v":
+			return m.startVoting()
+		case "ctrl+t":
+			m.openPins()
+			return m, nil
+		case "alt+a":
+			if m.messageIndex(m.selectedID) < 0 {
+				m.flashMessage = "Select a message first (Alt+↑/↓)"
+				m.flashStyle = styles.StatusErrorStyle
+				return m, nil
+			}
+			m.reacting = true
+			options := make([]string, len(reactionPalette))
+			for i, emoji := range reactionPalette {
+				options[i] = fmt.Sprintf("%d %s", i+1, emoji)
+			}
+			m.flashMessage = "React: " + strings.Join(options, "  ") + "  (Esc to cancel)"
+			m.flashStyle = styles.StatusInfoStyle
+			return m, nil
+		case "tab":
+			if m.searching {
+				m.toggleGlobalSearch()
+				return m, nil
+			}
+			if !m.completeCommand() {
+				m.completeMention()
+			}
+			return m, nil
 		case "ctrl+o":
-			if !m.currentUserIsAdmin() {
-				m.flashMessage = "Only admins can invite users"
+			if !m.can(models.PermInvite) {
+				m.flashMessage = "Your role cannot invite users"
 				m.flashStyle = styles.StatusErrorStyle
 				return m, nil
 			}
 			modal := NewInviteUserModal(m.apiClient, m.chatroom.Id, m)
 			return modal, modal.Init()
 		case "ctrl+p":
-			// only owner can promote; we approximate owner==admin+owner flag
-			isOwner := false
-			for _, u := range m.users {
-				if u.UserID == m.userID && u.IsOwner {
-					isOwner = true
-					break
-				}
-			}
-			if !isOwner {
-				m.flashMessage = "Only the owner can promote admins"
+			if !m.can(models.PermManageRoles) {
+				m.flashMessage = "Your role cannot promote admins"
 				m.flashStyle = styles.StatusErrorStyle
 				return m, nil
 			}
 			pm := NewPromoteUserModal(m.apiClient, m.chatroom.Id, m)
 			return pm, pm.Init()
 		case "ctrl+k":
-			if !m.currentUserIsAdmin() {
-				m.flashMessage = "Only admins can kick users"
+			if !m.can(models.PermKick) {
+				m.flashMessage = "Your role cannot kick users"
 				m.flashStyle = styles.StatusErrorStyle
 				return m, nil
 			}
 			km := NewKickUserModal(m.apiClient, m.chatroom.Id, m)
 			return km, km.Init()
+		case "ctrl+r":
+			return m, m.retryFailed()
 		case "ctrl+b":
-			if !m.currentUserIsAdmin() {
-				m.flashMessage = "Only admins can ban users"
+			if !m.can(models.PermBan) {
+				m.flashMessage = "Your role cannot ban users"
 				m.flashStyle = styles.StatusErrorStyle
 				return m, nil
 			}
 			bm := NewBanUserModal(m.apiClient, m.chatroom.Id, m)
 			return bm, bm.Init()
+		case "alt+u":
+			if !m.can(models.PermMute) {
+				m.flashMessage = "Your role cannot mute users"
+				m.flashStyle = styles.StatusErrorStyle
+				return m, nil
+			}
+			mm := NewMuteUserModal(m.apiClient, m.chatroom.Id, m)
+			return mm, mm.Init()
 		case "enter":
 			if m.searching {
 				q := strings.TrimSpace(m.searchInput.Value())
+				if m.globalSearch {
+					return m.runGlobalSearch(q)
+				}
 				return m, searchMessages(m.apiClient, m.chatroom.Id, q)
 			}
 			if m.sending {
@@ -272,17 +479,37 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
-			m.sending = true
-			m.input.Reset()
-			m.flashMessage = "Sending message..."
-			m.flashStyle = styles.StatusInfoStyle
+			if m.editingID != 0 {
+				m.sending = true
+				m.flashMessage = "Saving edit..."
+				m.flashStyle = styles.StatusInfoStyle
+				return m, editMessage(m.apiClient, m.chatroom.Id, m.editingID, content)
+			}
+			if strings.HasPrefix(content, "/") {
+				if !strings.HasPrefix(content, "//") {
+					return m.runCommand(content)
+				}
+				content = content[1:]
+			}
+			if m.thread != nil {
+				m.sending = true
+				m.input.Reset()
+				m.flashMessage = "Sending reply..."
+				m.flashStyle = styles.StatusInfoStyle
+				typingStoppedCmd := makeUserStatusCmd(m.wsSend, "stoppedTyping", m.username)
+				return m, tea.Batch(sendReply(m.apiClient, m.chatroom.Id, m.thread.Root.ID, m.username, content), typingStoppedCmd)
+			}
 
-			chatroomID := m.chatroom.Id
+			m.input.Reset()
 			typingStoppedCmd := makeUserStatusCmd(m.wsSend, "stoppedTyping", m.username)
-			return m, tea.Batch(sendMessage(m.apiClient, chatroomID, m.username, content), typingStoppedCmd)
+			return m, tea.Batch(m.queueMessage(content, 0, models.MessageKindText), typingStoppedCmd)
 		case "up", "down":
 
 		default:
+			// Editing a command replaces any stale status with its inline help.
+			if !m.searching && strings.HasPrefix(m.input.Value(), "/") {
+				m.flashMessage = ""
+			}
 			// Any other keypress in the main input area counts as "typing".
 			if !m.searching && m.wsSend != nil {
 				// user hit a key → bump typing sequence and reset "stopped typing" timer
@@ -303,36 +530,296 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case sendMessageResultMsg:
-		m.sending = false
 		if msg.err != nil {
-			m.flashMessage = fmt.Sprintf("Failed to send: %s", msg.err.Error())
-			m.flashStyle = styles.StatusErrorStyle
+			m.failOutgoing(msg.clientID, msg.err.Error())
 			return m, nil
 		}
-
-		if msg.message.Username == "" {
-			msg.message.Username = m.username
+		if _, pending := m.outbox[msg.clientID]; pending {
+			m.confirmOutgoing(msg.clientID, msg.message)
 		}
-
 		m.ensureParticipant(msg.message.Username)
 		m.wsStatusMessage = ""
-		m.flashMessage = fmt.Sprintf("Delivered at %s", time.Now().Format("15:04:05"))
-		m.flashStyle = styles.StatusSuccessStyle
-		m.refreshViewportContent(false)
+		return m, m.markRead()
+	case wsMessageAckMsg:
+		if _, pending := m.outbox[msg.ack.ClientID]; pending {
+			stored := models.MessageWithUser{ID: msg.ack.MessageID, CreatedAt: msg.ack.CreatedAt, ExpiresAt: msg.ack.ExpiresAt}
+			if idx := m.clientMessageIndex(msg.ack.ClientID); idx >= 0 {
+				stored.Content = m.messages[idx].Content
+				stored.Username = m.messages[idx].Username
+			}
+			// The broadcast copy may already have replaced the optimistic one.
+			if m.messageIndex(msg.ack.MessageID) < 0 {
+				m.confirmOutgoing(msg.ack.ClientID, stored)
+			} else {
+				delete(m.outbox, msg.ack.ClientID)
+				m.refreshViewportContent(true)
+			}
+		}
+		return m, tea.Batch(m.listenWS(m.wsChan), m.markRead())
+	case wsMessageNackMsg:
+		m.failOutgoing(msg.nack.ClientID, msg.nack.Error)
 		return m, m.listenWS(m.wsChan)
+	case ackTimeoutMsg:
+		return m, m.handleAckTimeout(msg)
+	case editMessageResultMsg:
+		m.sending = false
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to edit: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.editingID = 0
+		m.input.Reset()
+		m.input.Placeholder = "Write a message..."
+		m.replaceMessage(msg.message)
+		m.flashMessage = "Message edited"
+		m.flashStyle = styles.StatusSuccessStyle
+		m.refreshViewportContent(true)
+		return m, nil
 	case wsMessageMsg:
-		// Append incoming message, ensure participant, keep scroll position
-		if len(m.messages) > 0 {
-			last := m.messages[len(m.messages)-1]
-			if strings.EqualFold(last.Username, msg.message.Username) && last.Content == msg.message.Content && last.CreatedAt.Equal(msg.message.CreatedAt) {
-				return m, m.listenWS(m.wsChan)
-			}
+		// Our own messages replace their optimistic copy; anything already shown is skipped.
+		if idx := m.clientMessageIndex(msg.message.ClientID); idx >= 0 {
+			m.confirmOutgoing(msg.message.ClientID, msg.message)
+			return m, tea.Batch(m.listenWS(m.wsChan), m.markRead())
+		}
+		if msg.message.ID != 0 && m.messageIndex(msg.message.ID) >= 0 {
+			return m, m.listenWS(m.wsChan)
 		}
 		m.messages = append(m.messages, msg.message)
 		m.ensureParticipant(msg.message.Username)
 		m.refreshViewportContent(true)
 		// continue listening for the next websocket message
+		return m, tea.Batch(m.listenWS(m.wsChan), m.markRead())
+	case expiryTickMsg:
+		return m, m.handleExpiryTick(msg)
+	case wsMessageExpiredMsg:
+		m.removeExpired(msg.payload, "")
+		m.refreshViewportContent(true)
 		return m, m.listenWS(m.wsChan)
+	case messageTTLResultMsg:
+		m.handleMessageTTLResult(msg)
+		return m, nil
+	case memberActionMsg:
+		m.handleMemberAction(msg)
+		return m, nil
+	case topicResultMsg:
+		m.handleTopicResult(msg)
+		return m, nil
+	case attachmentUploadedMsg:
+		m.handleAttachmentUploaded(msg)
+		return m, nil
+	case pollCreatedMsg:
+		m.handlePollCreated(msg)
+		return m, nil
+	case pollVotedMsg:
+		m.handlePollVoted(msg)
+		return m, nil
+	case wsPollUpdatedMsg:
+		m.applyPollResults(msg.results, false)
+		return m, m.listenWS(m.wsChan)
+	case attachmentDownloadedMsg:
+		m.handleAttachmentDownloaded(msg)
+		return m, nil
+	case exportDoneMsg:
+		m.handleExportDone(msg)
+		return m, nil
+	case thumbnailMsg:
+		return m, m.handleThumbnail(msg)
+	case imageViewedMsg:
+		if msg.err != nil {
+			m.flashMessage = "Image viewer failed: " + msg.err.Error()
+			m.flashStyle = styles.StatusErrorStyle
+		}
+		return m, nil
+	case wsTopicUpdatedMsg:
+		m.chatroom.Topic = msg.payload.Topic
+		return m, m.listenWS(m.wsChan)
+	case wsRoomUpdatedMsg:
+		m.chatroom = msg.payload.Chatroom
+		return m, m.listenWS(m.wsChan)
+	case roomSettingsSavedMsg:
+		m.chatroom = msg.room
+		m.flashMessage = "Settings saved"
+		m.flashStyle = styles.StatusSuccessStyle
+		return m, nil
+	case wsMemberUpdatedMsg:
+		m.handleMemberUpdated(msg)
+		return m, m.listenWS(m.wsChan)
+	case wsRolesUpdatedMsg:
+		m.setRoles(msg.payload.Roles)
+		return m, m.listenWS(m.wsChan)
+	case rolesLoadedMsg:
+		m.handleRolesLoaded(msg)
+		return m, nil
+	case roleResultMsg:
+		m.handleRoleResult(msg)
+		return m, nil
+	case scheduledLoadedMsg:
+		m.handleScheduledLoaded(msg)
+		return m, nil
+	case scheduleResultMsg:
+		return m, m.handleScheduleResult(msg)
+	case scheduleCancelledMsg:
+		m.handleScheduleCancelled(msg)
+		return m, nil
+	case ownershipResultMsg:
+		m.handleOwnershipResult(msg)
+		return m, nil
+	case bansLoadedMsg:
+		m.handleBansLoaded(msg)
+		return m, nil
+	case unbanResultMsg:
+		m.handleUnbanResult(msg)
+		return m, nil
+	case readMarkedMsg:
+		if msg.err == nil && msg.messageID > m.lastReadID {
+			m.lastReadID = msg.messageID
+		}
+		return m, nil
+	case threadLoadedMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to open thread: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		thread := msg.thread
+		m.thread = &thread
+		m.showPins = false
+		m.showScheduled = false
+		m.showBans = false
+		m.editingID = 0
+		m.input.Placeholder = "Reply in thread..."
+		m.flashMessage = "Replying in thread (Esc to close)"
+		m.flashStyle = styles.StatusInfoStyle
+		return m, m.input.Focus()
+	case sendReplyResultMsg:
+		m.sending = false
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to reply: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		if m.thread != nil {
+			m.addThreadReply(m.thread.Root.ID, msg.message)
+		}
+		m.flashMessage = fmt.Sprintf("Replied at %s", time.Now().Format("15:04:05"))
+		m.flashStyle = styles.StatusSuccessStyle
+		return m, nil
+	case wsThreadReplyMsg:
+		m.setReplyCount(msg.payload.RootID, msg.payload.ReplyCount)
+		m.addThreadReply(msg.payload.RootID, msg.payload.Message)
+		m.ensureParticipant(msg.payload.Message.Username)
+		m.refreshViewportContent(true)
+		return m, m.listenWS(m.wsChan)
+	case reactionResultMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to react: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+		}
+		return m, nil
+	case wsReactionUpdatedMsg:
+		if idx := m.messageIndex(msg.payload.MessageID); idx >= 0 {
+			m.messages[idx].Reactions = msg.payload.Reactions
+			m.refreshViewportContent(true)
+		}
+		return m, m.listenWS(m.wsChan)
+	case deleteMessageResultMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to delete: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		if m.selectedID == msg.messageID {
+			m.selectedID = 0
+		}
+		m.flashMessage = "Message deleted"
+		m.flashStyle = styles.StatusSuccessStyle
+		return m, nil
+	case wsMessageEditedMsg:
+		m.replaceMessage(msg.message)
+		m.replaceThreadMessage(msg.message)
+		m.syncPinnedMessage(msg.message)
+		m.refreshViewportContent(true)
+		return m, m.listenWS(m.wsChan)
+	case wsMessageDeletedMsg:
+		m.replaceMessage(msg.message)
+		m.replaceThreadMessage(msg.message)
+		m.syncPinnedMessage(msg.message)
+		if m.editingID == msg.message.ID {
+			m.editingID = 0
+			m.input.Reset()
+			m.input.Placeholder = "Write a message..."
+			m.flashMessage = "The message you were editing was removed"
+			m.flashStyle = styles.StatusErrorStyle
+		}
+		m.refreshViewportContent(true)
+		return m, m.listenWS(m.wsChan)
+	case wsIgnoredMsg:
+		return m, m.listenWS(m.wsChan)
+	case wsMessagePinnedMsg:
+		m.addPin(msg.pin)
+		m.refreshViewportContent(true)
+		return m, m.listenWS(m.wsChan)
+	case wsMessageUnpinnedMsg:
+		m.removePin(msg.messageID)
+		m.refreshViewportContent(true)
+		return m, m.listenWS(m.wsChan)
+	case globalSearchResultMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Search failed: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.searchResults = msg.hits
+		if m.searchResults == nil {
+			m.searchResults = []models.SearchHit{}
+		}
+		m.resultCursor = 0
+		m.flashMessage = fmt.Sprintf("%d results for %q", len(msg.hits), msg.query)
+		m.flashStyle = styles.StatusInfoStyle
+		return m, nil
+	case pinsLoadedMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to load pins: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.pins = msg.pins
+		m.refreshViewportContent(true)
+		return m, nil
+	case pinResultMsg:
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to update pin: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		// The pins list itself follows the websocket events.
+		m.flashMessage = "Message unpinned"
+		if msg.pinned {
+			m.flashMessage = "Message pinned"
+		}
+		m.flashStyle = styles.StatusSuccessStyle
+		return m, nil
+	case messageContextMsg:
+		m.loadingOlder = false
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to load message history: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		// Ignore history loaded before the filter changed.
+		if msg.query != m.searchQuery {
+			return m, nil
+		}
+		m.prependMessages(msg.messages)
+		m.olderCursor = msg.cursor
+		if m.messageIndex(msg.targetID) < 0 && msg.cursor != nil {
+			m.flashMessage = "That message is too far back; scroll up to load more history"
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.scrollToMessage(msg.targetID)
+		return m, nil
 	case wsTypingQueueMsg:
 		switch n := len(msg.users); {
 		case n > 2:
@@ -367,7 +854,8 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.flashStyle = styles.StatusErrorStyle
 			return m, nil
 		}
-		m.messages = msg.messages
+		m.messages = msg.page.Messages
+		m.olderCursor = msg.page.NextCursor
 		m.searchQuery = msg.query
 		if strings.TrimSpace(msg.query) == "" {
 			m.flashMessage = ""
@@ -377,6 +865,20 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.refreshViewportContent(false)
 		return m, nil
+	case olderMessagesMsg:
+		m.loadingOlder = false
+		if msg.err != nil {
+			m.flashMessage = fmt.Sprintf("Failed to load older messages: %s", msg.err.Error())
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		// Ignore pages requested before the filter changed.
+		if msg.query != m.searchQuery {
+			return m, nil
+		}
+		m.olderCursor = msg.page.NextCursor
+		m.prependMessages(msg.page.Messages)
+		return m, nil
 	case typingStoppedMsg:
 		// Ignore stale timers for older typing sequences.
 		if msg.seq != m.typingSeq {
@@ -399,6 +901,12 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.viewport, viewportCmd = m.viewport.Update(msg)
 	cmds = append(cmds, viewportCmd)
 
+	// Scrolling to the top pulls in the previous page of history; reaching the
+	// bottom marks the room as read.
+	if _, isKey := msg.(tea.KeyMsg); isKey || isMouseMsg(msg) {
+		cmds = append(cmds, m.loadOlderAtTop(), m.markRead())
+	}
+
 	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "enter" && !m.searching {
 		return m, tea.Batch(cmds...)
 	}
@@ -416,7 +924,33 @@ func (m ChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// readMarkedMsg reports the outcome of moving the room's read marker.
+type readMarkedMsg struct {
+	messageID uint
+	err       error
+}
+
+// markRead reports the newest message as read once the conversation is scrolled
+// to the bottom. Search results are not the live conversation and are skipped.
+func (m ChatroomModel) markRead() tea.Cmd {
+	if m.searchQuery != "" || len(m.messages) == 0 || !m.viewport.AtBottom() {
+		return nil
+	}
+	latest := m.messages[len(m.messages)-1].ID
+	if latest <= m.lastReadID {
+		return nil
+	}
+	api, chatroomID := m.apiClient, m.chatroom.Id
+	return func() tea.Msg {
+		return readMarkedMsg{messageID: latest, err: api.MarkChatroomRead(chatroomID, latest)}
+	}
+}
+
 type wsMessageMsg struct{ message models.MessageWithUser }
+type wsMessageEditedMsg struct{ message models.MessageWithUser }
+type wsMessageDeletedMsg struct{ message models.MessageWithUser }
+type wsReactionUpdatedMsg struct{ payload models.ReactionUpdatePayload }
+type wsIgnoredMsg struct{} // event types this view does not handle; keep listening
 type wsClosedMsg struct{}
 type wsTypingQueueMsg struct{ users []string } // currently ws tracked users who are typing
 type wsJoinedMsg struct{ name string }
@@ -424,19 +958,75 @@ type wsLeftMsg struct{ name string }
 
 // search results
 type searchMessagesResultMsg struct {
-	messages []models.MessageWithUser
-	err      error
-	query    string
+	page  models.MessagePage
+	err   error
+	query string
 }
 
 func searchMessages(api *client.APIClient, chatroomID uint, query string) tea.Cmd {
 	return func() tea.Msg {
-		msgs, err := api.GetMessagesWithSearch(chatroomID, query)
-		return searchMessagesResultMsg{messages: msgs, err: err, query: query}
+		page, err := api.GetMessagesPage(chatroomID, query, 0, messagePageSize)
+		return searchMessagesResultMsg{page: page, err: err, query: query}
 	}
 }
 
-func (m ChatroomModel) leaveChatroom(api *client.APIClient, chatroomID uint) (tea.Model, tea.Cmd) {
+// olderMessagesMsg carries a page of history older than what the room already shows.
+type olderMessagesMsg struct {
+	page  models.MessagePage
+	err   error
+	query string
+}
+
+func loadOlderMessages(api *client.APIClient, chatroomID uint, query string, before uint) tea.Cmd {
+	return func() tea.Msg {
+		page, err := api.GetMessagesPage(chatroomID, query, before, messagePageSize)
+		return olderMessagesMsg{page: page, err: err, query: query}
+	}
+}
+
+func isMouseMsg(msg tea.Msg) bool {
+	_, ok := msg.(tea.MouseMsg)
+	return ok
+}
+
+// loadOlderAtTop requests the previous history page once the viewport reaches the top.
+func (m *ChatroomModel) loadOlderAtTop() tea.Cmd {
+	if m.loadingOlder || m.olderCursor == nil || !m.viewport.AtTop() {
+		return nil
+	}
+	m.loadingOlder = true
+	m.flashMessage = "Loading older messages..."
+	m.flashStyle = styles.StatusInfoStyle
+	return loadOlderMessages(m.apiClient, m.chatroom.Id, m.searchQuery, *m.olderCursor)
+}
+
+// prependMessages adds older history above the current messages while keeping
+// the lines the user is looking at in place.
+func (m *ChatroomModel) prependMessages(older []models.MessageWithUser) {
+	if m.flashMessage == "Loading older messages..." {
+		m.flashMessage = ""
+	}
+	// A page can overlap what is shown when messages arrived while it loaded.
+	shown := make(map[uint]bool, len(m.messages))
+	for _, message := range m.messages {
+		shown[message.ID] = true
+	}
+	older = slices.DeleteFunc(slices.Clone(older), func(message models.MessageWithUser) bool {
+		return shown[message.ID]
+	})
+	if len(older) == 0 {
+		return
+	}
+	previousTotal := m.viewport.TotalLineCount()
+	previousOffset := m.viewport.YOffset
+
+	m.messages = append(older, m.messages...)
+	m.viewport.SetContent(m.renderMessages())
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() - previousTotal + previousOffset)
+}
+
+// disconnect announces that the user left the room view and closes the websocket.
+func (m *ChatroomModel) disconnect() {
 	if m.wsSend != nil {
 		if cmd := makeUserStatusCmd(m.wsSend, "left", m.username); cmd != nil {
 			_ = cmd()
@@ -445,6 +1035,10 @@ func (m ChatroomModel) leaveChatroom(api *client.APIClient, chatroomID uint) (te
 	if m.wsCancel != nil {
 		m.wsCancel()
 	}
+}
+
+func (m ChatroomModel) leaveChatroom(api *client.APIClient, chatroomID uint) (tea.Model, tea.Cmd) {
+	m.disconnect()
 	err := api.LeaveChatroom(strconv.FormatUint(uint64(chatroomID), 10))
 	if err != nil {
 		m.flashMessage = "Failed to Leave Chatroom"
@@ -467,6 +1061,90 @@ func (m *ChatroomModel) listenWS(ch <-chan ws.WsEvent) tea.Cmd {
 				return wsClosedMsg{}
 			}
 			return wsMessageMsg{message: msg}
+		case "message_ack":
+			var ack models.MessageAck
+			if err := json.Unmarshal(event.Data, &ack); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageAckMsg{ack: ack}
+		case "message_nack":
+			var nack models.MessageNack
+			if err := json.Unmarshal(event.Data, &nack); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageNackMsg{nack: nack}
+		case "message_edited":
+			var msg models.MessageWithUser
+			if err := json.Unmarshal(event.Data, &msg); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageEditedMsg{message: msg}
+		case "message_deleted":
+			var msg models.MessageWithUser
+			if err := json.Unmarshal(event.Data, &msg); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageDeletedMsg{message: msg}
+		case "reaction_updated":
+			var payload models.ReactionUpdatePayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsReactionUpdatedMsg{payload: payload}
+		case "poll_updated":
+			var results models.PollResults
+			if err := json.Unmarshal(event.Data, &results); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsPollUpdatedMsg{results: results}
+		case "message_pinned":
+			var pin models.PinnedMessage
+			if err := json.Unmarshal(event.Data, &pin); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessagePinnedMsg{pin: pin}
+		case "message_expired":
+			var payload models.MessageExpiredPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageExpiredMsg{payload: payload}
+		case "message_unpinned":
+			var payload models.PinRemovedPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMessageUnpinnedMsg{messageID: payload.MessageID}
+		case "topic_updated":
+			var payload models.TopicUpdatedPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsTopicUpdatedMsg{payload: payload}
+		case "room_updated":
+			var payload models.RoomUpdatedPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsRoomUpdatedMsg{payload: payload}
+		case "member_updated":
+			var payload models.MemberUpdatedPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsMemberUpdatedMsg{payload: payload}
+		case "roles_updated":
+			var payload models.RolesUpdatedPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsRolesUpdatedMsg{payload: payload}
+		case "thread_reply":
+			var payload models.ThreadReplyPayload
+			if err := json.Unmarshal(event.Data, &payload); err != nil {
+				return wsIgnoredMsg{}
+			}
+			return wsThreadReplyMsg{payload: payload}
 		case "typing_queue":
 			var users []string
 			if err := json.Unmarshal(event.Data, &users); err != nil {
@@ -486,7 +1164,7 @@ func (m *ChatroomModel) listenWS(ch <-chan ws.WsEvent) tea.Cmd {
 			}
 			return wsLeftMsg{name: payload.Username}
 		default:
-			return wsClosedMsg{}
+			return wsIgnoredMsg{}
 		}
 	}
 }
@@ -520,7 +1198,17 @@ func (m ChatroomModel) View() string {
 	if m.chatroom.IsPublic {
 		visibility = "public"
 	}
-	summary := styles.SubtitleStyle.Render(fmt.Sprintf("%s | %d of %d participants", visibility, len(m.users), m.chatroom.MaxUserCount))
+	summaryText := fmt.Sprintf("%s | %d of %d participants", visibility, len(m.users), m.chatroom.MaxUserCount)
+	if m.chatroom.SlowMode > 0 {
+		summaryText += " | slow mode " + settingDuration(m.chatroom.SlowMode)
+	}
+	if m.chatroom.Topic != "" {
+		summaryText += " | " + m.chatroom.Topic
+	}
+	if m.width > 0 {
+		summaryText = ansi.Truncate(summaryText, m.width, "…")
+	}
+	summary := styles.SubtitleStyle.Render(summaryText)
 
 	conversation := m.viewport.View()
 	// Inject search UI before composing the row, so it becomes visible
@@ -532,8 +1220,29 @@ func (m ChatroomModel) View() string {
 		conversation = lipgloss.JoinVertical(lipgloss.Left, hint, "", conversation)
 	}
 
+	if m.searchResults != nil {
+		conversation = m.renderSearchResults(m.messageColumnWidth)
+	}
+
 	var sidebar string
-	if m.showSidebar {
+	switch {
+	case m.showScheduled && m.showSidebar:
+		sidebar = m.renderScheduledPane(m.sidebarWidth)
+	case m.showScheduled:
+		conversation = m.renderScheduledPane(m.messageColumnWidth)
+	case m.showBans && m.showSidebar:
+		sidebar = m.renderBansPane(m.sidebarWidth)
+	case m.showBans:
+		conversation = m.renderBansPane(m.messageColumnWidth)
+	case m.showPins && m.showSidebar:
+		sidebar = m.renderPinsPane(m.sidebarWidth)
+	case m.showPins:
+		conversation = m.renderPinsPane(m.messageColumnWidth)
+	case m.thread != nil && m.showSidebar:
+		sidebar = m.renderThreadPane(m.sidebarWidth)
+	case m.thread != nil:
+		conversation = m.renderThreadPane(m.messageColumnWidth)
+	case m.showSidebar:
 		sidebar = m.renderSidebar()
 	}
 
@@ -558,33 +1267,44 @@ func (m ChatroomModel) View() string {
 	if m.flashMessage != "" {
 		info = m.flashMessage
 		statusStyle = m.flashStyle
+	} else if hint := m.commandHint(); hint != "" {
+		info = hint
+		statusStyle = styles.MutedTextStyle
 	}
 
 	helpItems := []string{
 		styles.RenderKeyBinding("Esc", "Back"),
 		styles.RenderKeyBinding("Enter", "Send"),
-		styles.RenderKeyBinding("/ or Ctrl+F", "Search messages"),
+		styles.RenderKeyBinding("Tab", "Complete @mention or /command"),
+		styles.RenderKeyBinding("/help", "Commands"),
+		styles.RenderKeyBinding("Ctrl+F", "Search messages"),
+		styles.RenderKeyBinding("Alt+↑/↓", "Select message"),
+		styles.RenderKeyBinding("Alt+E", "Edit"),
+		styles.RenderKeyBinding("Alt+D", "Delete"),
+		styles.RenderKeyBinding("Alt+R", "Thread"),
+		styles.RenderKeyBinding("Alt+A", "React"),
+		styles.RenderKeyBinding("Alt+V", "Vote"),
+		styles.RenderKeyBinding("Alt+M", "Raw/formatted text"),
+		styles.RenderKeyBinding("Ctrl+T", "Pins"),
+		styles.RenderKeyBinding("Ctrl+R", "Retry failed"),
 		styles.RenderKeyBinding("Ctrl+L", "Leave Chatroom"),
 	}
-	// Admin-level actions: only show if current user is admin/owner
-	if m.currentUserIsAdmin() {
-		helpItems = append(helpItems,
-			styles.RenderKeyBinding("Ctrl+O", "Invite"),
-			styles.RenderKeyBinding("Ctrl+K", "Kick"),
-			styles.RenderKeyBinding("Ctrl+B", "Ban"),
-		)
-	}
-	// Owner-only action: promote
-	isOwner := false
-	for _, u := range m.users {
-		if u.UserID == m.userID && u.IsOwner {
-			isOwner = true
-			break
+	// Moderation actions: only show those the current user's role allows
+	for _, action := range []struct {
+		perm      models.Permissions
+		key, desc string
+	}{
+		{models.PermInvite, "Ctrl+O", "Invite"},
+		{models.PermKick, "Ctrl+K", "Kick"},
+		{models.PermBan, "Ctrl+B", "Ban"},
+		{models.PermMute, "Alt+U", "Mute"},
+		{models.PermPin, "Alt+P", "Pin/unpin"},
+		{models.PermManageRoles, "Ctrl+P", "Promote"},
+	} {
+		if m.can(action.perm) {
+			helpItems = append(helpItems, styles.RenderKeyBinding(action.key, action.desc))
 		}
 	}
-	if isOwner {
-		helpItems = append(helpItems, styles.RenderKeyBinding("Ctrl+P", "Promote"))
-	}
 	helpItems = append(helpItems, styles.RenderKeyBinding("Ctrl + c", "Quit"))
 	help := strings.Join(helpItems, styles.HelpStyle.Render("  "))
 
@@ -670,8 +1390,16 @@ func (m *ChatroomModel) refreshViewportContent(keepPosition bool) {
 }
 
 func (m ChatroomModel) renderMessages() string {
+	content, _ := m.renderMessageSections()
+	return content
+}
+
+// renderMessageSections renders the conversation and reports the line each message
+// starts on, so the viewport can scroll straight to a message.
+func (m ChatroomModel) renderMessageSections() (string, map[uint]int) {
+	offsets := make(map[uint]int, len(m.messages))
 	if len(m.messages) == 0 {
-		return styles.MutedTextStyle.Render("No messages yet. Say hi to get things started!")
+		return styles.MutedTextStyle.Render("No messages yet. Say hi to get things started!"), offsets
 	}
 
 	contentWidth := m.viewport.Width
@@ -681,14 +1409,22 @@ func (m ChatroomModel) renderMessages() string {
 
 	var sections []string
 	var prevDate string
+	lines := 0
+	add := func(section string) {
+		sections = append(sections, section)
+		lines += strings.Count(section, "\n") + 1
+	}
 
 	for _, message := range m.messages {
 		// Simple date separator when date changes
 		dateKey := message.CreatedAt.Format("2006-01-02")
 		if dateKey != prevDate {
-			sections = append(sections, styles.DateDividerStyle.Width(contentWidth).Render(formatDateSeparator(message.CreatedAt)))
+			add(styles.DateDividerStyle.Width(contentWidth).Render(formatDateSeparator(message.CreatedAt)))
 			prevDate = dateKey
 		}
+		if message.ID != 0 && message.ID == m.newSinceID {
+			add(styles.NewMessagesDividerStyle.Width(contentWidth).Render("── new messages ──"))
+		}
 
 		isSelf := strings.EqualFold(message.Username, m.username)
 		authorStyle := styles.MessageAuthorStyle
@@ -710,14 +1446,220 @@ func (m ChatroomModel) renderMessages() string {
 
 		author := authorStyle.Render(message.Username) + roleTag
 		timestamp := styles.MessageTimestampStyle.Render(message.CreatedAt.Format("15:04"))
+		content := message.Content
+		if message.DeletedAt != nil {
+			content = styles.MessageTombstoneStyle.Render(tombstoneText(message))
+		} else if message.EditedAt != nil {
+			timestamp += styles.MutedTextStyle.Render(" (edited)")
+		}
+		if m.isPinned(message.ID) {
+			timestamp += styles.PinMarkerStyle.Render(" 📌")
+		}
+		timestamp += expiryBadge(message)
+		timestamp += m.deliveryStatus(message)
 
-		// Single-line structure: "author HH:MM: content"; wrapped to viewport width
-		line := fmt.Sprintf("%s %s: %s", author, timestamp, message.Content)
-		wrapped := wrapText(line, contentWidth)
-		sections = append(sections, styles.MessageContainerStyle.Render(wrapped))
+		// "author HH:MM: content", wrapped to the viewport width; actions read "HH:MM * author content"
+		prefix := fmt.Sprintf("%s %s: ", author, timestamp)
+		if message.Kind == models.MessageKindAction && message.DeletedAt == nil {
+			prefix = fmt.Sprintf("%s %s %s ", timestamp, styles.ActionMarkerStyle.Render("*"), author)
+		}
+		var wrapped string
+		if message.DeletedAt != nil {
+			wrapped = wrapText(prefix+content, contentWidth)
+		} else {
+			wrapped = m.renderContent(prefix, message, contentWidth)
+		}
+		if message.Attachment != nil && message.DeletedAt == nil {
+			wrapped += "\n" + renderAttachment(message.Attachment, contentWidth)
+		}
+		if message.Poll != nil && message.DeletedAt == nil {
+			wrapped += "\n" + renderPoll(message.Poll, contentWidth)
+		}
+		if len(message.Reactions) > 0 && message.DeletedAt == nil {
+			wrapped += "\n" + m.renderReactions(message.Reactions)
+		}
+		if message.ReplyCount > 0 {
+			label := fmt.Sprintf("↳ %d replies", message.ReplyCount)
+			if message.ReplyCount == 1 {
+				label = "↳ 1 reply"
+			}
+			wrapped += "\n" + styles.ThreadReplyHintStyle.Render(label)
+		}
+		container := styles.MessageContainerStyle
+		if message.ID != 0 && message.ID == m.selectedID {
+			container = styles.MessageSelectedStyle
+		}
+		offsets[message.ID] = lines
+		add(container.Render(wrapped))
 	}
 
-	return strings.Join(sections, "\n")
+	return strings.Join(sections, "\n"), offsets
+}
+
+// moveSelection moves the message selection by delta, starting from the newest
+// message when nothing is selected yet.
+func (m *ChatroomModel) moveSelection(delta int) {
+	if len(m.messages) == 0 {
+		return
+	}
+	idx := m.messageIndex(m.selectedID)
+	switch {
+	case idx < 0 && delta < 0:
+		idx = len(m.messages) - 1
+	case idx < 0:
+		return
+	default:
+		idx += delta
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(m.messages) {
+		// moving past the newest message clears the selection
+		m.selectedID = 0
+		m.refreshViewportContent(true)
+		return
+	}
+	m.selectedID = m.messages[idx].ID
+	m.refreshViewportContent(true)
+}
+
+func (m ChatroomModel) messageIndex(id uint) int {
+	if id == 0 {
+		return -1
+	}
+	for i, message := range m.messages {
+		if message.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceMessage swaps in an updated copy of a message already shown in the room.
+func (m *ChatroomModel) replaceMessage(updated models.MessageWithUser) {
+	if idx := m.messageIndex(updated.ID); idx >= 0 {
+		m.messages[idx] = keepShownDetails(updated, m.messages[idx])
+	}
+}
+
+// keepShownDetails fills in what an edit or delete payload may leave out from the copy
+// already shown: thread stats always, and reactions, the attachment and the poll unless
+// the message was deleted.
+func keepShownDetails(updated, shown models.MessageWithUser) models.MessageWithUser {
+	updated.ReplyCount = shown.ReplyCount
+	if updated.DeletedAt == nil {
+		updated.Reactions = shown.Reactions
+		if updated.Attachment == nil {
+			updated.Attachment = shown.Attachment
+		}
+		if updated.Poll == nil {
+			updated.Poll = shown.Poll
+		}
+	}
+	return updated
+}
+
+// pickReaction toggles one of the palette reactions on the selected message.
+func (m ChatroomModel) pickReaction(key string) (tea.Model, tea.Cmd) {
+	m.reacting = false
+	m.flashMessage = ""
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+	choice, err := strconv.Atoi(key)
+	if err != nil || choice < 1 || choice > len(reactionPalette) {
+		return m, nil
+	}
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		return m, nil
+	}
+
+	emoji := reactionPalette[choice-1]
+	remove := false
+	for _, reaction := range m.messages[idx].Reactions {
+		if reaction.Emoji == emoji && slices.ContainsFunc(reaction.Usernames, func(name string) bool { return strings.EqualFold(name, m.username) }) {
+			remove = true
+		}
+	}
+	return m, toggleReaction(m.apiClient, m.chatroom.Id, m.selectedID, emoji, remove)
+}
+
+// renderReactions renders counts like "👍 3  🎉 1", highlighting the ones the user added.
+func (m ChatroomModel) renderReactions(reactions []models.ReactionCount) string {
+	parts := make([]string, 0, len(reactions))
+	for _, reaction := range reactions {
+		style := styles.ReactionStyle
+		if slices.ContainsFunc(reaction.Usernames, func(name string) bool { return strings.EqualFold(name, m.username) }) {
+			style = styles.ReactionSelfStyle
+		}
+		parts = append(parts, style.Render(fmt.Sprintf("%s %d", reaction.Emoji, reaction.Count)))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// confirmDelete asks for confirmation before deleting the selected message.
+func (m ChatroomModel) confirmDelete() (tea.Model, tea.Cmd) {
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		m.flashMessage = "Select a message first (Alt+↑/↓)"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	target := m.messages[idx]
+	if target.DeletedAt != nil {
+		return m, nil
+	}
+	if !strings.EqualFold(target.Username, m.username) && !m.can(models.PermDeleteMessages) {
+		m.flashMessage = "Your role cannot delete other users' messages"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.confirmingDeleteID = target.ID
+	m.flashMessage = "Delete selected message? (y to confirm, n to cancel)"
+	m.flashStyle = styles.StatusErrorStyle
+	return m, nil
+}
+
+// startEditing loads the selected message (or the user's most recent one) into the input.
+func (m ChatroomModel) startEditing() (tea.Model, tea.Cmd) {
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if strings.EqualFold(m.messages[i].Username, m.username) {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 || m.messages[idx].ID == 0 || m.messages[idx].DeletedAt != nil {
+		m.flashMessage = "No message of yours to edit"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	target := m.messages[idx]
+	if !strings.EqualFold(target.Username, m.username) {
+		m.flashMessage = "You can only edit your own messages"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if target.Kind == models.MessageKindPoll {
+		m.flashMessage = "Polls can't be edited"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+
+	m.searching = false
+	m.searchInput.Blur()
+	m.editingID = target.ID
+	m.selectedID = target.ID
+	m.input.SetValue(target.Content)
+	m.input.Placeholder = "Edit message..."
+	m.flashMessage = "Editing message (Enter to save, Esc to cancel)"
+	m.flashStyle = styles.StatusInfoStyle
+	m.refreshViewportContent(true)
+	return m, m.input.Focus()
 }
 
 func (m ChatroomModel) renderSidebar() string {
@@ -739,6 +1681,11 @@ func (m ChatroomModel) renderSidebar() string {
 		}
 		if user.IsAdmin {
 			badges = append(badges, styles.ParticipantBadgeAdminStyle.Render(" admin"))
+		} else if user.RoleID != nil {
+			badges = append(badges, styles.ParticipantBadgeAdminStyle.Render(" "+m.roleOf(user).Name))
+		}
+		if user.MutedAt(time.Now()) {
+			badges = append(badges, styles.ParticipantBadgeMutedStyle.Render(" muted"))
 		}
 		if strings.EqualFold(user.Name, m.username) {
 			badges = append(badges, styles.ParticipantBadgeYouStyle.Render(" you"))
@@ -766,40 +1713,26 @@ func (m *ChatroomModel) ensureParticipant(username string) {
 	m.users = append(m.users, models.UserChatroom{Name: username})
 }
 
-func (m ChatroomModel) currentUserIsAdmin() bool {
-	for _, u := range m.users {
-		if u.UserID == m.userID {
-			return u.IsAdmin || u.IsOwner
+func toggleReaction(apiClient *client.APIClient, chatroomID, messageID uint, emoji string, remove bool) tea.Cmd {
+	return func() tea.Msg {
+		if remove {
+			return reactionResultMsg{err: apiClient.RemoveReaction(chatroomID, messageID, emoji)}
 		}
+		return reactionResultMsg{err: apiClient.AddReaction(chatroomID, messageID, emoji)}
 	}
-	return false
 }
 
-func sendMessage(apiClient *client.APIClient, chatroomID uint, username, content string) tea.Cmd {
+func deleteMessage(apiClient *client.APIClient, chatroomID, messageID uint) tea.Cmd {
 	return func() tea.Msg {
-		result, err := apiClient.SendMessage(strconv.FormatUint(uint64(chatroomID), 10), content)
-		if err != nil {
-			return sendMessageResultMsg{err: err}
-		}
-
-		var message models.MessageWithUser
-		payload, ok := result["Message"]
-		if ok {
-			raw, _ := json.Marshal(payload)
-			_ = json.Unmarshal(raw, &message)
-		}
-
-		if message.Username == "" {
-			message.Username = username
-		}
-		if message.Content == "" {
-			message.Content = content
-		}
-		if message.CreatedAt.IsZero() {
-			message.CreatedAt = time.Now()
-		}
+		err := apiClient.DeleteMessage(chatroomID, messageID)
+		return deleteMessageResultMsg{messageID: messageID, err: err}
+	}
+}
 
-		return sendMessageResultMsg{message: message}
+func editMessage(apiClient *client.APIClient, chatroomID, messageID uint, content string) tea.Cmd {
+	return func() tea.Msg {
+		message, err := apiClient.EditMessage(chatroomID, messageID, content)
+		return editMessageResultMsg{message: message, err: err}
 	}
 }
 
@@ -838,6 +1771,17 @@ func sidebarRank(user models.UserChatroom, current string) int {
 	}
 }
 
+func tombstoneText(message models.MessageWithUser) string {
+	switch {
+	case message.DeletedBy == "":
+		return "message removed"
+	case strings.EqualFold(message.DeletedBy, message.Username):
+		return "message removed by its author"
+	default:
+		return "message removed by " + message.DeletedBy
+	}
+}
+
 func formatDateSeparator(t time.Time) string {
 	today := time.Now().Format("2006-01-02")
 	msgDate := t.Format("2006-01-02")
@@ -847,61 +1791,12 @@ func formatDateSeparator(t time.Time) string {
 	return "— " + t.Format("Mon Jan 02") + " —"
 }
 
+// wrapText wraps text to width display cells. Widths are measured on screen, so
+// styled text and wide characters wrap correctly; words longer than a line are broken.
+// Line breaks already in the text are kept.
 func wrapText(text string, width int) string {
 	if width <= 0 {
 		return text
 	}
-
-	words := strings.Fields(text)
-	if len(words) == 0 {
-		return text
-	}
-
-	var lines []string
-	var line strings.Builder
-	lineLen := 0
-
-	for _, word := range words {
-		wordLen := len(word)
-		if lineLen > 0 && lineLen+1+wordLen > width {
-			lines = append(lines, line.String())
-			line.Reset()
-			lineLen = 0
-		}
-		if lineLen > 0 {
-			line.WriteByte(' ')
-			lineLen++
-		}
-		if wordLen > width {
-			for start := 0; start < wordLen; start += width {
-				end := start + width
-				end = min(end, wordLen)
-				chunk := word[start:end]
-				if lineLen > 0 {
-					lines = append(lines, line.String())
-					line.Reset()
-					lineLen = 0
-				}
-				if len(chunk) == width {
-					lines = append(lines, chunk)
-				} else {
-					line.WriteString(chunk)
-					lineLen += len(chunk)
-				}
-			}
-			continue
-		}
-		line.WriteString(word)
-		lineLen += wordLen
-	}
-
-	if lineLen > 0 {
-		lines = append(lines, line.String())
-	}
-
-	if len(lines) == 0 {
-		return text
-	}
-
-	return strings.Join(lines, "\n")
+	return ansi.Wrap(text, width, "")
 }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+func TestKeepShownDetails(t *testing.T) {
+	deleted := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	attachment := &models.Attachment{ID: 4, Name: "plan.pdf"}
+	poll := &models.PollResults{MessageID: 7}
+	reactions := []models.ReactionCount{{Emoji: "👍", Count: 2}}
+	shown := models.MessageWithUser{ID: 7, Content: "the plan", ReplyCount: 3, Reactions: reactions, Attachment: attachment, Poll: poll}
+
+	tests := []struct {
+		name    string
+		updated models.MessageWithUser
+		want    models.MessageWithUser
+	}{
+		{
+			name:    "edit without details keeps the shown ones",
+			updated: models.MessageWithUser{ID: 7, Content: "the plan, v2"},
+			want:    models.MessageWithUser{ID: 7, Content: "the plan, v2", ReplyCount: 3, Reactions: reactions, Attachment: attachment, Poll: poll},
+		},
+		{
+			name:    "edit with an attachment keeps it",
+			updated: models.MessageWithUser{ID: 7, Content: "v3", Attachment: &models.Attachment{ID: 4, Name: "plan-v3.pdf"}},
+			want:    models.MessageWithUser{ID: 7, Content: "v3", ReplyCount: 3, Reactions: reactions, Attachment: &models.Attachment{ID: 4, Name: "plan-v3.pdf"}, Poll: poll},
+		},
+		{
+			name:    "tombstone keeps only its thread",
+			updated: models.MessageWithUser{ID: 7, DeletedAt: &deleted},
+			want:    models.MessageWithUser{ID: 7, DeletedAt: &deleted, ReplyCount: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepShownDetails(tt.updated, shown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keepShownDetails = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/api/ws"
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages are rendered as soon as they are sent and tracked in the outbox until the
+// server acknowledges them. Each carries a client-generated id, so resending after a
+// timeout or over the REST fallback never posts the message twice.
+
+const (
+	ackTimeout      = 5 * time.Second
+	maxSendAttempts = 3
+)
+
+// outgoingMessage is a sent message that has not been acknowledged yet.
+type outgoingMessage struct {
+	content  string
+	ttl      time.Duration
+	kind     string
+	attempts int
+	failed   bool
+	reason   string
+}
+
+type ackTimeoutMsg struct {
+	clientID string
+	attempt  int
+}
+
+type wsMessageAckMsg struct{ ack models.MessageAck }
+type wsMessageNackMsg struct{ nack models.MessageNack }
+
+func newClientID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func waitForAck(clientID string, attempt int) tea.Cmd {
+	return tea.Tick(ackTimeout, func(time.Time) tea.Msg {
+		return ackTimeoutMsg{clientID: clientID, attempt: attempt}
+	})
+}
+
+// sendOverSocket pushes a send_message event. If the socket is gone it falls back to
+// the REST endpoint with the same client id.
+func sendOverSocket(send func(ws.WsEvent) error, api *client.APIClient, chatroomID uint, username string, req models.SendMessageRequest) tea.Cmd {
+	return func() tea.Msg {
+		evt, err := ws.NewEvent("send_message", req)
+		if err == nil && send(evt) == nil {
+			return nil
+		}
+		return sendMessage(api, chatroomID, username, req)()
+	}
+}
+
+func sendMessage(apiClient *client.APIClient, chatroomID uint, username string, req models.SendMessageRequest) tea.Cmd {
+	clientID, content := req.ClientID, req.Content
+	return func() tea.Msg {
+		result, err := apiClient.SendMessage(strconv.FormatUint(uint64(chatroomID), 10), req)
+		if err != nil {
+			return sendMessageResultMsg{clientID: clientID, err: err}
+		}
+
+		var message models.MessageWithUser
+		payload, ok := result["Message"]
+		if ok {
+			raw, _ := json.Marshal(payload)
+			_ = json.Unmarshal(raw, &message)
+		}
+
+		if message.Username == "" {
+			message.Username = username
+		}
+		if message.Content == "" {
+			message.Content = content
+		}
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = time.Now()
+		}
+
+		return sendMessageResultMsg{clientID: clientID, message: message}
+	}
+}
+
+// queueMessage renders content straight away and starts delivering it. A non-zero ttl
+// sends it as an ephemeral message; kind is one of the models.MessageKind values.
+func (m *ChatroomModel) queueMessage(content string, ttl time.Duration, kind string) tea.Cmd {
+	clientID := newClientID()
+	m.outbox[clientID] = &outgoingMessage{content: content, ttl: ttl, kind: kind}
+	m.messages = append(m.messages, models.MessageWithUser{
+		ClientID:  clientID,
+		Username:  m.username,
+		Content:   content,
+		CreatedAt: time.Now(),
+		ExpiresAt: m.expiryFor(ttl),
+		Kind:      kind,
+	})
+	m.newSinceID = 0
+	m.refreshViewportContent(false)
+	return m.deliver(clientID)
+}
+
+// deliver (re)sends an outbox entry, preferring the websocket.
+func (m *ChatroomModel) deliver(clientID string) tea.Cmd {
+	out, ok := m.outbox[clientID]
+	if !ok {
+		return nil
+	}
+	out.attempts++
+	out.failed = false
+	out.reason = ""
+	req := models.SendMessageRequest{ClientID: clientID, Content: out.content, TTL: uint(out.ttl / time.Second), Kind: out.kind}
+	if m.wsSend == nil {
+		return sendMessage(m.apiClient, m.chatroom.Id, m.username, req)
+	}
+	return tea.Batch(
+		sendOverSocket(m.wsSend, m.apiClient, m.chatroom.Id, m.username, req),
+		waitForAck(clientID, out.attempts),
+	)
+}
+
+// retryFailed resends every message that could not be delivered.
+func (m *ChatroomModel) retryFailed() tea.Cmd {
+	var cmds []tea.Cmd
+	for clientID, out := range m.outbox {
+		if out.failed {
+			out.attempts = 0
+			cmds = append(cmds, m.deliver(clientID))
+		}
+	}
+	if len(cmds) == 0 {
+		m.flashMessage = "No failed messages to retry"
+		m.flashStyle = styles.StatusInfoStyle
+		return nil
+	}
+	m.flashMessage = fmt.Sprintf("Retrying %d message(s)...", len(cmds))
+	m.flashStyle = styles.StatusInfoStyle
+	m.refreshViewportContent(true)
+	return tea.Batch(cmds...)
+}
+
+// handleAckTimeout resends a message the server has not acknowledged, giving up
+// after maxSendAttempts.
+func (m *ChatroomModel) handleAckTimeout(msg ackTimeoutMsg) tea.Cmd {
+	out, ok := m.outbox[msg.clientID]
+	if !ok || out.failed || out.attempts != msg.attempt {
+		return nil
+	}
+	if out.attempts >= maxSendAttempts {
+		m.failOutgoing(msg.clientID, "no response from server")
+		return nil
+	}
+	return m.deliver(msg.clientID)
+}
+
+// confirmOutgoing swaps the optimistic copy of a message for the stored one.
+func (m *ChatroomModel) confirmOutgoing(clientID string, stored models.MessageWithUser) {
+	delete(m.outbox, clientID)
+	idx := m.clientMessageIndex(clientID)
+	if idx < 0 {
+		return
+	}
+	local := m.messages[idx]
+	if stored.Username == "" {
+		stored.Username = local.Username
+	}
+	if stored.Content == "" {
+		stored.Content = local.Content
+	}
+	if stored.Kind == "" {
+		stored.Kind = local.Kind
+	}
+	stored.ClientID = clientID
+	m.messages[idx] = stored
+	m.refreshViewportContent(true)
+}
+
+func (m *ChatroomModel) failOutgoing(clientID, reason string) {
+	out, ok := m.outbox[clientID]
+	if !ok {
+		return
+	}
+	out.failed = true
+	out.reason = reason
+	m.flashMessage = fmt.Sprintf("Message not sent: %s (Ctrl+R to retry)", reason)
+	m.flashStyle = styles.StatusErrorStyle
+	m.refreshViewportContent(true)
+}
+
+func (m ChatroomModel) clientMessageIndex(clientID string) int {
+	if clientID == "" {
+		return -1
+	}
+	for i, message := range m.messages {
+		if message.ClientID == clientID {
+			return i
+		}
+	}
+	return -1
+}
+
+// deliveryStatus is the marker shown next to messages still in the outbox.
+func (m ChatroomModel) deliveryStatus(message models.MessageWithUser) string {
+	out, ok := m.outbox[message.ClientID]
+	if message.ClientID == "" || !ok {
+		return ""
+	}
+	if out.failed {
+		return styles.StatusErrorStyle.Render(" (failed: " + out.reason + ")")
+	}
+	return styles.MutedTextStyle.Render(" (sending…)")
+}
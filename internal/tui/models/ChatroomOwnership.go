@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The owner hands the room over with "/transfer <user>", which only offers it: the
+// member becomes owner once they run "/ownership accept". Role changes reach every
+// open room through member_updated events, which keep the sidebar current.
+
+// ownerOfferTTL mirrors how long the server keeps an ownership offer open.
+const ownerOfferTTL = 24 * time.Hour
+
+type ownershipResultMsg struct {
+	answer string
+	err    error
+}
+
+func answerOwnership(api *client.APIClient, chatroomID uint, answer string, userID uint) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		switch answer {
+		case "accept":
+			err = api.AcceptOwnership(chatroomID)
+		case "decline":
+			err = api.DeclineOwnership(chatroomID)
+		case "withdraw":
+			err = api.WithdrawOwnership(strconv.FormatUint(uint64(chatroomID), 10), strconv.FormatUint(uint64(userID), 10))
+		}
+		return ownershipResultMsg{answer: answer, err: err}
+	}
+}
+
+func (m ChatroomModel) runDemote(args string) (tea.Model, tea.Cmd) {
+	if !m.currentUserIsOwner() {
+		m.flashMessage = "Only the owner can demote admins"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	return m.runOnMember("demote", args)
+}
+
+func (m ChatroomModel) runTransfer(args string) (tea.Model, tea.Cmd) {
+	if !m.currentUserIsOwner() {
+		m.flashMessage = "Only the owner can hand over the room"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	return m.runOnMember("transfer", args)
+}
+
+// runOwnership answers an offer of the room, or withdraws the owner's own offer.
+func (m ChatroomModel) runOwnership(args string) (tea.Model, tea.Cmd) {
+	answer := strings.ToLower(args)
+	pending := m.chatroom.PendingOwnerID
+	switch answer {
+	case "accept", "decline":
+		if pending == nil || *pending != m.userID {
+			m.flashMessage = "You have not been offered this room"
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+	case "withdraw":
+		if !m.currentUserIsOwner() || pending == nil {
+			m.flashMessage = "There is no ownership offer to withdraw"
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+	default:
+		m.flashUsage("ownership")
+		return m, nil
+	}
+	var target uint
+	if pending != nil {
+		target = *pending
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Running /ownership %s...", answer)
+	m.flashStyle = styles.StatusInfoStyle
+	return m, answerOwnership(m.apiClient, m.chatroom.Id, answer, target)
+}
+
+func (m *ChatroomModel) handleOwnershipResult(msg ownershipResultMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("/ownership %s failed: %s", msg.answer, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	// Accepting is confirmed by the owner_changed event.
+	switch msg.answer {
+	case "decline":
+		m.flashMessage = "Declined ownership of the room"
+	case "withdraw":
+		m.flashMessage = "Ownership offer withdrawn"
+	default:
+		return
+	}
+	m.chatroom.PendingOwnerID = nil
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+func demotableCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	for _, name := range otherMemberCandidates(m, "") {
+		if member, ok := m.findMember(name); ok && member.BuiltInRoleName() == models.RoleAdmin {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func ownershipCandidates(m ChatroomModel, _ string) []string {
+	if m.currentUserIsOwner() {
+		return []string{"withdraw"}
+	}
+	return []string{"accept", "decline"}
+}
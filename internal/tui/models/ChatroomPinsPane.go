@@ -0,0 +1,278 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The pins pane shares the sidebar slot with the thread pane. Pins are loaded when the
+// room opens so pinned messages can be marked in the conversation, and are kept in sync
+// through the message_pinned/message_unpinned events.
+
+type pinsLoadedMsg struct {
+	pins []models.PinnedMessage
+	err  error
+}
+
+type pinResultMsg struct {
+	messageID uint
+	pinned    bool
+	err       error
+}
+
+// messageContextMsg carries the history between the oldest loaded message and a message the
+// user jumped to (from the pins pane or a search result), loaded under the filter query.
+type messageContextMsg struct {
+	messages []models.MessageWithUser
+	cursor   *uint
+	targetID uint
+	query    string
+	err      error
+}
+
+type wsMessagePinnedMsg struct{ pin models.PinnedMessage }
+type wsMessageUnpinnedMsg struct{ messageID uint }
+
+func loadPins(api *client.APIClient, chatroomID uint) tea.Cmd {
+	return func() tea.Msg {
+		pins, err := api.GetPins(chatroomID)
+		return pinsLoadedMsg{pins: pins, err: err}
+	}
+}
+
+func togglePin(api *client.APIClient, chatroomID, messageID uint, pin bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if pin {
+			err = api.PinMessage(chatroomID, messageID)
+		} else {
+			err = api.UnpinMessage(chatroomID, messageID)
+		}
+		return pinResultMsg{messageID: messageID, pinned: pin, err: err}
+	}
+}
+
+// pinContextPageSize is the page size used when paging back to a pinned message, and
+// maxContextPages how far back a jump may page before giving up.
+const (
+	pinContextPageSize = 100
+	maxContextPages    = 10
+)
+
+// loadHistoryUntil pages back from before until the page holding targetID is loaded, or
+// maxContextPages have been read.
+func loadHistoryUntil(api *client.APIClient, chatroomID, before, targetID uint, query string) tea.Cmd {
+	return func() tea.Msg {
+		var loaded []models.MessageWithUser
+		cursor := &before
+		for pages := 0; cursor != nil && *cursor > targetID && pages < maxContextPages; pages++ {
+			page, err := api.GetMessagesPage(chatroomID, query, *cursor, pinContextPageSize)
+			if err != nil {
+				return messageContextMsg{err: err}
+			}
+			loaded = append(page.Messages, loaded...)
+			cursor = page.NextCursor
+		}
+		return messageContextMsg{messages: loaded, cursor: cursor, targetID: targetID, query: query}
+	}
+}
+
+func (m ChatroomModel) isPinned(messageID uint) bool {
+	return m.pinIndex(messageID) >= 0
+}
+
+func (m ChatroomModel) pinIndex(messageID uint) int {
+	for i, pin := range m.pins {
+		if pin.Message.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// togglePinSelected pins the selected message, or unpins it when it already is.
+func (m ChatroomModel) togglePinSelected() (tea.Model, tea.Cmd) {
+	if !m.can(models.PermPin) {
+		m.flashMessage = "Your role cannot pin messages"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		m.flashMessage = "Select a message first (Alt+↑/↓)"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if m.messages[idx].DeletedAt != nil {
+		m.flashMessage = "Deleted messages can't be pinned"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	pin := !m.isPinned(m.selectedID)
+	m.flashMessage = "Unpinning message..."
+	if pin {
+		m.flashMessage = "Pinning message..."
+	}
+	m.flashStyle = styles.StatusInfoStyle
+	return m, togglePin(m.apiClient, m.chatroom.Id, m.selectedID, pin)
+}
+
+func (m *ChatroomModel) openPins() {
+	m.thread = nil
+	m.showScheduled = false
+	m.showBans = false
+	m.showPins = true
+	m.pinCursor = 0
+	m.flashMessage = "↑/↓ to browse pins, Enter to jump, Esc to close"
+	m.flashStyle = styles.StatusInfoStyle
+	if len(m.pins) == 0 {
+		m.flashMessage = "No pinned messages yet"
+	}
+}
+
+// updatePins handles keys while the pins pane has focus.
+func (m ChatroomModel) updatePins(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		m.pinCursor = max(m.pinCursor-1, 0)
+	case "down":
+		m.pinCursor = min(m.pinCursor+1, max(len(m.pins)-1, 0))
+	case "enter":
+		return m.jumpToPin()
+	case "esc", "ctrl+t":
+		m.showPins = false
+		m.flashMessage = ""
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m ChatroomModel) jumpToPin() (tea.Model, tea.Cmd) {
+	if m.pinCursor >= len(m.pins) {
+		return m, nil
+	}
+	m.showPins = false
+	cmd := m.jumpToMessage(m.pins[m.pinCursor].Message)
+	return m, cmd
+}
+
+// jumpToMessage selects a message in the conversation, loading older history first
+// when it is not on screen yet. Replies are not part of that history: they open their
+// thread instead, with the root selected if it is already loaded.
+func (m *ChatroomModel) jumpToMessage(message models.MessageWithUser) tea.Cmd {
+	if m.searchQuery != "" {
+		m.flashMessage = "Clear the search filter before jumping to a message"
+		m.flashStyle = styles.StatusErrorStyle
+		return nil
+	}
+	if message.ParentID != nil {
+		rootID := *message.ParentID
+		if m.messageIndex(rootID) >= 0 {
+			m.scrollToMessage(rootID)
+		}
+		m.flashMessage = "Opening thread..."
+		m.flashStyle = styles.StatusInfoStyle
+		return loadThread(m.apiClient, m.chatroom.Id, rootID)
+	}
+
+	if m.messageIndex(message.ID) >= 0 || m.olderCursor == nil {
+		m.scrollToMessage(message.ID)
+		return nil
+	}
+	m.loadingOlder = true
+	m.flashMessage = "Loading message history..."
+	m.flashStyle = styles.StatusInfoStyle
+	return loadHistoryUntil(m.apiClient, m.chatroom.Id, *m.olderCursor, message.ID, m.searchQuery)
+}
+
+// scrollToMessage selects a message and scrolls it to the top of the viewport.
+func (m *ChatroomModel) scrollToMessage(id uint) {
+	if m.messageIndex(id) < 0 {
+		m.flashMessage = "That message is no longer in the history"
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.selectedID = id
+	content, offsets := m.renderMessageSections()
+	m.viewport.SetContent(content)
+	m.viewport.SetYOffset(offsets[id])
+	m.flashMessage = ""
+}
+
+func (m *ChatroomModel) addPin(pin models.PinnedMessage) {
+	if m.isPinned(pin.Message.ID) {
+		return
+	}
+	m.pins = append([]models.PinnedMessage{pin}, m.pins...)
+}
+
+func (m *ChatroomModel) removePin(messageID uint) {
+	if idx := m.pinIndex(messageID); idx >= 0 {
+		m.pins = append(m.pins[:idx], m.pins[idx+1:]...)
+		m.pinCursor = min(m.pinCursor, max(len(m.pins)-1, 0))
+	}
+}
+
+// syncPinnedMessage refreshes a pin's copy of a message that was edited or deleted.
+func (m *ChatroomModel) syncPinnedMessage(updated models.MessageWithUser) {
+	idx := m.pinIndex(updated.ID)
+	if idx < 0 {
+		return
+	}
+	if updated.DeletedAt != nil {
+		m.removePin(updated.ID)
+		return
+	}
+	m.pins[idx].Message = keepShownDetails(updated, m.pins[idx].Message)
+}
+
+func (m ChatroomModel) renderPinsPane(width int) string {
+	if width <= 0 {
+		width = styles.SidebarStyle.GetWidth()
+	}
+	innerWidth := max(width-4, 12)
+
+	lines := []string{
+		styles.SidebarTitleStyle.Render(fmt.Sprintf("Pinned (%d)", len(m.pins))),
+		styles.MutedTextStyle.Render("Enter to jump, Esc to close"),
+		"",
+	}
+	if len(m.pins) == 0 {
+		lines = append(lines, styles.MutedTextStyle.Render("Nothing pinned yet."))
+	}
+	cursorLine := 0
+	for i, pin := range m.pins {
+		pointer := "  "
+		authorStyle := styles.MessageAuthorStyle
+		if i == m.pinCursor {
+			pointer = styles.KeyStyle.Render("> ")
+			authorStyle = styles.ListItemTitleSelectedStyle
+			cursorLine = len(lines)
+		}
+		header := pointer + authorStyle.Render(pin.Message.Username) + " " +
+			styles.MessageTimestampStyle.Render(pin.Message.CreatedAt.Format("Jan 2 15:04"))
+		body := strings.Split(wrapText(pin.Message.Content, innerWidth), "\n")
+		if len(body) > 2 {
+			body = append(body[:2], "…")
+		}
+		lines = append(lines, header)
+		for _, line := range body {
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, "  "+styles.MutedTextStyle.Render("pinned by "+pin.PinnedBy), "")
+	}
+
+	// Keep the pin under the cursor visible when the list is taller than the pane.
+	if height := m.viewport.Height; height > 0 && len(lines) > height {
+		start := min(cursorLine, len(lines)-height)
+		lines = lines[start : start+height]
+	}
+	return styles.ThreadPaneStyle.Copy().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+)
+
+func TestMessageContextMerge(t *testing.T) {
+	cursor := uint(5)
+	tests := []struct {
+		name      string
+		filter    string
+		msg       messageContextMsg
+		wantIDs   []uint
+		wantFlash string
+	}{
+		{
+			name:    "older messages go first, shown ones are skipped",
+			msg:     messageContextMsg{messages: []models.MessageWithUser{{ID: 7}, {ID: 8}, {ID: 10}}, targetID: 7},
+			wantIDs: []uint{7, 8, 10, 11},
+		},
+		{
+			name:      "target beyond the page limit",
+			msg:       messageContextMsg{messages: []models.MessageWithUser{{ID: 8}}, cursor: &cursor, targetID: 2},
+			wantIDs:   []uint{8, 10, 11},
+			wantFlash: "That message is too far back; scroll up to load more history",
+		},
+		{
+			name:    "loaded before the filter changed",
+			filter:  "deploy",
+			msg:     messageContextMsg{messages: []models.MessageWithUser{{ID: 7}}, targetID: 7},
+			wantIDs: []uint{10, 11},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ChatroomModel{searchQuery: tt.filter, messages: []models.MessageWithUser{{ID: 10}, {ID: 11}}}
+			model, _ := m.Update(tt.msg)
+			m = model.(ChatroomModel)
+			var ids []uint
+			for _, message := range m.messages {
+				ids = append(ids, message.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("messages = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.wantFlash != "" && m.flashMessage != tt.wantFlash {
+				t.Errorf("flash = %q, want %q", m.flashMessage, tt.wantFlash)
+			}
+		})
+	}
+}
+
+func TestJumpToReplyOpensThread(t *testing.T) {
+	root, older := uint(3), uint(50)
+	m := ChatroomModel{olderCursor: &older, messages: []models.MessageWithUser{{ID: 60}}}
+	cmd := m.jumpToMessage(models.MessageWithUser{ID: 40, ParentID: &root})
+	if cmd == nil {
+		t.Fatal("jumping to a reply should load its thread")
+	}
+	if m.loadingOlder {
+		t.Error("jumping to a reply should not page through the room's history")
+	}
+}
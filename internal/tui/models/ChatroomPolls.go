@@ -0,0 +1,388 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Polls are created with /poll and voted on with the Alt+V picker: number keys pick
+// options (several in a multiple-choice poll), x closes the poll.
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 10
+	// pollBarWidth is the widest a result bar gets.
+	pollBarWidth = 20
+)
+
+// pollCreatedMsg reports the outcome of /poll.
+type pollCreatedMsg struct {
+	message models.MessageWithUser
+	err     error
+}
+
+// pollVotedMsg reports a vote or close from the picker; results carry MyVotes.
+type pollVotedMsg struct {
+	results models.PollResults
+	closed  bool
+	err     error
+}
+
+type wsPollUpdatedMsg struct{ results models.PollResults }
+
+func (m ChatroomModel) runPoll(args string) (tea.Model, tea.Cmd) {
+	words, quoted, ok := splitQuoted(args)
+	if !ok {
+		m.flashMessage = "Unterminated quote"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	req := models.CreatePollRequest{ClientID: newClientID()}
+	var texts []string
+	for i := 0; i < len(words); i++ {
+		if quoted[i] || !strings.HasPrefix(words[i], "-") {
+			texts = append(texts, words[i])
+			continue
+		}
+		switch words[i] {
+		case "-multi":
+			req.MultiChoice = true
+		case "-anon":
+			req.Anonymous = true
+		case "-closes":
+			if i+1 >= len(words) {
+				m.flashUsage("poll")
+				return m, nil
+			}
+			i++
+			d, err := parseTTL(words[i])
+			if err != nil || d == 0 {
+				m.flashMessage = fmt.Sprintf("Invalid close time %q (e.g. 30m, 2h, 1d)", words[i])
+				m.flashStyle = styles.StatusErrorStyle
+				return m, nil
+			}
+			closesAt := time.Now().Add(d)
+			req.ClosesAt = &closesAt
+		default:
+			m.flashMessage = fmt.Sprintf("Unknown option %s (use -multi, -anon or -closes)", words[i])
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+	}
+	if len(texts) < 1+minPollOptions || len(texts) > 1+maxPollOptions {
+		m.flashMessage = fmt.Sprintf("A poll needs a question and %d-%d options, e.g. /poll \"Lunch?\" \"Pizza\" \"Tacos\"", minPollOptions, maxPollOptions)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	req.Question, req.Options = texts[0], texts[1:]
+
+	m.input.Reset()
+	m.flashMessage = "Creating poll..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, createPoll(m.apiClient, m.chatroom.Id, req)
+}
+
+// splitQuoted splits command arguments on spaces, keeping "double" or 'single' quoted
+// runs together. quoted reports which words were quoted, so "-multi" in quotes is text;
+// ok is false when a quote is left open.
+func splitQuoted(s string) (words []string, quoted []bool, ok bool) {
+	var word strings.Builder
+	var quote rune
+	inWord, wasQuoted := false, false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			quoted = append(quoted, wasQuoted)
+		}
+		word.Reset()
+		inWord, wasQuoted = false, false
+	}
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inWord, wasQuoted = r, true, true
+		case r == ' ' || r == '\t':
+			flush()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, nil, false
+	}
+	flush()
+	return words, quoted, true
+}
+
+func createPoll(api *client.APIClient, chatroomID uint, req models.CreatePollRequest) tea.Cmd {
+	return func() tea.Msg {
+		message, err := api.CreatePoll(chatroomID, req)
+		return pollCreatedMsg{message: message, err: err}
+	}
+}
+
+func (m *ChatroomModel) handlePollCreated(msg pollCreatedMsg) {
+	if msg.err != nil {
+		m.flashMessage = "Failed to create poll: " + msg.err.Error()
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	// The websocket broadcast may have delivered the message already.
+	if m.messageIndex(msg.message.ID) < 0 {
+		m.messages = append(m.messages, msg.message)
+		m.refreshViewportContent(false)
+	}
+	m.flashMessage = "Poll created (Alt+V to vote)"
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+// startVoting opens the vote picker on the selected poll, or the newest open poll when
+// no message is selected.
+func (m ChatroomModel) startVoting() (tea.Model, tea.Cmd) {
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if poll := m.messages[i].Poll; poll != nil && m.messages[i].DeletedAt == nil && !pollClosed(poll) {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 || m.messages[idx].Poll == nil || m.messages[idx].DeletedAt != nil {
+		m.flashMessage = "Select a poll first (Alt+↑/↓)"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if pollClosed(m.messages[idx].Poll) {
+		m.flashMessage = "This poll is closed"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.votingID = m.messages[idx].ID
+	m.selectedID = m.votingID
+	m.flashMessage = m.voteHint(m.messages[idx])
+	m.flashStyle = styles.StatusInfoStyle
+	m.refreshViewportContent(true)
+	return m, nil
+}
+
+func (m ChatroomModel) voteHint(message models.MessageWithUser) string {
+	keys := fmt.Sprintf("1-%d", len(message.Poll.Options))
+	if len(message.Poll.Options) == maxPollOptions {
+		keys = "1-9, 0"
+	}
+	hint := "Vote: " + keys + " to pick"
+	if message.Poll.MultiChoice {
+		hint = "Vote: " + keys + " to toggle"
+	}
+	if m.canClosePoll(message) {
+		hint += " · x to close the poll"
+	}
+	return hint + " · Esc when done"
+}
+
+func (m ChatroomModel) canClosePoll(message models.MessageWithUser) bool {
+	return strings.EqualFold(message.Username, m.username) || m.can(models.PermDeleteMessages)
+}
+
+// pickVote handles a key while the vote picker is open. Single-choice polls take one
+// key; picking your current answer again withdraws it. Multiple-choice polls stay open
+// so several options can be toggled.
+func (m ChatroomModel) pickVote(key string) (tea.Model, tea.Cmd) {
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+	idx := m.messageIndex(m.votingID)
+	if idx < 0 || m.messages[idx].Poll == nil || key == "esc" {
+		m.votingID = 0
+		m.flashMessage = ""
+		return m, nil
+	}
+	message := m.messages[idx]
+	poll := message.Poll
+
+	if key == "x" && m.canClosePoll(message) {
+		m.votingID = 0
+		m.flashMessage = "Closing poll..."
+		m.flashStyle = styles.StatusInfoStyle
+		return m, closePoll(m.apiClient, m.chatroom.Id, message.ID)
+	}
+	choice, err := strconv.Atoi(key)
+	if choice == 0 && err == nil {
+		choice = 10
+	}
+	if err != nil || choice > len(poll.Options) {
+		return m, nil // ignore stray keys; Esc leaves the picker
+	}
+
+	option := poll.Options[choice-1].ID
+	var votes []uint
+	switch {
+	case poll.MultiChoice && slices.Contains(poll.MyVotes, option):
+		votes = slices.DeleteFunc(slices.Clone(poll.MyVotes), func(id uint) bool { return id == option })
+	case poll.MultiChoice:
+		votes = append(slices.Clone(poll.MyVotes), option)
+	case !slices.Equal(poll.MyVotes, []uint{option}):
+		votes = []uint{option}
+	}
+	if !poll.MultiChoice {
+		m.votingID = 0
+		m.flashMessage = "Voting..."
+		m.flashStyle = styles.StatusInfoStyle
+	}
+	return m, votePoll(m.apiClient, m.chatroom.Id, message.ID, votes)
+}
+
+func votePoll(api *client.APIClient, chatroomID, messageID uint, optionIDs []uint) tea.Cmd {
+	return func() tea.Msg {
+		results, err := api.VotePoll(chatroomID, messageID, optionIDs)
+		return pollVotedMsg{results: results, err: err}
+	}
+}
+
+func closePoll(api *client.APIClient, chatroomID, messageID uint) tea.Cmd {
+	return func() tea.Msg {
+		results, err := api.ClosePoll(chatroomID, messageID)
+		return pollVotedMsg{results: results, closed: true, err: err}
+	}
+}
+
+func (m *ChatroomModel) handlePollVoted(msg pollVotedMsg) {
+	if msg.err != nil {
+		m.votingID = 0
+		m.flashMessage = "Poll update failed: " + msg.err.Error()
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	if msg.closed {
+		m.applyPollResults(msg.results, false)
+		m.flashMessage = "Poll closed"
+		m.flashStyle = styles.StatusSuccessStyle
+		return
+	}
+	m.applyPollResults(msg.results, true)
+	if m.votingID != 0 {
+		if idx := m.messageIndex(m.votingID); idx >= 0 {
+			m.flashMessage = m.voteHint(m.messages[idx])
+			m.flashStyle = styles.StatusInfoStyle
+		}
+		return
+	}
+	m.flashMessage = "Vote recorded"
+	if len(msg.results.MyVotes) == 0 {
+		m.flashMessage = "Vote withdrawn"
+	}
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+// applyPollResults swaps in new tallies wherever the poll is shown. Broadcast results
+// don't say how this user voted, so unless own is set the known votes are kept.
+func (m *ChatroomModel) applyPollResults(results models.PollResults, own bool) {
+	apply := func(message *models.MessageWithUser) {
+		if message.ID != results.MessageID || message.Poll == nil {
+			return
+		}
+		updated := results
+		if !own {
+			updated.MyVotes = message.Poll.MyVotes
+		}
+		message.Poll = &updated
+	}
+	for i := range m.messages {
+		apply(&m.messages[i])
+	}
+	if m.thread != nil {
+		apply(&m.thread.Root)
+	}
+	for i := range m.pins {
+		apply(&m.pins[i].Message)
+	}
+	m.refreshViewportContent(true)
+}
+
+// pollMark is a radio button for single-choice polls and a checkbox for the others.
+func pollMark(multiChoice, picked bool) string {
+	switch {
+	case multiChoice && picked:
+		return "☒"
+	case multiChoice:
+		return "☐"
+	case picked:
+		return "●"
+	}
+	return "○"
+}
+
+func pollClosed(poll *models.PollResults) bool {
+	return poll.Closed || (poll.ClosesAt != nil && !time.Now().Before(*poll.ClosesAt))
+}
+
+// renderPoll draws a poll's options as numbered rows with result bars, marking the
+// user's picks, and a summary line below.
+func renderPoll(poll *models.PollResults, width int) string {
+	labelWidth := 0
+	for _, option := range poll.Options {
+		labelWidth = max(labelWidth, ansi.StringWidth(option.Text))
+	}
+	labelWidth = min(labelWidth, max(width/3, 8), 28)
+	barWidth := min(max(width-labelWidth-18, 5), pollBarWidth)
+
+	lines := make([]string, 0, len(poll.Options)+1)
+	for i, option := range poll.Options {
+		mine := slices.Contains(poll.MyVotes, option.ID)
+		mark := pollMark(poll.MultiChoice, mine)
+		share := 0.0
+		if poll.Voters > 0 {
+			share = float64(option.Votes) / float64(poll.Voters)
+		}
+		filled := int(share*float64(barWidth) + 0.5)
+		label := ansi.Truncate(option.Text, labelWidth, "…")
+		label += strings.Repeat(" ", labelWidth-ansi.StringWidth(label))
+
+		labelStyle := styles.MessageContentStyle
+		if mine {
+			labelStyle = styles.PollOptionSelfStyle
+		}
+		line := fmt.Sprintf("%2d %s %s ", i+1, labelStyle.Render(mark+" "+label),
+			styles.PollBarStyle.Render(strings.Repeat("█", filled))+styles.PollBarEmptyStyle.Render(strings.Repeat("░", barWidth-filled)))
+		line += fmt.Sprintf("%3d %3.0f%%", option.Votes, share*100)
+		if len(option.Voters) > 0 {
+			line += styles.MutedTextStyle.Render("  " + strings.Join(option.Voters, ", "))
+		}
+		lines = append(lines, ansi.Truncate(line, width, "…"))
+	}
+
+	summary := []string{fmt.Sprintf("%d voters", poll.Voters)}
+	if poll.Voters == 1 {
+		summary[0] = "1 voter"
+	}
+	if poll.MultiChoice {
+		summary = append(summary, "multiple choice")
+	}
+	if poll.Anonymous {
+		summary = append(summary, "anonymous")
+	}
+	switch {
+	case pollClosed(poll):
+		summary = append(summary, "closed")
+	case poll.ClosesAt != nil:
+		summary = append(summary, "closes in "+formatTTL(time.Until(*poll.ClosesAt)))
+	}
+	lines = append(lines, styles.MutedTextStyle.Render("📊 "+strings.Join(summary, " · ")))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,299 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The room's roles are loaded when it opens and kept in sync through roles_updated
+// events. The commands and keys a member is offered follow their role's permissions;
+// "/roles" lists the roles and "/role", "/newrole", "/editrole" and "/delrole" manage them.
+
+type rolesLoadedMsg struct {
+	roles []models.Role
+	err   error
+}
+
+// roleResultMsg reports the outcome of a role command; done is flashed on success.
+type roleResultMsg struct {
+	action string
+	done   string
+	err    error
+}
+
+type wsRolesUpdatedMsg struct{ payload models.RolesUpdatedPayload }
+
+func loadRoles(api *client.APIClient, chatroomID uint) tea.Cmd {
+	return func() tea.Msg {
+		roles, err := api.GetRoles(chatroomID)
+		return rolesLoadedMsg{roles: roles, err: err}
+	}
+}
+
+func (m *ChatroomModel) handleRolesLoaded(msg rolesLoadedMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to load roles: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.setRoles(msg.roles)
+}
+
+// setRoles replaces the room's roles. Members whose custom role is gone fall back to
+// the member role, as they do on the server.
+func (m *ChatroomModel) setRoles(roles []models.Role) {
+	m.roles = roles
+	for i, u := range m.users {
+		if u.RoleID == nil {
+			continue
+		}
+		if _, ok := m.roleByID(*u.RoleID); !ok {
+			m.users[i].RoleID = nil
+		}
+	}
+}
+
+func (m ChatroomModel) roleByID(id uint) (models.Role, bool) {
+	for _, role := range m.roles {
+		if role.ID == id {
+			return role, true
+		}
+	}
+	return models.Role{}, false
+}
+
+func (m ChatroomModel) findRole(name string) (models.Role, bool) {
+	for _, role := range m.roles {
+		if strings.EqualFold(role.Name, name) {
+			return role, true
+		}
+	}
+	return models.Role{}, false
+}
+
+// roleOf returns the role a member holds, or its built-in defaults until the room's
+// roles are loaded.
+func (m ChatroomModel) roleOf(uc models.UserChatroom) models.Role {
+	name := uc.BuiltInRoleName()
+	if name == "" {
+		if role, ok := m.roleByID(*uc.RoleID); ok {
+			return role
+		}
+		name = models.RoleMember
+	}
+	for _, role := range m.roles {
+		if role.BuiltIn && role.Name == name {
+			return role
+		}
+	}
+	for _, role := range models.BuiltInRoles(m.chatroom.Id) {
+		if role.Name == name {
+			return role
+		}
+	}
+	return models.Role{}
+}
+
+// can reports whether the current user's role grants perm.
+func (m ChatroomModel) can(perm models.Permissions) bool {
+	for _, u := range m.users {
+		if u.UserID == m.userID {
+			return m.roleOf(u).Permissions.Has(perm)
+		}
+	}
+	return perm == 0
+}
+
+// moderates reports whether the current user's role holds a moderator permission, as
+// the server decides who @here and @admins are for.
+func (m ChatroomModel) moderates() bool {
+	for _, u := range m.users {
+		if u.UserID == m.userID {
+			return m.roleOf(u).Permissions.Moderates()
+		}
+	}
+	return false
+}
+
+func roleCandidates(m ChatroomModel, _ string) []string {
+	var names []string
+	for _, role := range m.roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func (m ChatroomModel) runRoles(args string) (tea.Model, tea.Cmd) {
+	m.input.Reset()
+	m.flashStyle = styles.StatusInfoStyle
+	if args != "" {
+		role, ok := m.findRole(args)
+		if !ok {
+			m.flashMessage = fmt.Sprintf("No role named %s", args)
+			m.flashStyle = styles.StatusErrorStyle
+			return m, nil
+		}
+		m.flashMessage = fmt.Sprintf("%s (rank %d): %s", role.Name, role.Rank, strings.Join(role.Permissions.Names(), ", "))
+		return m, nil
+	}
+	var roles []string
+	for _, role := range m.roles {
+		roles = append(roles, fmt.Sprintf("%s (%d)", role.Name, role.Rank))
+	}
+	m.flashMessage = "Roles: " + strings.Join(roles, " · ") + "  (/roles <role> for its permissions)"
+	return m, nil
+}
+
+func (m ChatroomModel) runRole(args string) (tea.Model, tea.Cmd) {
+	ident, name, _ := strings.Cut(args, " ")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		m.flashUsage("role")
+		return m, nil
+	}
+	member, ok := m.resolveMember("role", ident)
+	if !ok {
+		return m, nil
+	}
+	role, ok := m.findRole(name)
+	if !ok {
+		m.flashMessage = fmt.Sprintf("No role named %s (see /roles)", name)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Giving %s the %s role...", member.Name, role.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	api, chatroomID := m.apiClient, m.chatroom.Id
+	return m, func() tea.Msg {
+		room := strconv.FormatUint(uint64(chatroomID), 10)
+		user := strconv.FormatUint(uint64(member.UserID), 10)
+		err := api.AssignRole(room, user, role.ID)
+		return roleResultMsg{action: "role", done: fmt.Sprintf("%s is now %s", member.Name, role.Name), err: err}
+	}
+}
+
+func (m ChatroomModel) runNewRole(args string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 || len(fields) > 3 {
+		m.flashUsage("newrole")
+		return m, nil
+	}
+	rank, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		m.flashUsage("newrole")
+		return m, nil
+	}
+	var perms *models.Permissions
+	if len(fields) == 3 {
+		if perms, err = parsePermissionList(fields[2]); err != nil {
+			m.flashPermissionUsage("newrole")
+			return m, nil
+		}
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Creating the %s role...", fields[0])
+	m.flashStyle = styles.StatusInfoStyle
+	api, chatroomID, name := m.apiClient, m.chatroom.Id, fields[0]
+	return m, func() tea.Msg {
+		err := api.CreateRole(chatroomID, name, uint(rank), perms)
+		return roleResultMsg{action: "newrole", done: "Created the " + name + " role", err: err}
+	}
+}
+
+// runEditRole changes a role's rank, given as a number, and replaces its permissions,
+// given as a comma-separated list, in either order.
+func (m ChatroomModel) runEditRole(args string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 || len(fields) > 3 {
+		m.flashUsage("editrole")
+		return m, nil
+	}
+	role, ok := m.findRole(fields[0])
+	if !ok {
+		m.flashMessage = fmt.Sprintf("No role named %s (see /roles)", fields[0])
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	var rank *uint
+	var perms *models.Permissions
+	for _, field := range fields[1:] {
+		if n, err := strconv.ParseUint(field, 10, 32); err == nil && rank == nil {
+			r := uint(n)
+			rank = &r
+			continue
+		}
+		p, err := parsePermissionList(field)
+		if err != nil || perms != nil {
+			m.flashPermissionUsage("editrole")
+			return m, nil
+		}
+		perms = p
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Updating the %s role...", role.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	api, chatroomID := m.apiClient, m.chatroom.Id
+	return m, func() tea.Msg {
+		err := api.UpdateRole(chatroomID, role.ID, rank, perms)
+		return roleResultMsg{action: "editrole", done: "Updated the " + role.Name + " role", err: err}
+	}
+}
+
+func (m ChatroomModel) runDelRole(args string) (tea.Model, tea.Cmd) {
+	if args == "" || strings.Contains(args, " ") {
+		m.flashUsage("delrole")
+		return m, nil
+	}
+	role, ok := m.findRole(args)
+	if !ok {
+		m.flashMessage = fmt.Sprintf("No role named %s (see /roles)", args)
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = fmt.Sprintf("Deleting the %s role...", role.Name)
+	m.flashStyle = styles.StatusInfoStyle
+	api, chatroomID := m.apiClient, m.chatroom.Id
+	return m, func() tea.Msg {
+		err := api.DeleteRole(chatroomID, role.ID)
+		return roleResultMsg{action: "delrole", done: "Deleted the " + role.Name + " role", err: err}
+	}
+}
+
+// flashPermissionUsage shows a role command's usage along with the permission names.
+func (m *ChatroomModel) flashPermissionUsage(name string) {
+	m.flashUsage(name)
+	m.flashMessage += "; permissions are " + strings.Join(models.PermissionNames(), ", ")
+}
+
+// parsePermissionList reads a comma-separated list of permission names, e.g. "send,pin".
+func parsePermissionList(list string) (*models.Permissions, error) {
+	var perms models.Permissions
+	for _, name := range strings.Split(list, ",") {
+		perm, err := models.ParsePermission(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		perms |= perm
+	}
+	return &perms, nil
+}
+
+// handleRoleResult reports a role command; the roles_updated and member_updated
+// events that follow carry the change itself.
+func (m *ChatroomModel) handleRoleResult(msg roleResultMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("/%s failed: %s", msg.action, msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.flashMessage = msg.done
+	m.flashStyle = styles.StatusSuccessStyle
+}
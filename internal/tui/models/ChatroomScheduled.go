@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// "/schedule <when> <text>" queues a message that the server posts later; "/schedule" on
+// its own opens the pending list in the sidebar slot, where Delete cancels one.
+
+// scheduleLayouts are the accepted forms of <when>, read in local time. A bare time of
+// day means its next occurrence.
+var scheduleLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+const scheduleTimeOfDay = "15:04"
+
+type scheduledLoadedMsg struct {
+	scheduled []models.ScheduledMessage
+	err       error
+}
+
+type scheduleResultMsg struct {
+	scheduled models.ScheduledMessage
+	err       error
+}
+
+type scheduleCancelledMsg struct {
+	id  uint
+	err error
+}
+
+func loadScheduled(api *client.APIClient, chatroomID uint) tea.Cmd {
+	return func() tea.Msg {
+		scheduled, err := api.GetScheduledMessages(chatroomID)
+		return scheduledLoadedMsg{scheduled: scheduled, err: err}
+	}
+}
+
+func scheduleMessage(api *client.APIClient, chatroomID uint, content string, sendAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		scheduled, err := api.ScheduleMessage(chatroomID, content, sendAt)
+		return scheduleResultMsg{scheduled: scheduled, err: err}
+	}
+}
+
+func cancelScheduled(api *client.APIClient, chatroomID, id uint) tea.Cmd {
+	return func() tea.Msg {
+		return scheduleCancelledMsg{id: id, err: api.CancelScheduledMessage(chatroomID, id)}
+	}
+}
+
+// parseScheduleTime reads <when> relative to now.
+func parseScheduleTime(when string, now time.Time) (time.Time, error) {
+	for _, layout := range scheduleLayouts {
+		if t, err := time.ParseInLocation(layout, when, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation(scheduleTimeOfDay, when, time.Local); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("use YYYY-MM-DDTHH:MM or HH:MM")
+}
+
+// runSchedule handles "/schedule [<when> <text>]" typed into the input.
+func (m ChatroomModel) runSchedule(args string) (tea.Model, tea.Cmd) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		m.openScheduled()
+		return m, loadScheduled(m.apiClient, m.chatroom.Id)
+	}
+	when, text, _ := strings.Cut(args, " ")
+	text = strings.TrimSpace(text)
+	if text == "" {
+		m.flashMessage = "Usage: /schedule 2026-10-20T09:00 message"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	sendAt, err := parseScheduleTime(when, time.Now())
+	if err != nil {
+		m.flashMessage = fmt.Sprintf("Invalid time %q: %s", when, err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	if !sendAt.After(time.Now()) {
+		m.flashMessage = "That time has already passed"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	m.input.Reset()
+	m.flashMessage = "Scheduling message..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, scheduleMessage(m.apiClient, m.chatroom.Id, text, sendAt)
+}
+
+func (m *ChatroomModel) openScheduled() {
+	m.thread = nil
+	m.showPins = false
+	m.showBans = false
+	m.showScheduled = true
+	m.scheduledCursor = 0
+	m.flashMessage = "↑/↓ to browse, Delete to cancel, Esc to close"
+	m.flashStyle = styles.StatusInfoStyle
+}
+
+// updateScheduled handles keys while the scheduled pane has focus.
+func (m ChatroomModel) updateScheduled(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up":
+		m.scheduledCursor = max(m.scheduledCursor-1, 0)
+	case "down":
+		m.scheduledCursor = min(m.scheduledCursor+1, max(len(m.scheduled)-1, 0))
+	case "delete":
+		if m.scheduledCursor >= len(m.scheduled) {
+			return m, nil
+		}
+		m.flashMessage = "Cancelling scheduled message..."
+		m.flashStyle = styles.StatusInfoStyle
+		return m, cancelScheduled(m.apiClient, m.chatroom.Id, m.scheduled[m.scheduledCursor].ID)
+	case "esc":
+		m.showScheduled = false
+		m.flashMessage = ""
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *ChatroomModel) handleScheduledLoaded(msg scheduledLoadedMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to load scheduled messages: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	m.scheduled = msg.scheduled
+	m.scheduledCursor = min(m.scheduledCursor, max(len(m.scheduled)-1, 0))
+	if len(m.scheduled) == 0 && m.showScheduled {
+		m.flashMessage = "No scheduled messages"
+		m.flashStyle = styles.StatusInfoStyle
+	}
+}
+
+func (m *ChatroomModel) handleScheduleResult(msg scheduleResultMsg) tea.Cmd {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to schedule: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return nil
+	}
+	m.flashMessage = fmt.Sprintf("Scheduled for %s (/schedule to list)", msg.scheduled.SendAt.Local().Format("Mon Jan 2 15:04"))
+	m.flashStyle = styles.StatusSuccessStyle
+	if m.showScheduled {
+		return loadScheduled(m.apiClient, m.chatroom.Id)
+	}
+	return nil
+}
+
+func (m *ChatroomModel) handleScheduleCancelled(msg scheduleCancelledMsg) {
+	if msg.err != nil {
+		m.flashMessage = fmt.Sprintf("Failed to cancel: %s", msg.err.Error())
+		m.flashStyle = styles.StatusErrorStyle
+		return
+	}
+	for i, scheduled := range m.scheduled {
+		if scheduled.ID == msg.id {
+			m.scheduled = append(m.scheduled[:i], m.scheduled[i+1:]...)
+			break
+		}
+	}
+	m.scheduledCursor = min(m.scheduledCursor, max(len(m.scheduled)-1, 0))
+	m.flashMessage = "Scheduled message cancelled"
+	m.flashStyle = styles.StatusSuccessStyle
+}
+
+func (m ChatroomModel) renderScheduledPane(width int) string {
+	if width <= 0 {
+		width = styles.SidebarStyle.GetWidth()
+	}
+	innerWidth := max(width-4, 12)
+
+	lines := []string{
+		styles.SidebarTitleStyle.Render(fmt.Sprintf("Scheduled (%d)", len(m.scheduled))),
+		styles.MutedTextStyle.Render("Delete to cancel, Esc to close"),
+		"",
+	}
+	if len(m.scheduled) == 0 {
+		lines = append(lines, styles.MutedTextStyle.Render("Nothing scheduled."))
+	}
+	cursorLine := 0
+	for i, scheduled := range m.scheduled {
+		pointer := "  "
+		timeStyle := styles.MessageTimestampStyle
+		if i == m.scheduledCursor {
+			pointer = styles.KeyStyle.Render("> ")
+			timeStyle = styles.ListItemTitleSelectedStyle
+			cursorLine = len(lines)
+		}
+		lines = append(lines, pointer+timeStyle.Render(scheduled.SendAt.Local().Format("Mon Jan 2 15:04")))
+		body := strings.Split(wrapText(scheduled.Content, innerWidth), "\n")
+		if len(body) > 2 {
+			body = append(body[:2], "…")
+		}
+		for _, line := range body {
+			lines = append(lines, "  "+line)
+		}
+		lines = append(lines, "")
+	}
+
+	if height := m.viewport.Height; height > 0 && len(lines) > height {
+		start := min(cursorLine, len(lines)-height)
+		lines = lines[start : start+height]
+	}
+	return styles.ThreadPaneStyle.Copy().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
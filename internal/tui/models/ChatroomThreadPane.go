@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The thread pane lives inside ChatroomModel: it takes over the sidebar slot (or the
+// conversation column on narrow terminals) while the room keeps receiving live updates.
+
+type threadLoadedMsg struct {
+	thread models.Thread
+	err    error
+}
+
+type sendReplyResultMsg struct {
+	message models.MessageWithUser
+	err     error
+}
+
+type wsThreadReplyMsg struct{ payload models.ThreadReplyPayload }
+
+func loadThread(api *client.APIClient, chatroomID, messageID uint) tea.Cmd {
+	return func() tea.Msg {
+		thread, err := api.GetThread(chatroomID, messageID)
+		return threadLoadedMsg{thread: thread, err: err}
+	}
+}
+
+func sendReply(api *client.APIClient, chatroomID, rootID uint, username, content string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := api.SendReply(chatroomID, rootID, content)
+		if err != nil {
+			return sendReplyResultMsg{err: err}
+		}
+
+		var message models.MessageWithUser
+		if payload, ok := result["Message"]; ok {
+			raw, _ := json.Marshal(payload)
+			_ = json.Unmarshal(raw, &message)
+		}
+		if message.Username == "" {
+			message.Username = username
+		}
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = time.Now()
+		}
+		return sendReplyResultMsg{message: message}
+	}
+}
+
+// openThread loads the thread of the selected message.
+func (m ChatroomModel) openThread() (tea.Model, tea.Cmd) {
+	idx := m.messageIndex(m.selectedID)
+	if idx < 0 {
+		m.flashMessage = "Select a message first (Alt+↑/↓)"
+		m.flashStyle = styles.StatusErrorStyle
+		return m, nil
+	}
+	target := m.messages[idx]
+	rootID := target.ID
+	if target.ParentID != nil {
+		rootID = *target.ParentID
+	}
+	m.flashMessage = "Opening thread..."
+	m.flashStyle = styles.StatusInfoStyle
+	return m, loadThread(m.apiClient, m.chatroom.Id, rootID)
+}
+
+func (m *ChatroomModel) closeThread() {
+	m.thread = nil
+	m.input.Placeholder = "Write a message..."
+	m.flashMessage = ""
+}
+
+// addThreadReply records a reply in the open thread, ignoring duplicates from the
+// websocket echo of our own replies.
+func (m *ChatroomModel) addThreadReply(rootID uint, reply models.MessageWithUser) {
+	if m.thread == nil || m.thread.Root.ID != rootID {
+		return
+	}
+	for _, existing := range m.thread.Replies {
+		if existing.ID == reply.ID {
+			return
+		}
+	}
+	m.thread.Replies = append(m.thread.Replies, reply)
+}
+
+// replaceThreadMessage swaps in an edited or deleted message if it is part of the open thread.
+func (m *ChatroomModel) replaceThreadMessage(updated models.MessageWithUser) {
+	if m.thread == nil {
+		return
+	}
+	if m.thread.Root.ID == updated.ID {
+		m.thread.Root = keepShownDetails(updated, m.thread.Root)
+		return
+	}
+	for i, reply := range m.thread.Replies {
+		if reply.ID == updated.ID {
+			m.thread.Replies[i] = keepShownDetails(updated, reply)
+			return
+		}
+	}
+}
+
+// setReplyCount updates the reply counter shown under a root message.
+func (m *ChatroomModel) setReplyCount(rootID uint, count int) {
+	if idx := m.messageIndex(rootID); idx >= 0 {
+		m.messages[idx].ReplyCount = count
+	}
+}
+
+func (m ChatroomModel) renderThreadPane(width int) string {
+	if width <= 0 {
+		width = styles.SidebarStyle.GetWidth()
+	}
+	innerWidth := max(width-2, 12)
+
+	title := styles.SidebarTitleStyle.Render("Thread")
+	hint := styles.MutedTextStyle.Render("Esc to close")
+	lines := []string{title, hint, ""}
+	lines = append(lines, m.renderThreadMessage(m.thread.Root, innerWidth))
+
+	count := len(m.thread.Replies)
+	label := fmt.Sprintf("%d replies", count)
+	if count == 1 {
+		label = "1 reply"
+	}
+	lines = append(lines, styles.DateDividerStyle.Width(innerWidth).Render("— "+label+" —"))
+	for _, reply := range m.thread.Replies {
+		lines = append(lines, m.renderThreadMessage(reply, innerWidth))
+	}
+
+	// Keep the newest replies visible when the thread is taller than the pane.
+	body := strings.Split(strings.Join(lines, "\n"), "\n")
+	if height := m.viewport.Height; height > 0 && len(body) > height {
+		body = append([]string{title}, body[len(body)-height+1:]...)
+	}
+	return styles.ThreadPaneStyle.Copy().Width(width).Render(strings.Join(body, "\n"))
+}
+
+func (m ChatroomModel) renderThreadMessage(message models.MessageWithUser, width int) string {
+	authorStyle := styles.MessageAuthorStyle
+	if strings.EqualFold(message.Username, m.username) {
+		authorStyle = styles.MessageAuthorSelfStyle
+	}
+	header := authorStyle.Render(message.Username) + " " + styles.MessageTimestampStyle.Render(message.CreatedAt.Format("15:04")) + expiryBadge(message)
+	if message.DeletedAt != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, header, wrapText(styles.MessageTombstoneStyle.Render(tombstoneText(message)), width))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, m.renderContent("", message, width))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"github.com/Wal-20/cli-chat-app/internal/models"
 	"github.com/Wal-20/cli-chat-app/internal/tui/client"
 	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
@@ -8,15 +9,30 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Fields of the form. Creating a room asks only for the first two.
+const (
+	fieldTitle = iota
+	fieldMaxUsers
+	fieldTopic
+	fieldSlowMode
+	fieldMessageTTL
+)
+
+// CreateChatroomModel is the form for a new chatroom and, opened from inside a room
+// with NewChatroomSettingsModel, for that room's settings.
 type CreateChatroomModel struct {
 	apiClient *client.APIClient
 	username  string
 	userID    uint
 
-	title    textinput.Model
-	maxUsers textinput.Model
+	room     *models.Chatroom // the room being edited; nil when creating one
+	returnTo tea.Model        // the room the settings form goes back to
+
+	inputs   []textinput.Model
+	focused  int
 	isPublic bool
 
 	submitting    bool
@@ -24,26 +40,72 @@ type CreateChatroomModel struct {
 }
 
 func NewCreateChatroomModel(username string, userID uint, apiClient *client.APIClient) CreateChatroomModel {
-	title := textinput.New()
-	title.Prompt = "Title: "
-	title.PromptStyle = styles.InputPromptFocusedStyle
-	title.TextStyle = styles.InputTextFocusedStyle
-	title.Focus()
-
-	max := textinput.New()
-	max.Prompt = "Max users: "
-	max.PromptStyle = styles.InputPromptStyle
-	max.TextStyle = styles.InputTextStyle
-	max.Placeholder = "10"
-
-	return CreateChatroomModel{
+	m := CreateChatroomModel{
 		apiClient: apiClient,
 		username:  username,
 		userID:    userID,
-		title:     title,
-		maxUsers:  max,
-		isPublic:  true,
+		inputs: []textinput.Model{
+			newFormInput("Title: ", ""),
+			newFormInput("Max users: ", "10"),
+		},
+		isPublic: true,
+	}
+	m.focus(fieldTitle)
+	return m
+}
+
+// NewChatroomSettingsModel opens the settings of room, filled in with their current
+// values; Esc or saving goes back to returnTo.
+func NewChatroomSettingsModel(username string, userID uint, room models.Chatroom, apiClient *client.APIClient, returnTo tea.Model) CreateChatroomModel {
+	m := CreateChatroomModel{
+		apiClient: apiClient,
+		username:  username,
+		userID:    userID,
+		room:      &room,
+		returnTo:  returnTo,
+		inputs: []textinput.Model{
+			newFormInput("Title: ", ""),
+			newFormInput("Max users: ", ""),
+			newFormInput("Topic: ", "none"),
+			newFormInput("Slow mode: ", "off, or e.g. 30s"),
+			newFormInput("Message ttl: ", "off, or e.g. 1h or 7d"),
+		},
+		isPublic: room.IsPublic,
+	}
+	m.inputs[fieldTitle].SetValue(room.Title)
+	m.inputs[fieldMaxUsers].SetValue(strconv.FormatUint(uint64(room.MaxUserCount), 10))
+	m.inputs[fieldTopic].SetValue(room.Topic)
+	m.inputs[fieldSlowMode].SetValue(settingDuration(room.SlowMode))
+	m.inputs[fieldMessageTTL].SetValue(settingDuration(room.MessageTTL))
+	m.focus(fieldTitle)
+	return m
+}
+
+func newFormInput(prompt, placeholder string) textinput.Model {
+	in := textinput.New()
+	in.Prompt = prompt
+	in.Placeholder = placeholder
+	in.PromptStyle = styles.InputPromptStyle
+	in.TextStyle = styles.InputTextStyle
+	return in
+}
+
+// settingDuration renders a number of seconds so that parseTTL reads it back exactly,
+// or "" for none.
+func settingDuration(seconds uint) string {
+	if seconds == 0 {
+		return ""
+	}
+	d := time.Duration(seconds) * time.Second
+	if s := formatTTL(d); mustParseTTL(s) == d {
+		return s
 	}
+	return d.String()
+}
+
+func mustParseTTL(s string) time.Duration {
+	d, _ := parseTTL(s)
+	return d
 }
 
 func (m CreateChatroomModel) Init() tea.Cmd { return textinput.Blink }
@@ -52,19 +114,26 @@ func (m CreateChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case createdChatroomMsg:
 		return m.UpdateCreated(msg)
+	case roomSettingsSavedMsg:
+		if msg.err != nil {
+			m.submitting = false
+			m.statusMessage = msg.err.Error()
+			return m, nil
+		}
+		return m.returnTo, func() tea.Msg { return msg }
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
+			if m.room != nil {
+				return m.returnTo, nil
+			}
 			// go back to main
 			return NewMainChatModel(m.username, m.userID, m.apiClient), nil
-		case "tab":
-			if m.title.Focused() {
-				m.title.Blur()
-				m.maxUsers.Focus()
-			} else {
-				m.maxUsers.Blur()
-				m.title.Focus()
-			}
+		case "tab", "down":
+			m.focus((m.focused + 1) % len(m.inputs))
+			return m, nil
+		case "shift+tab", "up":
+			m.focus((m.focused + len(m.inputs) - 1) % len(m.inputs))
 			return m, nil
 		case "ctrl+p":
 			m.isPublic = !m.isPublic
@@ -73,13 +142,16 @@ func (m CreateChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.submitting {
 				return m, nil
 			}
-			t := strings.TrimSpace(m.title.Value())
+			if m.room != nil {
+				return m.submitSettings()
+			}
+			t := strings.TrimSpace(m.inputs[fieldTitle].Value())
 			if t == "" {
 				m.statusMessage = "Title is required"
 				return m, nil
 			}
 			maxCount := 10
-			if s := strings.TrimSpace(m.maxUsers.Value()); s != "" {
+			if s := strings.TrimSpace(m.inputs[fieldMaxUsers].Value()); s != "" {
 				if v, err := strconv.Atoi(s); err == nil && v > 0 {
 					maxCount = v
 				}
@@ -87,35 +159,105 @@ func (m CreateChatroomModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.submitting = true
 			return m, createChatroomCmd(m.apiClient, m.username, t, maxCount, m.isPublic)
 		}
+	default:
+		// Keep the room behind the settings form live: its websocket events and timers
+		// still arrive here.
+		if m.returnTo != nil {
+			var roomCmd, inputCmd tea.Cmd
+			m.returnTo, roomCmd = m.returnTo.Update(msg)
+			m.inputs[m.focused], inputCmd = m.inputs[m.focused].Update(msg)
+			return m, tea.Batch(roomCmd, inputCmd)
+		}
 	}
 	var cmd tea.Cmd
-	if m.title.Focused() {
-		m.title, cmd = m.title.Update(msg)
-	} else {
-		m.maxUsers, cmd = m.maxUsers.Update(msg)
-	}
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
 	return m, cmd
 }
 
+func (m *CreateChatroomModel) focus(i int) {
+	for j := range m.inputs {
+		if j == i {
+			m.inputs[j].Focus()
+			m.inputs[j].PromptStyle = styles.InputPromptFocusedStyle
+			m.inputs[j].TextStyle = styles.InputTextFocusedStyle
+		} else {
+			m.inputs[j].Blur()
+			m.inputs[j].PromptStyle = styles.InputPromptStyle
+			m.inputs[j].TextStyle = styles.InputTextStyle
+		}
+	}
+	m.focused = i
+}
+
+// submitSettings checks what can be checked here and sends every setting; the server
+// has the final say on the limits.
+func (m CreateChatroomModel) submitSettings() (tea.Model, tea.Cmd) {
+	title := strings.TrimSpace(m.inputs[fieldTitle].Value())
+	if title == "" {
+		m.statusMessage = "Title is required"
+		m.focus(fieldTitle)
+		return m, nil
+	}
+	maxCount, err := strconv.ParseUint(strings.TrimSpace(m.inputs[fieldMaxUsers].Value()), 10, 32)
+	if err != nil || maxCount == 0 {
+		m.statusMessage = "Max users must be a positive number"
+		m.focus(fieldMaxUsers)
+		return m, nil
+	}
+	durations := map[int]time.Duration{}
+	for _, field := range []int{fieldSlowMode, fieldMessageTTL} {
+		value := strings.TrimSpace(m.inputs[field].Value())
+		if value == "" {
+			continue
+		}
+		d, err := parseTTL(value)
+		if err != nil {
+			m.statusMessage = "Durations look like 30s, 10m, 1h or 7d (or off)"
+			m.focus(field)
+			return m, nil
+		}
+		durations[field] = d
+	}
+	m.submitting = true
+	m.statusMessage = "Saving..."
+	settings := map[string]any{
+		"title":        title,
+		"topic":        strings.TrimSpace(m.inputs[fieldTopic].Value()),
+		"is_public":    m.isPublic,
+		"maxUserCount": maxCount,
+		"slow_mode":    uint(durations[fieldSlowMode] / time.Second),
+		"message_ttl":  uint(durations[fieldMessageTTL] / time.Second),
+	}
+	return m, saveRoomSettingsCmd(m.apiClient, m.room.Id, settings)
+}
+
 func (m CreateChatroomModel) View() string {
 	toggle := "Public"
 	if !m.isPublic {
 		toggle = "Private"
 	}
-	status := m.statusMessage
-	return styles.AppStyle.Render(strings.Join([]string{
-		styles.CardTitleStyle.Render("New Chatroom"),
-		styles.InputFieldFocusedStyle.Render(m.title.View()),
-		styles.InputFieldStyle.Render(m.maxUsers.View()),
-		styles.StatusInfoStyle.Render("Ctrl + p to toggle: " + toggle),
-		status,
+	heading, action := "New Chatroom", "Create"
+	if m.room != nil {
+		heading, action = "Chatroom Settings", "Save"
+	}
+	lines := []string{styles.CardTitleStyle.Render(heading)}
+	for i, in := range m.inputs {
+		if i == m.focused {
+			lines = append(lines, styles.InputFieldFocusedStyle.Render(in.View()))
+		} else {
+			lines = append(lines, styles.InputFieldStyle.Render(in.View()))
+		}
+	}
+	return styles.AppStyle.Render(strings.Join(append(lines,
+		styles.StatusInfoStyle.Render("Ctrl + p to toggle: "+toggle),
+		m.statusMessage,
 		styles.HelpStyle.Render(strings.Join([]string{
 			styles.RenderKeyBinding("Tab", "Next field"),
 			styles.RenderKeyBinding("Ctrl+p", "Toggle public"),
-			styles.RenderKeyBinding("Enter", "Create"),
+			styles.RenderKeyBinding("Enter", action),
 			styles.RenderKeyBinding("Esc", "Back"),
 		}, styles.HelpStyle.Render("  "))),
-	}, "\n\n"))
+	), "\n\n"))
 }
 
 type createdChatroomMsg struct {
@@ -123,6 +265,12 @@ type createdChatroomMsg struct {
 	err      error
 }
 
+// roomSettingsSavedMsg carries the room as saved back to the ChatroomModel.
+type roomSettingsSavedMsg struct {
+	room models.Chatroom
+	err  error
+}
+
 func createChatroomCmd(api *client.APIClient, username, title string, maxUsers int, isPublic bool) tea.Cmd {
 	return func() tea.Msg {
 		room, err := api.CreateChatroom(title, maxUsers, isPublic)
@@ -133,6 +281,16 @@ func createChatroomCmd(api *client.APIClient, username, title string, maxUsers i
 	}
 }
 
+func saveRoomSettingsCmd(api *client.APIClient, chatroomID uint, settings map[string]any) tea.Cmd {
+	return func() tea.Msg {
+		room, err := api.UpdateChatroom(chatroomID, settings)
+		if err != nil {
+			return roomSettingsSavedMsg{err: fmt.Errorf("failed to save settings: %w", err)}
+		}
+		return roomSettingsSavedMsg{room: room}
+	}
+}
+
 // Handle result messages in Update
 func (m CreateChatroomModel) UpdateCreated(msg createdChatroomMsg) (tea.Model, tea.Cmd) {
 	if msg.err != nil {
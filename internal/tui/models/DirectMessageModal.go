@@ -0,0 +1,131 @@
+package models
+
+import (
+	"strings"
+
+	appmodels "github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DirectMessageModal asks for a username and opens the direct conversation with them,
+// starting it if needed.
+type DirectMessageModal struct {
+	apiClient *client.APIClient
+	username  string
+	userID    uint
+	returnTo  tea.Model
+
+	input      textinput.Model
+	submitting bool
+	width      int
+	height     int
+	status     string
+}
+
+func NewDirectMessageModal(username string, userID uint, api *client.APIClient, returnTo tea.Model) DirectMessageModal {
+	in := textinput.New()
+	in.Prompt = "> "
+	in.Placeholder = "username"
+	in.PromptStyle = styles.InputPromptFocusedStyle
+	in.TextStyle = styles.InputTextFocusedStyle
+	in.PlaceholderStyle = styles.InputPlaceholderStyle
+	in.Cursor.Style = styles.KeyStyle
+	in.Focus()
+
+	return DirectMessageModal{
+		apiClient: api,
+		username:  username,
+		userID:    userID,
+		returnTo:  returnTo,
+		input:     in,
+	}
+}
+
+func (m DirectMessageModal) Init() tea.Cmd { return textinput.Blink }
+
+type directOpenedMsg struct {
+	conversation appmodels.DirectConversation
+	err          error
+}
+
+func (m DirectMessageModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.input.Width = min(max(m.width-20, 28), 48)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.submitting {
+				return m, nil
+			}
+			return m.returnTo, nil
+		case "enter":
+			if m.submitting {
+				return m, nil
+			}
+			peer := strings.TrimPrefix(strings.TrimSpace(m.input.Value()), "@")
+			if peer == "" {
+				m.status = "Enter a username"
+				return m, nil
+			}
+			if strings.EqualFold(peer, m.username) {
+				m.status = "You can't message yourself"
+				return m, nil
+			}
+			m.submitting = true
+			m.status = ""
+			return m, openDirectMessageCmd(m.apiClient, peer)
+		}
+	case directOpenedMsg:
+		m.submitting = false
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		cm := NewChatroomModel(m.username, m.userID, directChatroom(msg.conversation), m.apiClient)
+		return cm, cm.Init()
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m DirectMessageModal) View() string {
+	title := styles.CardTitleStyle.Render("Message a User")
+	subtitle := styles.CardSubtitleStyle.Render("Start or continue a private conversation")
+	field := styles.InputFieldFocusedStyle.Render(m.input.View())
+
+	statusView := ""
+	if m.submitting {
+		statusView = styles.StatusInfoStyle.Render("Opening conversation...")
+	} else if m.status != "" {
+		statusView = styles.StatusErrorStyle.Render(m.status)
+	}
+
+	help := styles.HelpStyle.Render(strings.Join([]string{
+		styles.RenderKeyBinding("Enter", "Open"),
+		styles.RenderKeyBinding("Esc", "Cancel"),
+	}, styles.HelpStyle.Render("  ")))
+
+	content := strings.Join([]string{title, subtitle, field, statusView, help}, "\n\n")
+	card := styles.CardStyle.Render(content)
+	if m.width > 0 && m.height > 0 {
+		centered := lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, card)
+		return styles.AppStyle.Copy().Width(m.width).Height(m.height).Render(centered)
+	}
+	return styles.AppStyle.Render(card)
+}
+
+func openDirectMessageCmd(api *client.APIClient, peer string) tea.Cmd {
+	return func() tea.Msg {
+		conv, err := api.OpenDirectMessage(peer)
+		return directOpenedMsg{conversation: conv, err: err}
+	}
+}
@@ -17,6 +17,15 @@ import (
 type chatroomItem struct {
 	chatroom models.Chatroom
 	isMember bool
+	unread   models.UnreadCount
+	direct   bool // a direct-message conversation, titled after the other person
+}
+
+// directChatroom returns a conversation's room titled "@peer", as the TUI shows it.
+func directChatroom(conv models.DirectConversation) models.Chatroom {
+	room := conv.Chatroom
+	room.Title = "@" + conv.PeerName
+	return room
 }
 
 func (i chatroomItem) Title() string       { return i.chatroom.Title }
@@ -46,16 +55,26 @@ func (d chatroomDelegate) Render(w io.Writer, m list.Model, index int, listItem
 	}
 
 	title := titleStyle.Render(item.chatroom.Title)
+	if item.unread.Unread > 0 {
+		title += " " + styles.UnreadBadgeStyle.Render(fmt.Sprintf("(%d)", item.unread.Unread))
+	}
+	if item.unread.Mentions > 0 {
+		title += " " + styles.MentionBadgeStyle.Render(fmt.Sprintf("@%d", item.unread.Mentions))
+	}
 
 	metaParts := []string{}
-	if item.isMember {
+	if item.direct {
+		metaParts = append(metaParts, "direct message")
+	} else if item.isMember {
 		metaParts = append(metaParts, "joined")
 	} else if item.chatroom.IsPublic {
 		metaParts = append(metaParts, "public")
 	} else {
 		metaParts = append(metaParts, "private")
 	}
-	metaParts = append(metaParts, fmt.Sprintf("capacity %d", item.chatroom.MaxUserCount))
+	if !item.direct {
+		metaParts = append(metaParts, fmt.Sprintf("capacity %d", item.chatroom.MaxUserCount))
+	}
 	meta := styles.ListItemMetaStyle.Render(strings.Join(metaParts, " | "))
 
 	pointer := "  "
@@ -69,11 +88,20 @@ func (d chatroomDelegate) Render(w io.Writer, m list.Model, index int, listItem
 	fmt.Fprintf(w, "%s\n%s", titleLine, metaLine)
 }
 
+// Panes of the main screen, in Tab order.
+const (
+	roomsPane = iota
+	directPane
+	discoverPane
+	paneCount
+)
+
 type MainChatModel struct {
 	apiClient        *client.APIClient
 	userID           uint
 	username         string
 	userChatrooms    list.Model
+	directMessages   list.Model
 	publicChatrooms  list.Model
 	activeList       int
 	width            int
@@ -87,15 +115,28 @@ type MainChatModel struct {
 
 func NewMainChatModel(username string, userID uint, apiClient *client.APIClient) MainChatModel {
 	userItems := []list.Item{}
+	directItems := []list.Item{}
 	publicItems := []list.Item{}
 	loadErrors := []string{}
 
+	// Badges are best-effort; the lists still work without them.
+	unread, _ := apiClient.GetUnreadCounts()
+
 	if userChatroomsData, err := apiClient.GetUserChatrooms(); err != nil {
 		loadErrors = append(loadErrors, fmt.Sprintf("Your chatrooms unavailable: %s", err.Error()))
 	} else {
 		userItems = make([]list.Item, len(userChatroomsData))
 		for i, c := range userChatroomsData {
-			userItems[i] = chatroomItem{chatroom: c, isMember: true}
+			userItems[i] = chatroomItem{chatroom: c, isMember: true, unread: unread[c.Id]}
+		}
+	}
+
+	if conversations, err := apiClient.GetDirectMessages(); err != nil {
+		loadErrors = append(loadErrors, fmt.Sprintf("Direct messages unavailable: %s", err.Error()))
+	} else {
+		directItems = make([]list.Item, len(conversations))
+		for i, conv := range conversations {
+			directItems[i] = chatroomItem{chatroom: directChatroom(conv), isMember: true, unread: unread[conv.Chatroom.Id], direct: true}
 		}
 	}
 
@@ -110,21 +151,9 @@ func NewMainChatModel(username string, userID uint, apiClient *client.APIClient)
 
 	delegate := NewChatroomDelegate()
 
-	userList := list.New(userItems, delegate, 40, 12)
-	userList.SetShowHelp(false)
-	userList.SetShowTitle(false)
-	userList.SetShowStatusBar(false)
-	userList.SetShowPagination(false)
-	userList.SetFilteringEnabled(true)
-	userList.DisableQuitKeybindings()
-
-	publicList := list.New(publicItems, delegate, 40, 12)
-	publicList.SetShowHelp(false)
-	publicList.SetShowTitle(false)
-	publicList.SetShowStatusBar(false)
-	publicList.SetShowPagination(false)
-	publicList.SetFilteringEnabled(true)
-	publicList.DisableQuitKeybindings()
+	userList := newChatroomList(userItems, delegate)
+	directList := newChatroomList(directItems, delegate)
+	publicList := newChatroomList(publicItems, delegate)
 
 	flashMessage := ""
 	flashStyle := styles.StatusInfoStyle
@@ -138,13 +167,25 @@ func NewMainChatModel(username string, userID uint, apiClient *client.APIClient)
 		userID:          userID,
 		username:        username,
 		userChatrooms:   userList,
+		directMessages:  directList,
 		publicChatrooms: publicList,
-		activeList:      0,
+		activeList:      roomsPane,
 		flashMessage:    flashMessage,
 		flashStyle:      flashStyle,
 	}
 }
 
+func newChatroomList(items []list.Item, delegate chatroomDelegate) list.Model {
+	l := list.New(items, delegate, 40, 12)
+	l.SetShowHelp(false)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowPagination(false)
+	l.SetFilteringEnabled(true)
+	l.DisableQuitKeybindings()
+	return l
+}
+
 func (m MainChatModel) Init() tea.Cmd {
 	return nil
 }
@@ -168,7 +209,10 @@ func (m MainChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			listHeight = 8
 		}
 
-		m.userChatrooms.SetSize(listWidth, listHeight)
+		// Direct messages share the left column with your chatrooms, below them.
+		directHeight := max(listHeight/3, 4)
+		m.userChatrooms.SetSize(listWidth, max(listHeight-directHeight-2, 4))
+		m.directMessages.SetSize(listWidth, directHeight)
 		m.publicChatrooms.SetSize(listWidth, listHeight)
 
 		return m, nil
@@ -214,8 +258,11 @@ func (m MainChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch msg.String() {
 		case "tab":
-			m.activeList = (m.activeList + 1) % 2
+			m.activeList = (m.activeList + 1) % paneCount
 			return m, nil
+		case "m":
+			dm := NewDirectMessageModal(m.username, m.userID, m.apiClient, m)
+			return dm, dm.Init()
 		case "c":
 			// create new chatroom
 			return NewCreateChatroomModel(m.username, m.userID, m.apiClient), nil
@@ -228,7 +275,7 @@ func (m MainChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return nm, loadNotifications(m.apiClient)
 		case "ctrl+d":
 			// Delete chatroom: only in "Your chatrooms" pane and only owner
-			if m.activeList != 0 {
+			if m.activeList != roomsPane {
 				return m, nil
 			}
 			idx := m.userChatrooms.Index()
@@ -249,12 +296,18 @@ func (m MainChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		case "enter":
-			if m.activeList == 0 {
+			switch m.activeList {
+			case roomsPane:
 				if item, ok := m.userChatrooms.SelectedItem().(chatroomItem); ok {
 					cm := NewChatroomModel(m.username, m.userID, item.chatroom, m.apiClient)
 					return cm, cm.Init()
 				}
-			} else {
+			case directPane:
+				if item, ok := m.directMessages.SelectedItem().(chatroomItem); ok {
+					cm := NewChatroomModel(m.username, m.userID, item.chatroom, m.apiClient)
+					return cm, cm.Init()
+				}
+			default:
 				if item, ok := m.publicChatrooms.SelectedItem().(chatroomItem); ok {
 					if err := m.apiClient.JoinChatroom(item.chatroom.Id); err != nil {
 						m.flashMessage = fmt.Sprintf("Could not join %s: %s", item.chatroom.Title, err.Error())
@@ -277,15 +330,16 @@ func (m MainChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	if m.activeList == 0 {
-		var cmd tea.Cmd
+	var cmd tea.Cmd
+	switch m.activeList {
+	case roomsPane:
 		m.userChatrooms, cmd = m.userChatrooms.Update(msg)
-		cmds = append(cmds, cmd)
-	} else {
-		var cmd tea.Cmd
+	case directPane:
+		m.directMessages, cmd = m.directMessages.Update(msg)
+	default:
 		m.publicChatrooms, cmd = m.publicChatrooms.Update(msg)
-		cmds = append(cmds, cmd)
 	}
+	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
@@ -295,13 +349,18 @@ func (m MainChatModel) View() string {
 	subtitle := styles.SubtitleStyle.Render("Use Tab to switch panes, Enter to dive into a room.")
 
 	paneWidth := m.paneWidth()
-	leftPane := renderPane("Your chatrooms", m.userChatrooms, m.activeList == 0, paneWidth, true)
-	rightPane := renderPane("Discover", m.publicChatrooms, m.activeList == 1, paneWidth, false)
+	leftPane := lipgloss.JoinVertical(lipgloss.Left,
+		renderPane("Your chatrooms", m.userChatrooms, m.activeList == roomsPane, paneWidth, true),
+		"",
+		renderPane("Direct messages", m.directMessages, m.activeList == directPane, paneWidth, true),
+	)
+	rightPane := renderPane("Discover", m.publicChatrooms, m.activeList == discoverPane, paneWidth, false)
 	columns := lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
 
 	joinedCount := len(m.userChatrooms.VisibleItems())
+	directCount := len(m.directMessages.VisibleItems())
 	discoverCount := len(m.publicChatrooms.VisibleItems())
-	info := fmt.Sprintf("%d joined | %d discoverable", joinedCount, discoverCount)
+	info := fmt.Sprintf("%d joined | %d direct | %d discoverable", joinedCount, directCount, discoverCount)
 	statusStyle := styles.StatusInfoStyle
 	if m.flashMessage != "" {
 		info = m.flashMessage
@@ -317,6 +376,7 @@ func (m MainChatModel) View() string {
 		styles.RenderKeyBinding("n", "Notifications"),
 		styles.RenderKeyBinding("q", "Quit"),
 		styles.RenderKeyBinding("c", "Create a chatroom"),
+		styles.RenderKeyBinding("m", "Message a user"),
 	}
 	help := strings.Join(helpItems, styles.HelpStyle.Render("  "))
 
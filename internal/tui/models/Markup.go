@@ -0,0 +1,353 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Wal-20/cli-chat-app/internal/models"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// A small markup layer for message content. Inline: **bold**, _italic_ (or *italic*),
+// `code` and ||spoilers||. Blocks: ``` fenced code ```, > quotes and - / 1. lists.
+// Everything else is plain text, and unmatched markers are shown as typed. All output
+// is wrapped with wrapText, which measures display width rather than bytes, so styled
+// text wraps at the same place as plain text.
+
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockCode
+	blockQuote
+	blockList
+)
+
+type markupBlock struct {
+	kind  blockKind
+	lang  string // code blocks only
+	lines []string
+	items []listItem // lists only
+}
+
+type listItem struct {
+	depth  int
+	marker string
+	text   string
+}
+
+// markupOptions controls how content is rendered. decorate, when set, is applied to
+// plain text runs (not code) and is how mentions get highlighted.
+type markupOptions struct {
+	width          int
+	revealSpoilers bool
+	decorate       func(string) string
+}
+
+var listItemPattern = regexp.MustCompile(`^(\s*)([-*+]|\d{1,3}[.)])\s+(.*)$`)
+
+// codeKeywords is a language-agnostic keyword set; enough to make the common languages
+// readable without shipping a real highlighter.
+var codeKeywords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"def": true, "default": true, "defer": true, "do": true, "elif": true, "else": true,
+	"enum": true, "except": true, "export": true, "extends": true, "false": true, "finally": true,
+	"fn": true, "for": true, "from": true, "func": true, "function": true, "go": true,
+	"if": true, "impl": true, "import": true, "in": true, "interface": true, "let": true,
+	"map": true, "match": true, "mut": true, "new": true, "nil": true, "None": true,
+	"null": true, "package": true, "pub": true, "raise": true, "range": true, "return": true,
+	"select": true, "self": true, "static": true, "struct": true, "switch": true, "this": true,
+	"throw": true, "true": true, "True": true, "False": true, "try": true, "type": true,
+	"use": true, "var": true, "while": true, "with": true, "yield": true,
+}
+
+// renderMarkup renders content to fit width. prefix (the author and timestamp) leads
+// the first line when the content starts with text, otherwise it sits on its own line.
+func renderMarkup(prefix, content string, opts markupOptions) string {
+	blocks := parseBlocks(content)
+	var out []string
+	for i, block := range blocks {
+		lead := ""
+		if i == 0 {
+			lead = prefix
+		}
+		if lead != "" && block.kind != blockParagraph {
+			out = append(out, wrapText(strings.TrimRight(lead, " "), opts.width))
+			lead = ""
+		}
+		switch block.kind {
+		case blockCode:
+			out = append(out, renderCodeBlock(block, opts.width))
+		case blockQuote:
+			out = append(out, renderQuote(block, opts))
+		case blockList:
+			out = append(out, renderList(block, opts))
+		default:
+			out = append(out, renderParagraph(lead, block, opts))
+		}
+	}
+	if len(out) == 0 {
+		return wrapText(prefix, opts.width)
+	}
+	return strings.Join(out, "\n")
+}
+
+func parseBlocks(content string) []markupBlock {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var blocks []markupBlock
+	last := func(kind blockKind) *markupBlock {
+		if n := len(blocks); n > 0 && blocks[n-1].kind == kind {
+			return &blocks[n-1]
+		}
+		blocks = append(blocks, markupBlock{kind: kind})
+		return &blocks[len(blocks)-1]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			rest := trimmed[3:]
+			// ```code``` on a single line
+			if end := strings.Index(rest, "```"); end >= 0 {
+				blocks = append(blocks, markupBlock{kind: blockCode, lines: []string{rest[:end]}})
+				continue
+			}
+			block := markupBlock{kind: blockCode, lang: strings.TrimSpace(rest)}
+			// An unterminated fence runs to the end of the message.
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				block.lines = append(block.lines, lines[i])
+			}
+			blocks = append(blocks, block)
+		case strings.HasPrefix(trimmed, ">"):
+			quote := last(blockQuote)
+			quote.lines = append(quote.lines, strings.TrimPrefix(trimmed[1:], " "))
+		case listItemPattern.MatchString(line):
+			parts := listItemPattern.FindStringSubmatch(line)
+			marker := parts[2]
+			if strings.ContainsAny(marker, "-*+") {
+				marker = "•"
+			}
+			list := last(blockList)
+			list.items = append(list.items, listItem{depth: len(parts[1]) / 2, marker: marker, text: parts[3]})
+		default:
+			paragraph := last(blockParagraph)
+			paragraph.lines = append(paragraph.lines, line)
+		}
+	}
+	return blocks
+}
+
+func renderParagraph(prefix string, block markupBlock, opts markupOptions) string {
+	lines := make([]string, len(block.lines))
+	for i, line := range block.lines {
+		if i == 0 {
+			line = prefix + renderInline(line, opts, opts.decorate)
+		} else {
+			line = renderInline(line, opts, opts.decorate)
+		}
+		lines[i] = wrapText(line, opts.width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderQuote(block markupBlock, opts markupOptions) string {
+	bar := styles.QuoteBarStyle.Render("│ ")
+	var out []string
+	for _, line := range block.lines {
+		text := renderInline(line, opts, func(s string) string { return styles.QuoteTextStyle.Render(s) })
+		for _, wrapped := range strings.Split(wrapText(text, max(opts.width-2, 8)), "\n") {
+			out = append(out, bar+wrapped)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func renderList(block markupBlock, opts markupOptions) string {
+	var out []string
+	for _, item := range block.items {
+		indent := strings.Repeat("  ", item.depth)
+		hang := strings.Repeat(" ", ansi.StringWidth(item.marker)+1)
+		width := max(opts.width-ansi.StringWidth(indent+hang), 8)
+		wrapped := strings.Split(wrapText(renderInline(item.text, opts, opts.decorate), width), "\n")
+		for i, line := range wrapped {
+			if i == 0 {
+				out = append(out, indent+styles.ListMarkerStyle.Render(item.marker)+" "+line)
+			} else {
+				out = append(out, indent+hang+line)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderCodeBlock draws a fenced block in a box. Long lines are broken rather than
+// word-wrapped so code keeps its shape.
+func renderCodeBlock(block markupBlock, width int) string {
+	// The box adds a border and a space of padding on each side.
+	inner := max(width-4, 8)
+	var lines []string
+	if block.lang != "" {
+		lines = append(lines, styles.CodeLanguageStyle.Render(block.lang))
+	}
+	for _, line := range block.lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for _, piece := range strings.Split(ansi.Hardwrap(line, inner, true), "\n") {
+			lines = append(lines, highlightCode(piece))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "")
+	}
+	return styles.CodeBlockStyle.Render(strings.Join(lines, "\n"))
+}
+
+// highlightCode colours keywords, strings, numbers and line comments in one line of code.
+func highlightCode(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "//") || c == '#' && strings.TrimSpace(line[:i]) == "" || strings.HasPrefix(line[i:], "--") && strings.TrimSpace(line[:i]) == "":
+			b.WriteString(styles.CodeCommentStyle.Render(line[i:]))
+			return b.String()
+		case c == '"' || c == '\'' || c == '`':
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(line))
+			b.WriteString(styles.CodeStringStyle.Render(line[i:end]))
+			i = end
+		case isDigit(c) && (i == 0 || !isWordByte(line[i-1])):
+			end := i
+			for end < len(line) && (isWordByte(line[end]) || line[end] == '.') {
+				end++
+			}
+			b.WriteString(styles.CodeNumberStyle.Render(line[i:end]))
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(line) && isWordByte(line[end]) {
+				end++
+			}
+			if word := line[i:end]; codeKeywords[word] {
+				b.WriteString(styles.CodeKeywordStyle.Render(word))
+			} else {
+				b.WriteString(word)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// renderInline applies the inline markers to one line of text. Text outside any marker
+// goes through plain, when given.
+func renderInline(text string, opts markupOptions, plain func(string) string) string {
+	var b, run strings.Builder
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if plain != nil {
+			b.WriteString(plain(run.String()))
+		} else {
+			b.WriteString(run.String())
+		}
+		run.Reset()
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush()
+				b.WriteString(styles.InlineCodeStyle.Render(rest[1 : 1+end]))
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				flush()
+				b.WriteString(styles.MarkupBoldStyle.Render(rest[2 : 2+end]))
+				i += end + 4
+				continue
+			}
+		case strings.HasPrefix(rest, "||"):
+			if end := strings.Index(rest[2:], "||"); end > 0 {
+				flush()
+				b.WriteString(renderSpoiler(rest[2:2+end], opts.revealSpoilers))
+				i += end + 4
+				continue
+			}
+		case rest[0] == '_' || rest[0] == '*':
+			if end, ok := italicEnd(text, i); ok {
+				flush()
+				b.WriteString(styles.MarkupItalicStyle.Render(text[i+1 : end]))
+				i = end + 1
+				continue
+			}
+		}
+		run.WriteByte(text[i])
+		i++
+	}
+	flush()
+	return b.String()
+}
+
+// italicEnd finds the marker closing an _italic_ or *italic* span opened at start. Markers
+// inside words (snake_case, 2*3*4) do not count.
+func italicEnd(text string, start int) (int, bool) {
+	marker := text[start]
+	if start > 0 && isWordByte(text[start-1]) {
+		return 0, false
+	}
+	if start+1 >= len(text) || text[start+1] == ' ' || text[start+1] == marker {
+		return 0, false
+	}
+	for j := start + 2; j < len(text); j++ {
+		if text[j] == marker && text[j-1] != ' ' && (j+1 == len(text) || !isWordByte(text[j+1])) {
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+// renderSpoiler hides text behind a mask of the same width unless it is revealed.
+func renderSpoiler(text string, reveal bool) string {
+	if reveal {
+		return styles.SpoilerRevealedStyle.Render(text)
+	}
+	return styles.SpoilerStyle.Render(strings.Repeat("▒", ansi.StringWidth(text)))
+}
+
+// renderContent renders a message body after prefix: formatted, or exactly as typed in
+// raw mode. Spoilers in the selected message are revealed.
+func (m ChatroomModel) renderContent(prefix string, message models.MessageWithUser, width int) string {
+	if m.rawMarkup {
+		return wrapText(prefix+m.highlightMentions(message.Content), width)
+	}
+	return renderMarkup(prefix, message.Content, markupOptions{
+		width:          width,
+		revealSpoilers: message.ID != 0 && message.ID == m.selectedID,
+		decorate:       m.highlightMentions,
+	})
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isWordByte reports whether c can be part of a word. Bytes of multi-byte runes count,
+// so markers next to non-ASCII letters behave like markers next to ASCII ones.
+func isWordByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
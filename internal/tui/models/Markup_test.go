@@ -0,0 +1,136 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+)
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []markupBlock
+	}{
+		{
+			name:    "paragraph lines stay together",
+			content: "hello\r\nworld",
+			want:    []markupBlock{{kind: blockParagraph, lines: []string{"hello", "world"}}},
+		},
+		{
+			name:    "fenced code with language",
+			content: "look:\n```go\nfunc main() {}\n```\ndone",
+			want: []markupBlock{
+				{kind: blockParagraph, lines: []string{"look:"}},
+				{kind: blockCode, lang: "go", lines: []string{"func main() {}"}},
+				{kind: blockParagraph, lines: []string{"done"}},
+			},
+		},
+		{
+			name:    "single-line fence",
+			content: "```x := 1```",
+			want:    []markupBlock{{kind: blockCode, lines: []string{"x := 1"}}},
+		},
+		{
+			name:    "unterminated fence runs to the end",
+			content: "```\na\nb",
+			want:    []markupBlock{{kind: blockCode, lines: []string{"a", "b"}}},
+		},
+		{
+			name:    "quote lines merge",
+			content: "> first\n>second",
+			want:    []markupBlock{{kind: blockQuote, lines: []string{"first", "second"}}},
+		},
+		{
+			name:    "lists with nesting and numbers",
+			content: "- one\n  * nested\n2. two",
+			want: []markupBlock{{kind: blockList, items: []listItem{
+				{depth: 0, marker: "•", text: "one"},
+				{depth: 1, marker: "•", text: "nested"},
+				{depth: 0, marker: "2.", text: "two"},
+			}}},
+		},
+		{
+			name:    "dash without space is text",
+			content: "-5 degrees",
+			want:    []markupBlock{{kind: blockParagraph, lines: []string{"-5 degrees"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBlocks(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBlocks(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	bold := styles.MarkupBoldStyle.Render
+	italic := styles.MarkupItalicStyle.Render
+	code := styles.InlineCodeStyle.Render
+	tests := []struct {
+		name   string
+		text   string
+		reveal bool
+		want   string
+	}{
+		{name: "plain", text: "just text", want: "just text"},
+		{name: "bold", text: "a **b** c", want: "a " + bold("b") + " c"},
+		{name: "italic underscores", text: "_it_", want: italic("it")},
+		{name: "italic stars", text: "an *it* here", want: "an " + italic("it") + " here"},
+		{name: "code keeps markers", text: "`**x**`", want: code("**x**")},
+		{name: "snake_case is not italic", text: "snake_case_name", want: "snake_case_name"},
+		{name: "arithmetic is not italic", text: "2*3*4", want: "2*3*4"},
+		{name: "unmatched markers shown as typed", text: "**open and `tick", want: "**open and `tick"},
+		{name: "hidden spoiler", text: "||abc||", want: styles.SpoilerStyle.Render("▒▒▒")},
+		{name: "revealed spoiler", text: "||abc||", reveal: true, want: styles.SpoilerRevealedStyle.Render("abc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderInline(tt.text, markupOptions{revealSpoilers: tt.reveal}, nil)
+			if got != tt.want {
+				t.Errorf("renderInline(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInlineDecoratesPlainRuns(t *testing.T) {
+	upper := func(s string) string { return strings.ToUpper(s) }
+	got := renderInline("hi `code` there", markupOptions{}, upper)
+	want := "HI " + styles.InlineCodeStyle.Render("code") + " THERE"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	keyword := styles.CodeKeywordStyle.Render
+	str := styles.CodeStringStyle.Render
+	number := styles.CodeNumberStyle.Render
+	comment := styles.CodeCommentStyle.Render
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "keywords and identifiers", line: "return value", want: keyword("return") + " value"},
+		{name: "string with escaped quote", line: `"a\"b" x`, want: str(`"a\"b"`) + " x"},
+		{name: "unterminated string", line: `'abc`, want: str(`'abc`)},
+		{name: "numbers", line: "x = 3.14", want: "x = " + number("3.14")},
+		{name: "digits inside words", line: "v2", want: "v2"},
+		{name: "trailing line comment", line: "i++ // bump", want: "i++ " + comment("// bump")},
+		{name: "hash comment at line start", line: "  # note", want: "  " + comment("# note")},
+		{name: "hash mid-line is not a comment", line: "a # b", want: "a # b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightCode(tt.line); got != tt.want {
+				t.Errorf("highlightCode(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wal-20/cli-chat-app/internal/tui/client"
+	"github.com/Wal-20/cli-chat-app/internal/tui/styles"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MuteUserModal prompts for a member to mute in the current chatroom, for how long and
+// why. An empty duration mutes them until an admin unmutes them.
+type MuteUserModal struct {
+	apiClient  *client.APIClient
+	chatroomID uint
+	returnTo   tea.Model
+
+	inputs     []textinput.Model // user, duration, reason
+	focused    int
+	submitting bool
+	width      int
+	height     int
+	status     string
+	statusOK   bool
+}
+
+func NewMuteUserModal(api *client.APIClient, chatroomID uint, returnTo tea.Model) MuteUserModal {
+	placeholders := []string{
+		"user id or name (e.g., 42 or alice)",
+		"duration, e.g. 10m, 2h or 1d (empty: until unmuted)",
+		"reason (optional)",
+	}
+	inputs := make([]textinput.Model, len(placeholders))
+	for i, placeholder := range placeholders {
+		in := textinput.New()
+		in.Prompt = "> "
+		in.Placeholder = placeholder
+		in.PromptStyle = styles.InputPromptStyle
+		in.TextStyle = styles.InputTextStyle
+		in.PlaceholderStyle = styles.InputPlaceholderStyle
+		in.Cursor.Style = styles.KeyStyle
+		inputs[i] = in
+	}
+	inputs[2].CharLimit = 255
+	m := MuteUserModal{apiClient: api, chatroomID: chatroomID, returnTo: returnTo, inputs: inputs}
+	m.focus(0)
+	return m
+}
+
+func (m MuteUserModal) Init() tea.Cmd { return textinput.Blink }
+
+type muteDoneMsg struct{ err error }
+
+func (m MuteUserModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		w := m.width - 20
+		if w > 56 {
+			w = 56
+		}
+		if w < 28 {
+			w = 28
+		}
+		for i := range m.inputs {
+			m.inputs[i].Width = w
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.submitting {
+				return m, nil
+			}
+			return m.returnTo, nil
+		case "tab", "down":
+			m.focus((m.focused + 1) % len(m.inputs))
+			return m, nil
+		case "shift+tab", "up":
+			m.focus((m.focused + len(m.inputs) - 1) % len(m.inputs))
+			return m, nil
+		case "enter":
+			if m.submitting {
+				return m, nil
+			}
+			ident := strings.TrimSpace(m.inputs[0].Value())
+			if ident == "" {
+				m.status = "Enter a user id or name"
+				m.statusOK = false
+				m.focus(0)
+				return m, nil
+			}
+			var duration time.Duration
+			if value := strings.TrimSpace(m.inputs[1].Value()); value != "" {
+				d, err := parseTTL(value)
+				if err != nil {
+					m.status = "Duration must look like 10m, 2h or 1d"
+					m.statusOK = false
+					m.focus(1)
+					return m, nil
+				}
+				duration = d
+			}
+			m.submitting = true
+			m.status = "Muting..."
+			m.statusOK = true
+			return m, muteUserCmd(m.apiClient, m.chatroomID, ident, duration, strings.TrimSpace(m.inputs[2].Value()))
+		}
+	case muteDoneMsg:
+		m.submitting = false
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			m.statusOK = false
+			return m, nil
+		}
+		return m.returnTo, nil
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m *MuteUserModal) focus(i int) {
+	for j := range m.inputs {
+		if j == i {
+			m.inputs[j].Focus()
+			m.inputs[j].PromptStyle = styles.InputPromptFocusedStyle
+			m.inputs[j].TextStyle = styles.InputTextFocusedStyle
+		} else {
+			m.inputs[j].Blur()
+			m.inputs[j].PromptStyle = styles.InputPromptStyle
+			m.inputs[j].TextStyle = styles.InputTextStyle
+		}
+	}
+	m.focused = i
+}
+
+func (m MuteUserModal) View() string {
+	title := styles.CardTitleStyle.Render("Mute User")
+	subtitle := styles.CardSubtitleStyle.Render("Stop a member posting in this chatroom")
+	fields := make([]string, len(m.inputs))
+	for i, in := range m.inputs {
+		if i == m.focused {
+			fields[i] = styles.InputFieldFocusedStyle.Render(in.View())
+		} else {
+			fields[i] = styles.InputFieldStyle.Render(in.View())
+		}
+	}
+
+	statusView := ""
+	if m.status != "" {
+		if m.statusOK {
+			statusView = styles.StatusSuccessStyle.Render(m.status)
+		} else {
+			statusView = styles.StatusErrorStyle.Render(m.status)
+		}
+	}
+	help := styles.HelpStyle.Render(strings.Join([]string{
+		styles.RenderKeyBinding("Tab", "Next field"),
+		styles.RenderKeyBinding("Enter", "Mute user"),
+		styles.RenderKeyBinding("Esc", "Cancel"),
+	}, styles.HelpStyle.Render("  ")))
+
+	content := strings.Join([]string{title, subtitle, strings.Join(fields, "\n"), statusView, help}, "\n\n")
+	card := styles.CardStyle.Render(content)
+	if m.width > 0 && m.height > 0 {
+		centered := lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, card)
+		return styles.AppStyle.Copy().Width(m.width).Height(m.height).Render(centered)
+	}
+	return styles.AppStyle.Render(card)
+}
+
+func muteUserCmd(api *client.APIClient, chatroomID uint, ident string, duration time.Duration, reason string) tea.Cmd {
+	return func() tea.Msg {
+		// Resolve ident to id if it's a name
+		if _, err := strconv.ParseUint(ident, 10, 64); err != nil {
+			users, uerr := api.GetUsersByChatroom(chatroomID, false)
+			if uerr == nil {
+				lowered := strings.ToLower(strings.TrimPrefix(ident, "@"))
+				for _, u := range users {
+					if strings.ToLower(u.Name) == lowered {
+						ident = fmt.Sprintf("%d", u.UserID)
+						break
+					}
+				}
+			}
+		}
+		err := api.MuteUser(fmt.Sprintf("%d", chatroomID), ident, duration, reason)
+		return muteDoneMsg{err: err}
+	}
+}
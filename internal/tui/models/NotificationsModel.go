@@ -98,6 +98,17 @@ func (m NotificationsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					nid := uint(it.notification.Id)
 					return m, joinInviteCmd(m.apiClient, it.notification.ChatroomId, nid)
 				}
+				if strings.EqualFold(it.notification.Type, "dm") {
+					if m.joining {
+						return m, nil
+					}
+					m.joining = true
+					m.loading = true
+					m.flashMessage = "Opening conversation..."
+					m.flashStyle = styles.StatusInfoStyle
+					nid := uint(it.notification.Id)
+					return m, openDirectCmd(m.apiClient, it.notification.ChatroomId, nid)
+				}
 			}
 			return m, nil
 		case "esc", "q":
@@ -162,7 +173,7 @@ func (m NotificationsModel) View() string {
 	}
 
 	helpItems := []string{
-		styles.RenderKeyBinding("Enter", "Join invite"),
+		styles.RenderKeyBinding("Enter", "Join invite / open DM"),
 		styles.RenderKeyBinding("r", "Refresh"),
 		styles.RenderKeyBinding("d", "Delete"),
 		styles.RenderKeyBinding("Esc", "Back"),
@@ -265,6 +276,24 @@ func joinInviteCmd(apiClient *client.APIClient, chatroomID uint, notificationID
 	}
 }
 
+// openDirectCmd opens the conversation a "dm" notification points at. The membership
+// already exists, so this only looks the conversation up to title it after the peer.
+func openDirectCmd(apiClient *client.APIClient, chatroomID uint, notificationID uint) tea.Cmd {
+	return func() tea.Msg {
+		conversations, err := apiClient.GetDirectMessages()
+		if err != nil {
+			return inviteJoinedMsg{err: err, notiID: notificationID}
+		}
+		for _, conv := range conversations {
+			if conv.Chatroom.Id == chatroomID {
+				_ = apiClient.DeleteNotification(notificationID)
+				return inviteJoinedMsg{room: directChatroom(conv), notiID: notificationID}
+			}
+		}
+		return inviteJoinedMsg{err: fmt.Errorf("conversation no longer available"), notiID: notificationID}
+	}
+}
+
 func (m NotificationsModel) findNotificationIndex(notiID uint) int {
 	items := m.notifications.Items()
 	for i, it := range items {
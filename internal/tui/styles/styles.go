@@ -50,17 +50,51 @@ var (
 	ListItemTitleStyle         = lipgloss.NewStyle()
 	ListItemTitleSelectedStyle = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
 	ListItemMetaStyle          = lipgloss.NewStyle().Foreground(textMutedColor)
+	UnreadBadgeStyle           = lipgloss.NewStyle().Bold(true).Foreground(secondaryColor)
+	MentionBadgeStyle          = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
 
 	// Conversation/message area
 	ConversationWrapperStyle = lipgloss.NewStyle() // width will be applied by views
 	MessageContainerStyle    = lipgloss.NewStyle().MarginBottom(1)
+	MessageSelectedStyle     = MessageContainerStyle.Copy().BorderLeft(true).BorderStyle(lipgloss.NormalBorder()).BorderForeground(primaryColor).PaddingLeft(1)
 	MessageBubbleStyle       = lipgloss.NewStyle() // no border/background
 	MessageBubbleSelfStyle   = MessageBubbleStyle.Foreground(primaryColor)
 	MessageAuthorStyle       = lipgloss.NewStyle().Bold(true)
 	MessageAuthorSelfStyle   = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
 	MessageTimestampStyle    = lipgloss.NewStyle().Foreground(textMutedColor)
+	MessageTombstoneStyle    = lipgloss.NewStyle().Foreground(textMutedColor).Italic(true)
+	ActionMarkerStyle        = lipgloss.NewStyle().Bold(true).Foreground(secondaryColor)
+	SearchHighlightStyle     = lipgloss.NewStyle().Bold(true).Foreground(primaryColor)
+	PinMarkerStyle           = lipgloss.NewStyle().Foreground(secondaryColor)
+	ExpiryBadgeStyle         = lipgloss.NewStyle().Foreground(dangerColor)
+	ReactionStyle            = lipgloss.NewStyle().Foreground(textMutedColor)
+	AttachmentStyle          = lipgloss.NewStyle().Foreground(secondaryColor)
+	AttachmentPreviewStyle   = lipgloss.NewStyle().Foreground(textMutedColor).BorderLeft(true).BorderStyle(lipgloss.NormalBorder()).BorderForeground(textMutedColor).PaddingLeft(1)
+	ReactionSelfStyle        = lipgloss.NewStyle().Foreground(secondaryColor).Bold(true)
+	PollBarStyle             = lipgloss.NewStyle().Foreground(secondaryColor)
+	PollBarEmptyStyle        = lipgloss.NewStyle().Foreground(textMutedColor)
+	PollOptionSelfStyle      = lipgloss.NewStyle().Foreground(secondaryColor).Bold(true)
 	MessageContentStyle      = lipgloss.NewStyle()
+	MentionStyle             = lipgloss.NewStyle().Foreground(secondaryColor)
+	MentionSelfStyle         = lipgloss.NewStyle().Bold(true).Foreground(primaryColor).Underline(true)
 	DateDividerStyle         = lipgloss.NewStyle().Foreground(textMutedColor).Align(lipgloss.Center)
+	NewMessagesDividerStyle  = lipgloss.NewStyle().Foreground(dangerColor).Align(lipgloss.Center)
+
+	// Rich text inside messages
+	MarkupBoldStyle      = lipgloss.NewStyle().Bold(true)
+	MarkupItalicStyle    = lipgloss.NewStyle().Italic(true)
+	InlineCodeStyle      = lipgloss.NewStyle().Foreground(successColor)
+	CodeBlockStyle       = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(textMutedColor).Padding(0, 1)
+	CodeLanguageStyle    = lipgloss.NewStyle().Foreground(textMutedColor).Italic(true)
+	CodeKeywordStyle     = lipgloss.NewStyle().Foreground(primaryColor)
+	CodeStringStyle      = lipgloss.NewStyle().Foreground(successColor)
+	CodeNumberStyle      = lipgloss.NewStyle().Foreground(secondaryColor)
+	CodeCommentStyle     = lipgloss.NewStyle().Foreground(textMutedColor).Italic(true)
+	QuoteBarStyle        = lipgloss.NewStyle().Foreground(textMutedColor)
+	QuoteTextStyle       = lipgloss.NewStyle().Foreground(textMutedColor)
+	ListMarkerStyle      = lipgloss.NewStyle().Foreground(secondaryColor)
+	SpoilerStyle         = lipgloss.NewStyle().Foreground(textMutedColor)
+	SpoilerRevealedStyle = lipgloss.NewStyle().Underline(true)
 
 	// Sidebar (members)
 	SidebarStyle               = lipgloss.NewStyle().Width(30)
@@ -70,6 +104,11 @@ var (
 	ParticipantBadgeOwnerStyle = ParticipantBadgeStyle.Foreground(primaryColor)
 	ParticipantBadgeAdminStyle = ParticipantBadgeStyle.Foreground(secondaryColor)
 	ParticipantBadgeYouStyle   = ParticipantBadgeStyle.Foreground(successColor)
+	ParticipantBadgeMutedStyle = ParticipantBadgeStyle.Foreground(dangerColor)
+
+	// Thread pane (shares the sidebar slot)
+	ThreadPaneStyle      = lipgloss.NewStyle().BorderLeft(true).BorderStyle(lipgloss.NormalBorder()).BorderForeground(textMutedColor).PaddingLeft(1)
+	ThreadReplyHintStyle = lipgloss.NewStyle().Foreground(secondaryColor)
 
 	// Inputs simplified: no borders/backgrounds
 	InputAreaStyle          = lipgloss.NewStyle()
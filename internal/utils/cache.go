@@ -1,12 +1,34 @@
 package utils
 
 import (
+	"fmt"
 	"github.com/patrickmn/go-cache"
+	"strings"
 	"time"
 )
 
 var MembershipCache = cache.New(time.Minute * 5, time.Second * 30)
 
+// MembershipKey is the MembershipCache key of a user's membership of a room.
+func MembershipKey(userID, chatroomID any) string {
+	return fmt.Sprintf("membership:%v:%v", userID, chatroomID)
+}
+
+// ForgetMembership drops a cached membership, so the next request sees a changed role.
+func ForgetMembership(userID, chatroomID any) {
+	MembershipCache.Delete(MembershipKey(userID, chatroomID))
+}
+
+// ForgetRoomMemberships drops every cached membership of a room, after one of its roles changes.
+func ForgetRoomMemberships(chatroomID any) {
+	suffix := fmt.Sprintf(":%v", chatroomID)
+	for key := range MembershipCache.Items() {
+		if strings.HasSuffix(key, suffix) {
+			MembershipCache.Delete(key)
+		}
+	}
+}
+
 var AuthCache = cache.New(time.Minute * 5, time.Second) 
 
 var chatroomMessagesCache = cache.New(time.Minute*5, time.Second*30)
@@ -5,14 +5,23 @@ import (
 	"github.com/Wal-20/cli-chat-app/internal/config"
 	"github.com/Wal-20/cli-chat-app/internal/cron"
 	"log"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	err := config.InitDB()
 	if err != nil {
 		log.Fatal("DB not initialized")
 	}
 
-	api.NewServer()
+	// NewServer blocks, so background jobs have to start first.
 	cron.StartCronJobs()
+	api.NewServer()
 }